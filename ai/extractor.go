@@ -1,6 +1,7 @@
 package ai
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -25,7 +26,7 @@ func NewCompetitorExtractor(llmClient *LLMClient) *CompetitorExtractor {
 }
 
 // ExtractCompetitors 从内容中提取竞品
-func (e *CompetitorExtractor) ExtractCompetitors(topic, content string) ([]CompetitorInfo, error) {
+func (e *CompetitorExtractor) ExtractCompetitors(ctx context.Context, topic, content string) ([]CompetitorInfo, error) {
 	systemPrompt := `你是一位专业的市场研究分析师。请从提供的文章内容中提取所有相关产品/工具的名称。
 
 要求：
@@ -49,12 +50,103 @@ func (e *CompetitorExtractor) ExtractCompetitors(topic, content string) ([]Compe
 
 请提取相关竞品。`, topic, content)
 
-	response, err := e.llmClient.CompletionWithJSON(systemPrompt, userPrompt)
+	response, err := e.llmClient.CompletionWithJSON(ctx, systemPrompt, userPrompt)
 	if err != nil {
 		return nil, err
 	}
 
-	// 解析结果
+	return parseCompetitorInfos(response)
+}
+
+// ProductProfile 产品定位，用于从种子产品推断品类
+type ProductProfile struct {
+	Category string   `json:"category"` // 产品品类，如"项目管理工具"
+	Keywords []string `json:"keywords"` // 核心功能/定位关键词
+	Audience string   `json:"audience"` // 目标用户
+	Summary  string   `json:"summary"`  // 一句话介绍
+}
+
+// ProfileProduct 从产品官网内容推断产品品类和定位
+func (e *CompetitorExtractor) ProfileProduct(ctx context.Context, name, content string) (*ProductProfile, error) {
+	systemPrompt := `你是一位专业的市场研究分析师。请根据产品官网内容判断该产品所属的品类和定位。
+
+要求：
+1. category 使用通用的品类名称（如"项目管理工具"、"在线设计工具"），不要包含产品名
+2. keywords 给出3-6个核心功能或定位关键词
+3. 按照JSON格式输出
+
+输出格式：
+{
+    "category": "品类",
+    "keywords": ["关键词1", "关键词2"],
+    "audience": "目标用户",
+    "summary": "一句话介绍"
+}`
+
+	userPrompt := fmt.Sprintf(`产品：%s
+
+官网内容：
+%s
+
+请判断产品品类和定位。`, name, content)
+
+	response, err := e.llmClient.CompletionWithJSON(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonBytes, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+
+	var profile ProductProfile
+	if err := json.Unmarshal(jsonBytes, &profile); err != nil {
+		return nil, err
+	}
+	if profile.Category == "" {
+		return nil, fmt.Errorf("响应中缺少品类")
+	}
+
+	return &profile, nil
+}
+
+// ExtractAlternatives 从内容中提取种子产品的替代品，reason说明与种子产品的相似之处
+func (e *CompetitorExtractor) ExtractAlternatives(ctx context.Context, seed, content string) ([]CompetitorInfo, error) {
+	systemPrompt := `你是一位专业的市场研究分析师。请从提供的文章内容中提取可以替代种子产品的同类产品。
+
+要求：
+1. 只提取明确提到的产品名称，不要臆测，不要包含种子产品本身
+2. 排除通用名词（如"AI工具"、"软件"等）和只是集成/插件关系的产品
+3. confidence 表示与种子产品的相似程度（0-1）：功能和目标用户都相同为高，只有部分功能重叠为低
+4. reason 用一句话说明与种子产品的相似之处（如"同样面向小团队的看板式任务管理"）
+5. 按照JSON格式输出
+
+输出格式：
+{
+    "competitors": [
+        {"name": "产品名", "confidence": 0.9, "reason": "与种子产品相似之处"}
+    ]
+}`
+
+	userPrompt := fmt.Sprintf(`种子产品：
+%s
+
+文章内容：
+%s
+
+请提取种子产品的替代品。`, seed, content)
+
+	response, err := e.llmClient.CompletionWithJSON(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseCompetitorInfos(response)
+}
+
+// parseCompetitorInfos 解析LLM返回的竞品列表
+func parseCompetitorInfos(response map[string]interface{}) ([]CompetitorInfo, error) {
 	competitorsData, ok := response["competitors"].([]interface{})
 	if !ok {
 		return nil, fmt.Errorf("响应格式错误")
@@ -138,7 +230,7 @@ func NewProductInfoExtractor(llmClient *LLMClient) *ProductInfoExtractor {
 }
 
 // Extract 提取产品信息
-func (e *ProductInfoExtractor) Extract(content string) (*ProductInfo, error) {
+func (e *ProductInfoExtractor) Extract(ctx context.Context, content string) (*ProductInfo, error) {
 	systemPrompt := `你是一位专业的产品分析师。请从以下内容中提取竞品的产品信息。
 
 请按照以下JSON格式输出：
@@ -184,7 +276,7 @@ func (e *ProductInfoExtractor) Extract(content string) (*ProductInfo, error) {
 
 	userPrompt := fmt.Sprintf(`内容：\n%s\n\n请提取产品信息。`, content)
 
-	response, err := e.llmClient.CompletionWithJSON(systemPrompt, userPrompt)
+	response, err := e.llmClient.CompletionWithJSON(ctx, systemPrompt, userPrompt)
 	if err != nil {
 		return nil, err
 	}
@@ -248,7 +340,7 @@ func NewSWOTAnalyzer(llmClient *LLMClient) *SWOTAnalyzer {
 }
 
 // Analyze 进行SWOT分析
-func (a *SWOTAnalyzer) Analyze(competitorName string, productInfo *ProductInfo, marketContext string) (*SWOTAnalysis, error) {
+func (a *SWOTAnalyzer) Analyze(ctx context.Context, competitorName string, productInfo *ProductInfo, marketContext string) (*SWOTAnalysis, error) {
 	systemPrompt := `你是一位专业的战略分析师。请对给定的竞品进行SWOT分析。
 
 输出JSON格式：
@@ -282,7 +374,7 @@ func (a *SWOTAnalyzer) Analyze(competitorName string, productInfo *ProductInfo,
 
 请进行SWOT分析。`, competitorName, string(productInfoJSON), marketContext)
 
-	response, err := a.llmClient.CompletionWithJSON(systemPrompt, userPrompt)
+	response, err := a.llmClient.CompletionWithJSON(ctx, systemPrompt, userPrompt)
 	if err != nil {
 		return nil, err
 	}
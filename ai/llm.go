@@ -2,6 +2,8 @@ package ai
 
 import (
 	"bytes"
+	"competitive-analyzer/breaker"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -17,7 +19,20 @@ type LLMClient struct {
 	Model       string
 	Temperature float64
 	MaxTokens   int
-	BaseURL     string // 自定义API地址（支持DeepSeek、Ollama等）
+	BaseURL     string           // 自定义API地址（支持DeepSeek、Ollama等）
+	Timeout     time.Duration    // 单次请求超时，调用方还可以通过ctx提前取消
+	Breaker     *breaker.Breaker // LLM服务的熔断器，为nil时不限制
+}
+
+// StatusError LLM服务返回的错误状态码
+type StatusError struct {
+	Provider   string // API/Ollama
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s返回错误 %d: %s", e.Provider, e.StatusCode, e.Body)
 }
 
 // ChatMessage 聊天消息
@@ -62,21 +77,62 @@ func NewLLMClient(apiKey, model string, temperature float64, maxTokens int, base
 		Temperature: temperature,
 		MaxTokens:   maxTokens,
 		BaseURL:     baseURL,
+		Timeout:     10 * time.Minute,
 	}
 }
 
-// Chat 发送聊天请求
-func (c *LLMClient) Chat(messages []ChatMessage) (string, error) {
+// httpClient 返回带超时的HTTP客户端
+func (c *LLMClient) httpClient() *http.Client {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Minute
+	}
+	return &http.Client{Timeout: timeout}
+}
+
+// Chat 发送聊天请求，LLM服务连续失败后熔断，冷却期内直接返回错误
+func (c *LLMClient) Chat(ctx context.Context, messages []ChatMessage) (string, error) {
 	if c.APIKey == "" {
 		return "", errors.New("API Key未配置")
 	}
+	if err := c.Breaker.Allow(); err != nil {
+		return "", fmt.Errorf("LLM服务暂不可用: %w", err)
+	}
 
+	var content string
+	var err error
 	// 检测是否使用Ollama
 	if strings.Contains(c.BaseURL, "localhost:11434") || c.APIKey == "ollama" {
-		return c.chatWithOllama(messages)
+		content, err = c.chatWithOllama(ctx, messages)
+	} else {
+		content, err = c.chatWithAPI(ctx, messages)
 	}
 
-	// 云端API请求
+	switch {
+	case err == nil || !isServiceFailure(err):
+		c.Breaker.Success()
+	case ctx.Err() == nil:
+		c.Breaker.Failure(err)
+	}
+	return content, err
+}
+
+// isServiceFailure 是否是LLM服务本身的问题：连接失败、超时、5xx、限流或鉴权失败；
+// 其他4xx（如请求过长）说明服务正常
+func isServiceFailure(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode >= 400 && statusErr.StatusCode < 500 {
+		switch statusErr.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+// chatWithAPI OpenAI兼容的云端API请求
+func (c *LLMClient) chatWithAPI(ctx context.Context, messages []ChatMessage) (string, error) {
 	request := ChatRequest{
 		Model:       c.Model,
 		Messages:    messages,
@@ -100,7 +156,7 @@ func (c *LLMClient) Chat(messages []ChatMessage) (string, error) {
 		}
 	}
 
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("创建请求失败: %w", err)
 	}
@@ -108,9 +164,8 @@ func (c *LLMClient) Chat(messages []ChatMessage) (string, error) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.APIKey)
 
-	// 增加超时时间，特别是对于推理模型（如DeepSeek-R1）
-	client := &http.Client{Timeout: 12000 * time.Second} // 20分钟超时
-	resp, err := client.Do(req)
+	// 推理模型（如DeepSeek-R1）较慢，超时时间通过LLM_TIMEOUT_SECONDS配置
+	resp, err := c.httpClient().Do(req)
 	if err != nil {
 		return "", fmt.Errorf("请求失败: %w", err)
 	}
@@ -122,7 +177,7 @@ func (c *LLMClient) Chat(messages []ChatMessage) (string, error) {
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API返回错误 %d: %s", resp.StatusCode, string(body))
+		return "", &StatusError{Provider: "API", StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	var response ChatResponse
@@ -138,7 +193,7 @@ func (c *LLMClient) Chat(messages []ChatMessage) (string, error) {
 }
 
 // chatWithOllama Ollama专用请求
-func (c *LLMClient) chatWithOllama(messages []ChatMessage) (string, error) {
+func (c *LLMClient) chatWithOllama(ctx context.Context, messages []ChatMessage) (string, error) {
 	type OllamaRequest struct {
 		Model    string                 `json:"model"`
 		Messages []ChatMessage          `json:"messages"`
@@ -163,15 +218,14 @@ func (c *LLMClient) chatWithOllama(messages []ChatMessage) (string, error) {
 
 	apiURL := c.BaseURL + "/api/chat"
 
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("创建请求失败: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 12000 * time.Second} // Ollama可能较慢，增加超时时间
-	resp, err := client.Do(req)
+	resp, err := c.httpClient().Do(req)
 	if err != nil {
 		return "", fmt.Errorf("请求Ollama失败: %w (请确保Ollama服务正在运行)", err)
 	}
@@ -183,7 +237,7 @@ func (c *LLMClient) chatWithOllama(messages []ChatMessage) (string, error) {
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("Ollama返回错误 %d: %s", resp.StatusCode, string(body))
+		return "", &StatusError{Provider: "Ollama", StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	// Ollama响应格式
@@ -206,13 +260,13 @@ func (c *LLMClient) chatWithOllama(messages []ChatMessage) (string, error) {
 }
 
 // CompletionWithJSON 使用JSON格式响应
-func (c *LLMClient) CompletionWithJSON(systemPrompt, userPrompt string) (map[string]interface{}, error) {
+func (c *LLMClient) CompletionWithJSON(ctx context.Context, systemPrompt, userPrompt string) (map[string]interface{}, error) {
 	messages := []ChatMessage{
 		{Role: "system", Content: systemPrompt},
 		{Role: "user", Content: userPrompt},
 	}
 
-	response, err := c.Chat(messages)
+	response, err := c.Chat(ctx, messages)
 	if err != nil {
 		return nil, err
 	}
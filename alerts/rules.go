@@ -0,0 +1,242 @@
+package alerts
+
+import (
+	"competitive-analyzer/models"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// 规则类型
+const (
+	RulePriceChange = "price_change" // 价格变化幅度超过阈值
+	RuleChangeType  = "change_type"  // 出现指定类型的变化（如 feature_removed）
+	RuleImpactLevel = "impact_level" // 影响等级达到指定级别
+	RuleKeyword     = "keyword"      // 新内容中出现关键词
+)
+
+// 影响等级排序
+var impactRanks = map[string]int{"低": 1, "中": 2, "高": 3}
+
+// Rule 告警规则
+type Rule struct {
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	Type          string   `json:"type"`
+	CompetitorIDs []uint   `json:"competitor_ids,omitempty"` // 为空表示监控任务的所有竞品
+	Threshold     float64  `json:"threshold,omitempty"`      // price_change: 变化幅度阈值（%）
+	Direction     string   `json:"direction,omitempty"`      // price_change: up/down/any，默认any
+	ChangeTypes   []string `json:"change_types,omitempty"`   // change_type: 匹配的变化类型
+	ImpactLevel   string   `json:"impact_level,omitempty"`   // impact_level: 达到该等级及以上（高/中/低）
+	Keywords      []string `json:"keywords,omitempty"`       // keyword: 任一关键词出现即触发
+}
+
+// Change 待评估的变化
+type Change struct {
+	Log            *models.ChangeLog
+	CompetitorName string
+	Text           string // 新增的内容（用于关键词匹配）
+}
+
+// Match 触发的规则
+type Match struct {
+	Rule    Rule
+	Message string
+}
+
+// ParseRules 解析并校验监控任务的告警规则，存储格式 {"rules": [...]}
+func ParseRules(data models.JSONB) ([]Rule, error) {
+	raw, ok := data["rules"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("告警规则格式错误: %w", err)
+	}
+
+	var rules []Rule
+	if err := json.Unmarshal(encoded, &rules); err != nil {
+		return nil, fmt.Errorf("告警规则格式错误: %w", err)
+	}
+
+	if err := Validate(rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// EncodeRules 将规则转换为存储格式
+func EncodeRules(rules []Rule) models.JSONB {
+	encoded, _ := json.Marshal(rules)
+
+	var list []interface{}
+	json.Unmarshal(encoded, &list)
+	return models.JSONB{"rules": list}
+}
+
+// Validate 校验规则参数，并为未命名的规则生成ID
+func Validate(rules []Rule) error {
+	ids := make(map[string]bool)
+
+	for i := range rules {
+		rule := &rules[i]
+		if rule.ID == "" {
+			rule.ID = fmt.Sprintf("rule_%d", i+1)
+		}
+		if ids[rule.ID] {
+			return fmt.Errorf("告警规则ID重复: %s", rule.ID)
+		}
+		ids[rule.ID] = true
+
+		if err := validateRule(rule); err != nil {
+			return fmt.Errorf("告警规则 %s 无效: %w", rule.ID, err)
+		}
+	}
+	return nil
+}
+
+func validateRule(rule *Rule) error {
+	switch rule.Type {
+	case RulePriceChange:
+		if rule.Threshold < 0 {
+			return fmt.Errorf("threshold 不能为负数")
+		}
+		switch rule.Direction {
+		case "":
+			rule.Direction = "any"
+		case "up", "down", "any":
+		default:
+			return fmt.Errorf("direction 只能是 up/down/any")
+		}
+	case RuleChangeType:
+		if len(rule.ChangeTypes) == 0 {
+			return fmt.Errorf("change_types 不能为空")
+		}
+	case RuleImpactLevel:
+		if _, ok := impactRanks[rule.ImpactLevel]; !ok {
+			return fmt.Errorf("impact_level 只能是 高/中/低")
+		}
+	case RuleKeyword:
+		keywords := rule.Keywords[:0]
+		for _, kw := range rule.Keywords {
+			if kw = strings.TrimSpace(kw); kw != "" {
+				keywords = append(keywords, kw)
+			}
+		}
+		if len(keywords) == 0 {
+			return fmt.Errorf("keywords 不能为空")
+		}
+		rule.Keywords = keywords
+	case "":
+		return fmt.Errorf("缺少 type")
+	default:
+		return fmt.Errorf("未知的规则类型 %s（可选 price_change/change_type/impact_level/keyword）", rule.Type)
+	}
+	return nil
+}
+
+// Evaluate 返回变化触发的所有规则
+func Evaluate(rules []Rule, change *Change) []Match {
+	var matches []Match
+	for _, rule := range rules {
+		if !rule.appliesTo(change.Log.CompetitorID) {
+			continue
+		}
+		if detail, ok := rule.match(change); ok {
+			matches = append(matches, Match{Rule: rule, Message: describe(change, detail)})
+		}
+	}
+	return matches
+}
+
+func (r *Rule) appliesTo(competitorID uint) bool {
+	if len(r.CompetitorIDs) == 0 {
+		return true
+	}
+	for _, id := range r.CompetitorIDs {
+		if id == competitorID {
+			return true
+		}
+	}
+	return false
+}
+
+// match 判断规则是否触发，返回触发原因
+func (r *Rule) match(change *Change) (string, bool) {
+	changeLog := change.Log
+
+	switch r.Type {
+	case RulePriceChange:
+		// 免费改为收费视为上涨，超过任何阈值
+		if changeLog.ChangeType == "free_to_paid" {
+			if r.Direction == "down" {
+				return "", false
+			}
+			return "免费改为收费", true
+		}
+		// 没有幅度（如免费套餐只改了计费周期）时不按幅度触发
+		if changeLog.ChangeType != "price_change" || changeLog.ChangePercent == nil {
+			return "", false
+		}
+		percent := *changeLog.ChangePercent
+		if (r.Direction == "up" && percent <= 0) || (r.Direction == "down" && percent >= 0) {
+			return "", false
+		}
+		if math.Abs(percent) <= r.Threshold {
+			return "", false
+		}
+		return fmt.Sprintf("价格变化 %+.1f%%，超过 %.1f%%", percent, r.Threshold), true
+
+	case RuleChangeType:
+		for _, t := range r.ChangeTypes {
+			if t == changeLog.ChangeType {
+				return "变化类型 " + t, true
+			}
+		}
+
+	case RuleImpactLevel:
+		if impactRanks[changeLog.ImpactLevel] >= impactRanks[r.ImpactLevel] {
+			return "影响等级 " + changeLog.ImpactLevel, true
+		}
+
+	case RuleKeyword:
+		text := strings.ToLower(change.Text)
+		for _, kw := range r.Keywords {
+			if strings.Contains(text, strings.ToLower(kw)) {
+				return "出现关键词 " + kw, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// describe 生成告警消息
+func describe(change *Change, reason string) string {
+	changeLog := change.Log
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s", change.CompetitorName, reason)
+	if changeLog.FieldName != "" {
+		fmt.Fprintf(&b, "：%s", changeLog.FieldName)
+	}
+	if changeLog.OldValue != "" || changeLog.NewValue != "" {
+		fmt.Fprintf(&b, "（%s → %s）", excerpt(changeLog.OldValue), excerpt(changeLog.NewValue))
+	}
+	return b.String()
+}
+
+func excerpt(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if s == "" {
+		return "无"
+	}
+	runes := []rune(s)
+	if len(runes) > 80 {
+		return string(runes[:80]) + "..."
+	}
+	return s
+}
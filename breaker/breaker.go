@@ -0,0 +1,222 @@
+// Package breaker 为外部服务（爬虫API、搜索引擎、LLM）提供熔断器：
+// 连续失败达到阈值后熔断一段时间，期间直接拒绝请求；冷却后放行一个探测请求，成功则恢复，失败则继续熔断
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// State 熔断器状态
+type State string
+
+const (
+	StateClosed   State = "closed"    // 正常
+	StateOpen     State = "open"      // 熔断中，拒绝请求
+	StateHalfOpen State = "half_open" // 冷却结束，正在探测
+)
+
+// OpenError 熔断中被拒绝的请求
+type OpenError struct {
+	Name    string
+	RetryAt time.Time // 下一次允许探测的时间
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("%s 已熔断，%s 后重试", e.Name, e.RetryAt.Format("15:04:05"))
+}
+
+// Breaker 一个外部服务的熔断器，nil时不做限制
+type Breaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+
+	mu          sync.Mutex
+	state       State
+	consecutive int       // 连续失败次数
+	openedAt    time.Time // 最近一次熔断（或开始探测）的时间
+	successes   int64
+	failures    int64
+	lastError   string
+	lastFailure time.Time
+	lastSuccess time.Time
+}
+
+// Status 熔断器的当前状态，用于健康检查
+type Status struct {
+	Name                string     `json:"name"`
+	State               State      `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Successes           int64      `json:"successes"`
+	Failures            int64      `json:"failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"`
+	LastSuccessAt       *time.Time `json:"last_success_at,omitempty"`
+	RetryAt             *time.Time `json:"retry_at,omitempty"` // 熔断中时下一次探测的时间
+}
+
+// Allow 是否允许发起请求：熔断中返回*OpenError；冷却结束后只放行一个探测请求，
+// 探测一直没有结果（如调用方被取消）时，再过一个冷却期放行下一个
+func (b *Breaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateClosed {
+		return nil
+	}
+	retryAt := b.openedAt.Add(b.cooldown)
+	if time.Now().Before(retryAt) {
+		return &OpenError{Name: b.name, RetryAt: retryAt}
+	}
+	b.state = StateHalfOpen
+	b.openedAt = time.Now()
+	return nil
+}
+
+// Success 记录一次成功，探测成功时恢复正常
+func (b *Breaker) Success() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.successes++
+	b.lastSuccess = time.Now()
+	b.consecutive = 0
+	b.state = StateClosed
+}
+
+// Failure 记录一次失败，连续失败达到阈值或探测失败时熔断
+func (b *Breaker) Failure(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.consecutive++
+	b.lastFailure = time.Now()
+	if err != nil {
+		b.lastError = errorMessage(err)
+	}
+	if b.state == StateHalfOpen || b.consecutive >= b.threshold {
+		b.state = StateOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// errorMessage 最近错误的文字（会出现在/health中）：请求地址可能带有API Key等凭证，
+// 遇到*url.Error时只保留操作和底层错误，不保留地址
+func errorMessage(err error) string {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err.Error()
+	}
+	message := urlErr.Op + ": " + urlErr.Err.Error()
+	if outer := err.Error(); outer != urlErr.Error() {
+		// 外层包装的说明（如"Google搜索失败: "）保留，只替换其中的url.Error部分
+		message = strings.Replace(outer, urlErr.Error(), message, 1)
+	}
+	return message
+}
+
+// Status 当前状态
+func (b *Breaker) Status() Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := Status{
+		Name:                b.name,
+		State:               b.state,
+		ConsecutiveFailures: b.consecutive,
+		Successes:           b.successes,
+		Failures:            b.failures,
+		LastError:           b.lastError,
+	}
+	if !b.lastFailure.IsZero() {
+		lastFailure := b.lastFailure
+		status.LastFailureAt = &lastFailure
+	}
+	if !b.lastSuccess.IsZero() {
+		lastSuccess := b.lastSuccess
+		status.LastSuccessAt = &lastSuccess
+	}
+	if b.state != StateClosed {
+		retryAt := b.openedAt.Add(b.cooldown)
+		status.RetryAt = &retryAt
+	}
+	return status
+}
+
+// Registry 按名称管理熔断器，同一服务的多个客户端共享一个熔断器
+type Registry struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+// NewRegistry 创建熔断器集合，threshold为触发熔断的连续失败次数，cooldown为熔断后到下一次探测的时间
+func NewRegistry(threshold int, cooldown time.Duration) *Registry {
+	if threshold <= 0 {
+		threshold = 5
+	}
+	if cooldown <= 0 {
+		cooldown = time.Minute
+	}
+	return &Registry{
+		threshold: threshold,
+		cooldown:  cooldown,
+		breakers:  make(map[string]*Breaker),
+	}
+}
+
+// Get 获取（不存在时创建）指定名称的熔断器，registry为nil时返回nil（不限制）
+func (r *Registry) Get(name string) *Breaker {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.breakers[name]
+	if !ok {
+		b = &Breaker{name: name, threshold: r.threshold, cooldown: r.cooldown, state: StateClosed}
+		r.breakers[name] = b
+	}
+	return b
+}
+
+// Statuses 所有熔断器的状态，按名称排序
+func (r *Registry) Statuses() []Status {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	breakers := make([]*Breaker, 0, len(r.breakers))
+	for _, b := range r.breakers {
+		breakers = append(breakers, b)
+	}
+	r.mu.Unlock()
+
+	statuses := make([]Status, len(breakers))
+	for i, b := range breakers {
+		statuses[i] = b.Status()
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
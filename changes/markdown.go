@@ -0,0 +1,378 @@
+package changes
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 超过该规模（行数乘积）的段落不做逐行LCS，退化为按行集合比较
+const maxLCSCells = 4_000_000
+
+var headingPattern = regexp.MustCompile(`^#{1,6}\s+(.+?)\s*#*\s*$`)
+
+// 价格相关的内容，命中时变化影响等级为高：英文关键词按整词匹配（plan不匹配planet、/mo不匹配/more），
+// 货币符号和"/月"类单位需要紧邻数字（"$"单独出现不算）
+var pricePattern = regexp.MustCompile(`(?i)价格|定价|套餐|收费|[¥￥$€£]\s?\d|\d\s*(?:美元|元)|` +
+	`\b(?:prices?|pricing|priced|plans?|per\s+(?:month|year|user|seat))\b|\d\s*/\s*(?:mo|month|yr|year)\b`)
+
+// Section Markdown中以标题划分的段落
+type Section struct {
+	Heading string // 标题文本，标题前的内容为空
+	Lines   []string
+}
+
+// DiffLine 行级差异
+type DiffLine struct {
+	Op   byte // ' ' 未变 '+' 新增 '-' 删除
+	Text string
+}
+
+// SectionChange 段落变化
+type SectionChange struct {
+	Heading string     `json:"heading"`
+	Kind    string     `json:"kind"` // added/removed/modified
+	Lines   []DiffLine `json:"-"`
+	Added   int        `json:"added"`
+	Removed int        `json:"removed"`
+}
+
+// MarkdownDiff 两次爬取内容的段落级差异
+type MarkdownDiff struct {
+	Sections   []SectionChange
+	Added      int // 新增行数
+	Removed    int // 删除行数
+	TotalLines int // 旧内容行数
+}
+
+// StripFrontMatter 去掉保存文件时添加的YAML元数据头
+func StripFrontMatter(content string) string {
+	if !strings.HasPrefix(content, "---\n") {
+		return content
+	}
+	if end := strings.Index(content[4:], "\n---\n"); end >= 0 {
+		return strings.TrimLeft(content[4+end+5:], "\n")
+	}
+	return content
+}
+
+// SplitSections 按标题把Markdown拆分为段落，忽略空行
+func SplitSections(markdown string) []Section {
+	sections := []Section{{}}
+	inCode := false
+
+	for _, line := range strings.Split(markdown, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+		}
+
+		if !inCode {
+			if m := headingPattern.FindStringSubmatch(line); m != nil {
+				sections = append(sections, Section{Heading: m[1]})
+				continue
+			}
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		last := &sections[len(sections)-1]
+		last.Lines = append(last.Lines, line)
+	}
+
+	if len(sections[0].Lines) == 0 {
+		sections = sections[1:]
+	}
+	return sections
+}
+
+// DiffMarkdown 按段落比较新旧Markdown，同名段落逐行比较
+func DiffMarkdown(oldContent, newContent string) *MarkdownDiff {
+	oldSections := SplitSections(oldContent)
+	newSections := SplitSections(newContent)
+
+	diff := &MarkdownDiff{}
+	for _, s := range oldSections {
+		diff.TotalLines += len(s.Lines)
+	}
+
+	// 标题重复时按出现次序区分
+	oldIndex := make(map[string]int)
+	for i, key := range sectionKeys(oldSections) {
+		oldIndex[key] = i
+	}
+	matched := make(map[int]bool)
+
+	for i, key := range sectionKeys(newSections) {
+		ns := newSections[i]
+		j, ok := oldIndex[key]
+		if !ok {
+			diff.add(SectionChange{
+				Heading: ns.Heading,
+				Kind:    "added",
+				Lines:   opLines('+', ns.Lines),
+				Added:   len(ns.Lines),
+			})
+			continue
+		}
+
+		matched[j] = true
+		lines := diffLines(oldSections[j].Lines, ns.Lines)
+		change := SectionChange{Heading: ns.Heading, Kind: "modified", Lines: lines}
+		for _, l := range lines {
+			switch l.Op {
+			case '+':
+				change.Added++
+			case '-':
+				change.Removed++
+			}
+		}
+		if change.Added > 0 || change.Removed > 0 {
+			diff.add(change)
+		}
+	}
+
+	for j, section := range oldSections {
+		if !matched[j] {
+			diff.add(SectionChange{
+				Heading: section.Heading,
+				Kind:    "removed",
+				Lines:   opLines('-', section.Lines),
+				Removed: len(section.Lines),
+			})
+		}
+	}
+
+	return diff
+}
+
+func (d *MarkdownDiff) add(change SectionChange) {
+	d.Sections = append(d.Sections, change)
+	d.Added += change.Added
+	d.Removed += change.Removed
+}
+
+// Empty 内容是否没有实质变化（只有空行或格式差异）
+func (d *MarkdownDiff) Empty() bool {
+	return len(d.Sections) == 0
+}
+
+// ChangeRatio 变化行数占旧内容的比例
+func (d *MarkdownDiff) ChangeRatio() float64 {
+	if d.TotalLines == 0 {
+		return 1
+	}
+	ratio := float64(d.Added+d.Removed) / float64(d.TotalLines)
+	if ratio > 1 {
+		ratio = 1
+	}
+	return ratio
+}
+
+// ImpactLevel 评估变化的影响等级：涉及价格或大面积改版为高，新增/删除段落或变化较多为中
+func (d *MarkdownDiff) ImpactLevel() string {
+	ratio := d.ChangeRatio()
+
+	if ratio >= 0.5 || d.touchesPricing() {
+		return "高"
+	}
+	for _, s := range d.Sections {
+		if s.Kind != "modified" {
+			return "中"
+		}
+	}
+	if ratio >= 0.2 {
+		return "中"
+	}
+	return "低"
+}
+
+func (d *MarkdownDiff) touchesPricing() bool {
+	for _, s := range d.Sections {
+		if containsPriceKeyword(s.Heading) {
+			return true
+		}
+		for _, l := range s.Lines {
+			if l.Op != ' ' && containsPriceKeyword(l.Text) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsPriceKeyword(text string) bool {
+	return pricePattern.MatchString(text)
+}
+
+// Headings 发生变化的段落标题
+func (d *MarkdownDiff) Headings() []string {
+	headings := make([]string, 0, len(d.Sections))
+	for _, s := range d.Sections {
+		heading := s.Heading
+		if heading == "" {
+			heading = "(正文开头)"
+		}
+		headings = append(headings, heading)
+	}
+	return headings
+}
+
+// Excerpts 返回第一个变化段落中删除和新增的内容摘要
+func (d *MarkdownDiff) Excerpts(maxRunes int) (oldExcerpt, newExcerpt string) {
+	if d.Empty() {
+		return "", ""
+	}
+
+	var removed, added []string
+	for _, l := range d.Sections[0].Lines {
+		switch l.Op {
+		case '-':
+			removed = append(removed, l.Text)
+		case '+':
+			added = append(added, l.Text)
+		}
+	}
+	return truncateRunes(strings.Join(removed, "\n"), maxRunes), truncateRunes(strings.Join(added, "\n"), maxRunes)
+}
+
+// 差异报告中改动行前后保留的上下文行数
+const contextLines = 2
+
+// Render 生成Markdown格式的差异报告，每个段落的改动放在diff代码块中
+func (d *MarkdownDiff) Render(title string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# 内容变化: %s\n\n", title)
+	fmt.Fprintf(&b, "- 新增行数: %d\n- 删除行数: %d\n- 变化比例: %.0f%%\n- 影响等级: %s\n\n",
+		d.Added, d.Removed, d.ChangeRatio()*100, d.ImpactLevel())
+
+	kindNames := map[string]string{"added": "新增段落", "removed": "删除段落", "modified": "修改"}
+	for i, s := range d.Sections {
+		fmt.Fprintf(&b, "## %s [%s]\n\n```diff\n", d.Headings()[i], kindNames[s.Kind])
+		skipped := false
+		for j, l := range s.Lines {
+			if !nearChange(s.Lines, j) {
+				if !skipped {
+					b.WriteString("  ...\n")
+					skipped = true
+				}
+				continue
+			}
+			skipped = false
+			fmt.Fprintf(&b, "%c %s\n", l.Op, l.Text)
+		}
+		b.WriteString("```\n\n")
+	}
+
+	return b.String()
+}
+
+// nearChange 第i行是否为改动行或在改动行附近
+func nearChange(lines []DiffLine, i int) bool {
+	for j := i - contextLines; j <= i+contextLines; j++ {
+		if j >= 0 && j < len(lines) && lines[j].Op != ' ' {
+			return true
+		}
+	}
+	return false
+}
+
+func sectionKeys(sections []Section) []string {
+	seen := make(map[string]int)
+	keys := make([]string, len(sections))
+	for i, s := range sections {
+		heading := strings.ToLower(strings.TrimSpace(s.Heading))
+		keys[i] = fmt.Sprintf("%s#%d", heading, seen[heading])
+		seen[heading]++
+	}
+	return keys
+}
+
+func opLines(op byte, lines []string) []DiffLine {
+	result := make([]DiffLine, len(lines))
+	for i, l := range lines {
+		result[i] = DiffLine{Op: op, Text: l}
+	}
+	return result
+}
+
+// diffLines 基于最长公共子序列的逐行比较
+func diffLines(a, b []string) []DiffLine {
+	if len(a)*len(b) > maxLCSCells {
+		return diffLineSets(a, b)
+	}
+
+	n, m := len(a), len(b)
+	// lcs[i][j] 为 a[i:] 和 b[j:] 的最长公共子序列长度
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var result []DiffLine
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			result = append(result, DiffLine{Op: ' ', Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, DiffLine{Op: '-', Text: a[i]})
+			i++
+		default:
+			result = append(result, DiffLine{Op: '+', Text: b[j]})
+			j++
+		}
+	}
+	result = append(result, opLines('-', a[i:])...)
+	result = append(result, opLines('+', b[j:])...)
+
+	return result
+}
+
+// diffLineSets 超大段落的近似比较：只列出新增和删除的行
+func diffLineSets(a, b []string) []DiffLine {
+	inA := make(map[string]bool, len(a))
+	for _, l := range a {
+		inA[l] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, l := range b {
+		inB[l] = true
+	}
+
+	var result []DiffLine
+	for _, l := range a {
+		if !inB[l] {
+			result = append(result, DiffLine{Op: '-', Text: l})
+		}
+	}
+	for _, l := range b {
+		if !inA[l] {
+			result = append(result, DiffLine{Op: '+', Text: l})
+		}
+	}
+	return result
+}
+
+func truncateRunes(s string, max int) string {
+	runes := []rune(s)
+	if max <= 0 || len(runes) <= max {
+		return s
+	}
+	return string(runes[:max]) + "..."
+}
@@ -0,0 +1,319 @@
+package changes
+
+import (
+	"competitive-analyzer/ai"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 结构化变化类型
+const (
+	TypePriceChange        = "price_change"
+	TypeFreeToPaid         = "free_to_paid" // 免费套餐改为收费，没有变化幅度
+	TypeTierAdded          = "tier_added"
+	TypeTierRemoved        = "tier_removed"
+	TypeFeatureAdded       = "feature_added"
+	TypeFeatureRemoved     = "feature_removed"
+	TypePricingModelChange = "pricing_model_change"
+	TypeTrialChange        = "trial_change"
+	TypeTargetUsersChange  = "target_users_change"
+)
+
+// FieldChange 产品信息的字段级变化
+type FieldChange struct {
+	ChangeType  string   `json:"change_type"`
+	FieldName   string   `json:"field_name"`
+	OldValue    string   `json:"old_value"`
+	NewValue    string   `json:"new_value"`
+	ImpactLevel string   `json:"impact_level"`      // 高/中/低
+	Percent     *float64 `json:"percent,omitempty"` // 价格变化幅度（%），原价为0时没有幅度
+}
+
+// DiffProductInfo 比较两次分析提取的产品信息，返回价格、套餐、功能等字段的变化。
+// 新一次提取的套餐、功能或目标用户为空而上次不为空时，多半是提取失败（页面没爬全、LLM输出不完整），跳过该部分，
+// 避免把每一项都记为下架
+func DiffProductInfo(oldInfo, newInfo *ai.ProductInfo) []FieldChange {
+	if oldInfo == nil || newInfo == nil {
+		return nil
+	}
+
+	var result []FieldChange
+	if !extractionMissing(len(oldInfo.Pricing.Tiers), len(newInfo.Pricing.Tiers)) {
+		result = append(result, diffPricing(oldInfo.Pricing, newInfo.Pricing)...)
+	}
+	if !extractionMissing(len(oldInfo.CoreFeatures), len(newInfo.CoreFeatures)) {
+		result = append(result, diffFeatures(oldInfo.CoreFeatures, newInfo.CoreFeatures)...)
+	}
+
+	added, removed := diffStrings(oldInfo.TargetUsers, newInfo.TargetUsers)
+	if extractionMissing(len(oldInfo.TargetUsers), len(newInfo.TargetUsers)) {
+		added, removed = nil, nil
+	}
+	if len(added) > 0 || len(removed) > 0 {
+		result = append(result, FieldChange{
+			ChangeType:  TypeTargetUsersChange,
+			FieldName:   "target_users",
+			OldValue:    strings.Join(oldInfo.TargetUsers, "、"),
+			NewValue:    strings.Join(newInfo.TargetUsers, "、"),
+			ImpactLevel: "低",
+		})
+	}
+
+	return result
+}
+
+// extractionMissing 上次提取有内容、这次为空，视为这次提取失败
+func extractionMissing(oldCount, newCount int) bool {
+	return oldCount > 0 && newCount == 0
+}
+
+func diffPricing(oldPricing, newPricing ai.PricingInfo) []FieldChange {
+	var result []FieldChange
+
+	if oldPricing.Model != "" && newPricing.Model != "" && normalizeName(oldPricing.Model) != normalizeName(newPricing.Model) {
+		result = append(result, FieldChange{
+			ChangeType:  TypePricingModelChange,
+			FieldName:   "pricing.model",
+			OldValue:    oldPricing.Model,
+			NewValue:    newPricing.Model,
+			ImpactLevel: "高",
+		})
+	}
+
+	oldTiers := make(map[string]ai.PricingTier)
+	for _, tier := range oldPricing.Tiers {
+		oldTiers[normalizeName(tier.Name)] = tier
+	}
+	seen := make(map[string]bool)
+
+	for _, tier := range newPricing.Tiers {
+		key := normalizeName(tier.Name)
+		seen[key] = true
+
+		oldTier, ok := oldTiers[key]
+		if !ok {
+			result = append(result, FieldChange{
+				ChangeType:  TypeTierAdded,
+				FieldName:   fmt.Sprintf("pricing.tiers[%s]", tier.Name),
+				NewValue:    formatPrice(tier),
+				ImpactLevel: "中",
+			})
+			continue
+		}
+
+		if change, ok := diffTierPrice(oldTier, tier); ok {
+			result = append(result, change)
+		}
+
+		added, removed := diffStrings(oldTier.Features, tier.Features)
+		for _, f := range added {
+			result = append(result, FieldChange{
+				ChangeType:  TypeFeatureAdded,
+				FieldName:   fmt.Sprintf("pricing.tiers[%s].features", tier.Name),
+				NewValue:    f,
+				ImpactLevel: "低",
+			})
+		}
+		for _, f := range removed {
+			result = append(result, FieldChange{
+				ChangeType:  TypeFeatureRemoved,
+				FieldName:   fmt.Sprintf("pricing.tiers[%s].features", tier.Name),
+				OldValue:    f,
+				ImpactLevel: "中",
+			})
+		}
+	}
+
+	for _, tier := range oldPricing.Tiers {
+		if !seen[normalizeName(tier.Name)] {
+			result = append(result, FieldChange{
+				ChangeType:  TypeTierRemoved,
+				FieldName:   fmt.Sprintf("pricing.tiers[%s]", tier.Name),
+				OldValue:    formatPrice(tier),
+				ImpactLevel: "高",
+			})
+		}
+	}
+
+	if change, ok := diffTrial(oldPricing.Trial, newPricing.Trial); ok {
+		result = append(result, change)
+	}
+
+	return result
+}
+
+// diffTierPrice 比较同一套餐的价格和计费周期
+func diffTierPrice(oldTier, newTier ai.PricingTier) (FieldChange, bool) {
+	priceChanged := math.Abs(oldTier.Price-newTier.Price) > 0.001
+	cycleChanged := oldTier.BillingCycle != "" && newTier.BillingCycle != "" &&
+		normalizeName(oldTier.BillingCycle) != normalizeName(newTier.BillingCycle)
+	if !priceChanged && !cycleChanged {
+		return FieldChange{}, false
+	}
+
+	change := FieldChange{
+		ChangeType: TypePriceChange,
+		FieldName:  fmt.Sprintf("pricing.tiers[%s].price", newTier.Name),
+		OldValue:   formatPrice(oldTier),
+		NewValue:   formatPrice(newTier),
+	}
+
+	switch {
+	case oldTier.Price == 0 && newTier.Price > 0:
+		change.ChangeType = TypeFreeToPaid
+		change.ImpactLevel = "高"
+	case oldTier.Price == 0:
+		change.ImpactLevel = "低" // 免费套餐只改了计费周期
+	default:
+		percent := math.Round((newTier.Price-oldTier.Price)/oldTier.Price*1000) / 10
+		change.Percent = &percent
+		change.ImpactLevel = priceImpact(math.Abs(percent))
+		if cycleChanged && change.ImpactLevel == "低" {
+			change.ImpactLevel = "中"
+		}
+	}
+
+	return change, true
+}
+
+// priceImpact 按价格变化幅度评估影响：≥20%为高，≥5%为中
+func priceImpact(percent float64) string {
+	switch {
+	case percent >= 20:
+		return "高"
+	case percent >= 5:
+		return "中"
+	default:
+		return "低"
+	}
+}
+
+func diffTrial(oldTrial, newTrial ai.TrialInfo) (FieldChange, bool) {
+	if oldTrial.Available == newTrial.Available && normalizeName(oldTrial.Duration) == normalizeName(newTrial.Duration) {
+		return FieldChange{}, false
+	}
+
+	impact := "低"
+	if oldTrial.Available != newTrial.Available {
+		impact = "中"
+	}
+
+	return FieldChange{
+		ChangeType:  TypeTrialChange,
+		FieldName:   "pricing.trial",
+		OldValue:    formatTrial(oldTrial),
+		NewValue:    formatTrial(newTrial),
+		ImpactLevel: impact,
+	}, true
+}
+
+// diffFeatures 比较核心功能，独有功能的增删影响更大
+func diffFeatures(oldFeatures, newFeatures []ai.FeatureInfo) []FieldChange {
+	var result []FieldChange
+
+	oldByName := make(map[string]ai.FeatureInfo)
+	for _, f := range oldFeatures {
+		oldByName[normalizeName(f.Name)] = f
+	}
+	newByName := make(map[string]ai.FeatureInfo)
+	for _, f := range newFeatures {
+		newByName[normalizeName(f.Name)] = f
+	}
+
+	for _, f := range newFeatures {
+		if _, ok := oldByName[normalizeName(f.Name)]; ok {
+			continue
+		}
+		impact := "中"
+		if f.Unique {
+			impact = "高"
+		}
+		result = append(result, FieldChange{
+			ChangeType:  TypeFeatureAdded,
+			FieldName:   "core_features",
+			NewValue:    formatFeature(f),
+			ImpactLevel: impact,
+		})
+	}
+
+	for _, f := range oldFeatures {
+		if _, ok := newByName[normalizeName(f.Name)]; ok {
+			continue
+		}
+		impact := "中"
+		if f.Unique {
+			impact = "高"
+		}
+		result = append(result, FieldChange{
+			ChangeType:  TypeFeatureRemoved,
+			FieldName:   "core_features",
+			OldValue:    formatFeature(f),
+			ImpactLevel: impact,
+		})
+	}
+
+	return result
+}
+
+// diffStrings 比较两个字符串列表，忽略大小写、空格和标点差异
+func diffStrings(oldList, newList []string) (added, removed []string) {
+	oldSet := make(map[string]bool)
+	for _, s := range oldList {
+		oldSet[normalizeName(s)] = true
+	}
+	newSet := make(map[string]bool)
+	for _, s := range newList {
+		newSet[normalizeName(s)] = true
+	}
+
+	for _, s := range newList {
+		if !oldSet[normalizeName(s)] {
+			added = append(added, s)
+		}
+	}
+	for _, s := range oldList {
+		if !newSet[normalizeName(s)] {
+			removed = append(removed, s)
+		}
+	}
+	return added, removed
+}
+
+// normalizeName 统一名称写法，避免LLM每次输出的大小写、空格不同被当作变化
+func normalizeName(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func formatPrice(tier ai.PricingTier) string {
+	price := strconv.FormatFloat(tier.Price, 'f', -1, 64)
+	if tier.BillingCycle != "" {
+		return price + "/" + tier.BillingCycle
+	}
+	return price
+}
+
+func formatTrial(trial ai.TrialInfo) string {
+	if !trial.Available {
+		return "无试用"
+	}
+	if trial.Duration != "" {
+		return "试用 " + trial.Duration
+	}
+	return "可试用"
+}
+
+func formatFeature(f ai.FeatureInfo) string {
+	if f.Description != "" {
+		return f.Name + ": " + f.Description
+	}
+	return f.Name
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -30,14 +31,53 @@ type Config struct {
 	ReportsPath string
 
 	// 搜索配置
-	SearchCacheDays   int
-	MaxSearchResults  int
+	SearchCacheDays  int
+	MaxSearchResults int
+	SearchEngines    []string // 启用的搜索引擎及优先级，为空时启用所有已配置的引擎
+	SearxNGURL       string   // 自建SearxNG实例地址
+	SearxNGEngines   string   // SearxNG使用的上游引擎，为空时使用实例默认配置
+	SearchMode       string   // fallback：使用第一个成功的引擎（默认）；fanout：查询所有引擎并融合结果
+	QueryTemplates   string   // 按市场区分的查询模板文件，为空时使用内置模板
+	StaleArticleDays int      // 发布超过该天数的文章不用于提取竞品，0表示不过滤
 
 	// AI配置
 	LLMModel       string
 	LLMTemperature float64
 	LLMMaxTokens   int
 	LLMBaseURL     string // 支持自定义LLM API地址
+
+	// 超时配置（秒，0表示不限制）
+	LLMTimeout       int // 单次LLM请求
+	DiscoveryTimeout int // 发现阶段（搜索竞品和数据源）
+	CrawlTimeout     int // 爬取阶段
+	AnalysisTimeout  int // AI分析阶段
+
+	// 爬取节奏配置
+	CrawlConcurrency   int     // 批量爬取的默认并发数（不同主机并行，同一主机按限速排队）
+	CrawlHostRPS       float64 // 每个主机每秒最多请求数，0表示不限速
+	CrawlRespectRobots bool    // 遵守robots.txt的Disallow和Crawl-delay
+	Platforms          string  // 平台配置文件（域名、User-Agent、Referer、爬虫顺序、正文选择器、分类），为空时使用内置配置
+
+	// 登录会话配置
+	SessionEncryptionKey string // 加密保存平台登录会话（Cookie和请求头）的密钥，为空时不能上传会话
+
+	// 熔断配置（爬虫API、搜索引擎、LLM）
+	CircuitFailureThreshold int // 连续失败多少次后熔断
+	CircuitCooldown         int // 熔断后多久（秒）放行探测请求
+
+	// 任务队列配置
+	JobWorkers     int
+	JobMaxAttempts int
+
+	// 监控配置
+	MonitorCheckInterval int // 检查到期监控任务的间隔（秒）
+
+	// 邮件通知配置
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
 }
 
 var AppConfig *Config
@@ -72,12 +112,51 @@ func LoadConfig() *Config {
 		// 搜索配置
 		SearchCacheDays:  getEnvAsInt("SEARCH_CACHE_DAYS", 7),
 		MaxSearchResults: getEnvAsInt("MAX_SEARCH_RESULTS", 10),
+		SearchEngines:    getEnvAsList("SEARCH_ENGINES"),
+		SearxNGURL:       getEnv("SEARXNG_URL", ""),
+		SearxNGEngines:   getEnv("SEARXNG_ENGINES", ""),
+		SearchMode:       getEnv("SEARCH_MODE", "fallback"),
+		QueryTemplates:   getEnv("QUERY_TEMPLATES_PATH", ""),
+		StaleArticleDays: getEnvAsInt("STALE_ARTICLE_DAYS", 730),
 
 		// AI配置
 		LLMModel:       getEnv("LLM_MODEL", "gpt-4"),
 		LLMTemperature: getEnvAsFloat("LLM_TEMPERATURE", 0.3),
 		LLMMaxTokens:   getEnvAsInt("LLM_MAX_TOKENS", 4000),
 		LLMBaseURL:     getEnv("OPENAI_BASE_URL", ""), // 自定义API地址（如DeepSeek、Ollama）
+
+		// 超时配置
+		LLMTimeout:       getEnvAsInt("LLM_TIMEOUT_SECONDS", 600),
+		DiscoveryTimeout: getEnvAsInt("DISCOVERY_TIMEOUT_SECONDS", 300),
+		CrawlTimeout:     getEnvAsInt("CRAWL_TIMEOUT_SECONDS", 1800),
+		AnalysisTimeout:  getEnvAsInt("ANALYSIS_TIMEOUT_SECONDS", 1800),
+
+		// 爬取节奏配置
+		CrawlConcurrency:   getEnvAsInt("CRAWL_CONCURRENCY", 4),
+		CrawlHostRPS:       getEnvAsFloat("CRAWL_HOST_RPS", 0.5),
+		CrawlRespectRobots: getEnvAsBool("CRAWL_RESPECT_ROBOTS", true),
+		Platforms:          getEnv("PLATFORMS_PATH", ""),
+
+		// 登录会话配置
+		SessionEncryptionKey: getEnv("SESSION_ENCRYPTION_KEY", ""),
+
+		// 熔断配置
+		CircuitFailureThreshold: getEnvAsInt("CIRCUIT_FAILURE_THRESHOLD", 5),
+		CircuitCooldown:         getEnvAsInt("CIRCUIT_COOLDOWN_SECONDS", 120),
+
+		// 任务队列配置
+		JobWorkers:     getEnvAsInt("JOB_WORKERS", 2),
+		JobMaxAttempts: getEnvAsInt("JOB_MAX_ATTEMPTS", 3),
+
+		// 监控配置
+		MonitorCheckInterval: getEnvAsInt("MONITOR_CHECK_SECONDS", 60),
+
+		// 邮件通知配置
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnvAsInt("SMTP_PORT", 587),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:     getEnv("SMTP_FROM", ""),
 	}
 
 	// 创建必要的目录
@@ -96,6 +175,17 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvAsList 逗号分隔的列表，去掉空项
+func getEnvAsList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, strings.ToLower(item))
+		}
+	}
+	return list
+}
+
 func getEnvAsInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
@@ -113,3 +203,12 @@ func getEnvAsFloat(key string, defaultValue float64) float64 {
 	}
 	return defaultValue
 }
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
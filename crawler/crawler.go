@@ -2,12 +2,18 @@ package crawler
 
 import (
 	"bytes"
+	"competitive-analyzer/breaker"
+	"competitive-analyzer/platforms"
+	"competitive-analyzer/pubdate"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 )
 
 // CrawlResult 爬取结果
@@ -17,14 +23,14 @@ type CrawlResult struct {
 	Title      string            `json:"title"`
 	URL        string            `json:"url"`
 	Platform   string            `json:"platform"`
-	Method     string            `json:"method"` // firecrawl/jina/playwright
+	Method     string            `json:"method"` // firecrawl/jina/native/playwright
 	Metadata   map[string]string `json:"metadata"`
 	Error      string            `json:"error,omitempty"`
 }
 
 // Crawler 爬虫接口
 type Crawler interface {
-	Crawl(url string, platform *PlatformInfo) (*CrawlResult, error)
+	Crawl(ctx context.Context, url string, platform *platforms.Platform) (*CrawlResult, error)
 	Name() string
 }
 
@@ -37,9 +43,9 @@ func (f *FirecrawlCrawler) Name() string {
 	return "firecrawl"
 }
 
-func (f *FirecrawlCrawler) Crawl(url string, platform *PlatformInfo) (*CrawlResult, error) {
+func (f *FirecrawlCrawler) Crawl(ctx context.Context, url string, platform *platforms.Platform) (*CrawlResult, error) {
 	if f.APIKey == "" {
-		return nil, errors.New("Firecrawl API Key未配置")
+		return nil, &CrawlError{Kind: ErrKindNotConfigured, Layer: f.Name(), Message: "Firecrawl API Key未配置", Provider: true}
 	}
 
 	// Firecrawl v2 API
@@ -47,15 +53,22 @@ func (f *FirecrawlCrawler) Crawl(url string, platform *PlatformInfo) (*CrawlResu
 		"url": url,
 		"formats": []string{"markdown"},
 	}
+	// 平台配置了正文选择器和Referer时让Firecrawl只保留正文区域，并带上Referer请求
+	if len(platform.ContentSelectors) > 0 {
+		requestBody["includeTags"] = platform.ContentSelectors
+	}
+	if platform.Referer != "" {
+		requestBody["headers"] = map[string]string{"Referer": platform.Referer}
+	}
 
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
-		return nil, fmt.Errorf("构造请求失败: %w", err)
+		return nil, &CrawlError{Kind: ErrKindOther, Layer: f.Name(), Message: "构造请求失败", Err: err}
 	}
 
-	req, err := http.NewRequest("POST", "https://api.firecrawl.dev/v1/scrape", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.firecrawl.dev/v1/scrape", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
+		return nil, &CrawlError{Kind: ErrKindOther, Layer: f.Name(), Message: "创建请求失败", Err: err}
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -64,51 +77,70 @@ func (f *FirecrawlCrawler) Crawl(url string, platform *PlatformInfo) (*CrawlResu
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("请求失败: %w", err)
+		return nil, apiRequestError(f.Name(), err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
+		return nil, apiRequestError(f.Name(), err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Firecrawl返回错误: %s", string(body))
+		return nil, apiStatusError(f.Name(), resp, "Firecrawl返回错误: "+string(body))
 	}
 
 	// 解析响应
 	var result map[string]interface{}
 	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
+		return nil, &CrawlError{Kind: ErrKindOther, Layer: f.Name(), Message: "解析响应失败", Err: err}
 	}
 
 	// 检查是否成功
 	success, ok := result["success"].(bool)
 	if !ok || !success {
-		return nil, errors.New("Firecrawl爬取失败")
+		return nil, &CrawlError{Kind: ErrKindOther, Layer: f.Name(), Message: "Firecrawl爬取失败"}
 	}
 
 	// 提取数据
 	data, ok := result["data"].(map[string]interface{})
 	if !ok {
-		return nil, errors.New("Firecrawl响应格式错误")
+		return nil, &CrawlError{Kind: ErrKindOther, Layer: f.Name(), Message: "Firecrawl响应格式错误"}
 	}
 
 	markdown, _ := data["markdown"].(string)
 	title := ""
+	published := ""
+	finalURL := ""
 	if metadata, ok := data["metadata"].(map[string]interface{}); ok {
 		title, _ = metadata["title"].(string)
+		finalURL, _ = metadata["url"].(string)
+		// 目标页面的状态码
+		if status, ok := metadata["statusCode"].(float64); ok && status >= 400 {
+			return nil, statusError(f.Name(), int(status), "", fmt.Sprintf("页面返回错误: %d", int(status)), false)
+		}
+		// Firecrawl的元数据中保留了页面的<meta>，发布时间可能在这些键中（修改时间不作为发布时间）
+		for _, key := range []string{"publishedTime", "article:published_time", "datePublished"} {
+			if value, ok := metadata[key].(string); ok && value != "" {
+				if t, ok := pubdate.Parse(value, time.Now()); ok {
+					published = t.Format(time.RFC3339)
+					break
+				}
+			}
+		}
+	}
+	if published == "" {
+		if t, ok := PublishedInText(markdown, time.Now()); ok {
+			published = t.Format(time.RFC3339)
+		}
 	}
 
 	// 验证内容
-	if len(markdown) < 100 {
-		return nil, errors.New("内容过短，可能是验证页面")
+	if finalURL == url {
+		finalURL = ""
 	}
-
-	if strings.Contains(strings.ToLower(markdown), "验证") ||
-		strings.Contains(strings.ToLower(markdown), "captcha") {
-		return nil, errors.New("触发了验证码")
+	if err := checkContent(f.Name(), markdown, finalURL); err != nil {
+		return nil, err
 	}
 
 	return &CrawlResult{
@@ -118,9 +150,9 @@ func (f *FirecrawlCrawler) Crawl(url string, platform *PlatformInfo) (*CrawlResu
 		URL:      url,
 		Platform: platform.Name,
 		Method:   "firecrawl",
-		Metadata: map[string]string{
+		Metadata: withPublishedAt(map[string]string{
 			"api": "firecrawl-v2",
-		},
+		}, published),
 	}, nil
 }
 
@@ -131,51 +163,65 @@ func (j *JinaCrawler) Name() string {
 	return "jina"
 }
 
-func (j *JinaCrawler) Crawl(url string, platform *PlatformInfo) (*CrawlResult, error) {
+func (j *JinaCrawler) Crawl(ctx context.Context, url string, platform *platforms.Platform) (*CrawlResult, error) {
 	// Jina Reader API
 	jinaURL := "https://r.jina.ai/" + url
 
-	req, err := http.NewRequest("GET", jinaURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", jinaURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
+		return nil, &CrawlError{Kind: ErrKindOther, Layer: j.Name(), Message: "创建请求失败", Err: err}
 	}
 
 	req.Header.Set("Accept", "text/markdown")
 	req.Header.Set("User-Agent", platform.UserAgent)
+	if len(platform.ContentSelectors) > 0 {
+		req.Header.Set("X-Target-Selector", strings.Join(platform.ContentSelectors, ", "))
+	}
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("请求失败: %w", err)
+		return nil, apiRequestError(j.Name(), err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Jina返回错误: %d", resp.StatusCode)
+		return nil, apiStatusError(j.Name(), resp, fmt.Sprintf("Jina返回错误: %d", resp.StatusCode))
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
+		return nil, apiRequestError(j.Name(), err)
 	}
 
 	markdown := string(body)
 
 	// 验证内容
-	if len(markdown) < 100 {
-		return nil, errors.New("内容过短")
+	if err := checkContent(j.Name(), markdown, ""); err != nil {
+		return nil, err
 	}
 
-	// 从markdown中提取标题（通常第一行是# 标题）
+	// 从markdown中提取标题（通常第一行是# 标题）和Jina给出的发布时间（Published Time: ...）
 	title := ""
+	published := ""
 	lines := strings.Split(markdown, "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Published Time:") && published == "" {
+			if t, ok := pubdate.Parse(strings.TrimPrefix(line, "Published Time:"), time.Now()); ok {
+				published = t.Format(time.RFC3339)
+			}
+		}
 		if strings.HasPrefix(line, "# ") {
 			title = strings.TrimPrefix(line, "# ")
 			break
 		}
 	}
+	if published == "" {
+		if t, ok := PublishedInText(markdown, time.Now()); ok {
+			published = t.Format(time.RFC3339)
+		}
+	}
 
 	return &CrawlResult{
 		Success:  true,
@@ -184,19 +230,32 @@ func (j *JinaCrawler) Crawl(url string, platform *PlatformInfo) (*CrawlResult, e
 		URL:      url,
 		Platform: platform.Name,
 		Method:   "jina",
-		Metadata: map[string]string{
+		Metadata: withPublishedAt(map[string]string{
 			"api": "jina-reader",
-		},
+		}, published),
 	}, nil
 }
 
+// withPublishedAt 有发布时间时加入元数据
+func withPublishedAt(metadata map[string]string, published string) map[string]string {
+	if published != "" {
+		metadata["published_at"] = published
+	}
+	return metadata
+}
+
 // ThreeLayerCrawler 三层策略爬虫
 type ThreeLayerCrawler struct {
-	Crawlers []Crawler
+	Crawlers  []Crawler
+	Scheduler *HostScheduler              // 按主机限速和检查robots.txt，为nil时不限制
+	Breakers  map[string]*breaker.Breaker // 按爬虫名称的熔断器，第三方API连续失败时暂时跳过该层
+	Platforms *platforms.Registry         // 平台配置（User-Agent、Referer、爬虫顺序、正文选择器），为nil时使用内置配置
 }
 
-// NewThreeLayerCrawler 创建三层策略爬虫
-func NewThreeLayerCrawler(firecrawlKey string) *ThreeLayerCrawler {
+// NewThreeLayerCrawler 创建三层策略爬虫，scheduler可在多个爬虫间共享，使同一主机的请求统一排队；
+// breakers为Firecrawl和Jina提供熔断器（名称为crawler:<爬虫名>），本地爬虫的失败取决于目标站点，不熔断；
+// registry为平台配置，为nil时使用内置配置
+func NewThreeLayerCrawler(firecrawlKey string, scheduler *HostScheduler, breakers *breaker.Registry, registry *platforms.Registry) *ThreeLayerCrawler {
 	crawlers := []Crawler{}
 
 	// 第一层：Firecrawl（如果配置了）
@@ -207,36 +266,154 @@ func NewThreeLayerCrawler(firecrawlKey string) *ThreeLayerCrawler {
 	// 第二层：Jina（免费）
 	crawlers = append(crawlers, &JinaCrawler{})
 
-	// 第三层：Playwright（暂未实现，需要浏览器环境）
+	// 第三层：本地HTTP抓取（无需第三方API，可爬内网/离线环境）
+	crawlers = append(crawlers, NewNativeCrawler())
+
+	// Playwright（暂未实现，需要浏览器环境）
 	// TODO: 实现Playwright爬虫
 
+	layerBreakers := make(map[string]*breaker.Breaker)
+	for _, crawler := range crawlers {
+		if crawler.Name() != "native" {
+			layerBreakers[crawler.Name()] = breakers.Get("crawler:" + crawler.Name())
+		}
+	}
+
 	return &ThreeLayerCrawler{
-		Crawlers: crawlers,
+		Crawlers:  crawlers,
+		Scheduler: scheduler,
+		Breakers:  layerBreakers,
+		Platforms: registry,
 	}
 }
 
-// Crawl 使用三层策略爬取
-func (t *ThreeLayerCrawler) Crawl(url string) (*CrawlResult, error) {
+// UseSessions 启用登录会话爬虫：需要登录的平台（或在平台配置的crawlers中列出session的平台）使用上传的Cookie和请求头爬取
+func (t *ThreeLayerCrawler) UseSessions(store SessionStore) {
+	t.Crawlers = append(t.Crawlers, NewSessionCrawler(store))
+}
+
+// Crawl 使用三层策略爬取，按平台配置的爬虫顺序依次尝试，ctx取消后立即停止尝试后续爬虫。
+// 目标站点返回404或跳转到登录页面时其他层也无能为力，直接返回（见stopLayers）；验证码、超时、内容过短等继续尝试下一层。
+// 成功时每一层的尝试结果记录在Metadata的attempt_<爬虫名>中（ok或失败类型），全部失败时返回*LayersError
+func (t *ThreeLayerCrawler) Crawl(ctx context.Context, url string) (*CrawlResult, error) {
 	// 识别平台
-	platform, err := IdentifyPlatform(url)
+	platform, err := t.Platforms.Identify(url)
 	if err != nil {
 		return nil, err
 	}
 
-	var lastError error
+	if !t.Scheduler.Allowed(ctx, url) {
+		return nil, &CrawlError{Kind: ErrKindRobots, Message: "跳过 " + url, Err: ErrDisallowedByRobots}
+	}
+
+	var failures []*CrawlError
 
-	// 依次尝试每个爬虫
-	for _, crawler := range t.Crawlers {
-		result, err := crawler.Crawl(url, platform)
+	// 依次尝试每个爬虫，每一层都会请求目标站点，都要按主机排队
+	for _, crawler := range t.layers(platform) {
+		layerBreaker := t.Breakers[crawler.Name()]
+		if err := layerBreaker.Allow(); err != nil {
+			failures = append(failures, &CrawlError{Kind: ErrKindCircuitOpen, Layer: crawler.Name(), Message: "跳过", Err: err})
+			continue
+		}
+
+		if err := t.Scheduler.Wait(ctx, url); err != nil {
+			return nil, err
+		}
+
+		result, err := crawler.Crawl(ctx, url, platform)
 		if err == nil && result.Success {
+			layerBreaker.Success()
+			if result.Metadata == nil {
+				result.Metadata = map[string]string{}
+			}
+			for _, failure := range failures {
+				result.Metadata["attempt_"+failure.Layer] = string(failure.Kind)
+			}
+			result.Metadata["attempt_"+crawler.Name()] = "ok"
 			return result, nil
 		}
-		lastError = err
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+
+		failure := layerError(crawler.Name(), err)
+		failures = append(failures, failure)
+		// 只有服务本身的问题（未配置、额度用尽、API限流或5xx、连不上API）计入熔断，
+		// 服务转述的目标站点失败（站点5xx、429、超时）和验证码、404等说明服务正常
+		if failure.ProviderFailure() {
+			layerBreaker.Failure(failure)
+		} else {
+			layerBreaker.Success()
+		}
+		if stopLayers(crawler.Name(), failure) {
+			break
+		}
+	}
+
+	if len(failures) == 0 {
+		return nil, errors.New("没有可用的爬虫")
 	}
+	return nil, &LayersError{Errors: failures}
+}
 
-	if lastError != nil {
-		return nil, fmt.Errorf("所有爬虫都失败了，最后一个错误: %w", lastError)
+// stopLayers 其他层也无能为力、不再尝试的失败：目标站点返回404/410，或被跳转到登录页面（登录会话层除外，会话失效时仍尝试其他层）。
+// 第三方API自身返回的404和页面中的登录提示不够确定，继续尝试下一层
+func stopLayers(layer string, failure *CrawlError) bool {
+	switch {
+	case failure.Kind == ErrKindNotFound:
+		return failure.SiteOutcome()
+	case failure.RedirectedToLogin():
+		return layer != sessionLayer
 	}
+	return false
+}
 
-	return nil, errors.New("所有爬虫都失败了")
+// layers 按平台配置的顺序排列爬虫，未列出的爬虫不使用；没有配置或配置的爬虫都不可用（如未配置Firecrawl）时按默认顺序。
+// 登录会话爬虫只在列出时使用，需要登录的平台未列出时排在最前
+func (t *ThreeLayerCrawler) layers(platform *platforms.Platform) []Crawler {
+	var ordered []Crawler
+	for _, name := range platform.Crawlers {
+		if crawler := t.crawler(name); crawler != nil {
+			ordered = append(ordered, crawler)
+		}
+	}
+	if len(ordered) == 0 {
+		for _, crawler := range t.Crawlers {
+			if crawler.Name() != sessionLayer {
+				ordered = append(ordered, crawler)
+			}
+		}
+	}
+
+	if platform.NeedsLogin && !slices.Contains(platform.Crawlers, sessionLayer) {
+		if session := t.crawler(sessionLayer); session != nil {
+			ordered = append([]Crawler{session}, ordered...)
+		}
+	}
+	return ordered
+}
+
+// crawler 按名称查找爬虫，不存在（如未配置）时返回nil
+func (t *ThreeLayerCrawler) crawler(name string) Crawler {
+	for _, crawler := range t.Crawlers {
+		if crawler.Name() == name {
+			return crawler
+		}
+	}
+	return nil
+}
+
+// layerError 把爬虫返回的错误转换为*CrawlError，未分类的错误归为other
+func layerError(layer string, err error) *CrawlError {
+	var crawlErr *CrawlError
+	if errors.As(err, &crawlErr) {
+		if crawlErr.Layer == "" {
+			crawlErr.Layer = layer
+		}
+		return crawlErr
+	}
+	if err == nil {
+		return &CrawlError{Kind: ErrKindOther, Layer: layer, Message: "爬取失败"}
+	}
+	return &CrawlError{Kind: ErrKindOther, Layer: layer, Message: "爬取失败", Err: err}
 }
@@ -0,0 +1,308 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorKind 爬取失败的类型
+type ErrorKind string
+
+const (
+	ErrKindCaptcha       ErrorKind = "captcha"      // 触发验证码或人机验证
+	ErrKindLoginWall     ErrorKind = "login_wall"   // 需要登录才能查看
+	ErrKindNotFound      ErrorKind = "not_found"    // 页面不存在（404/410）
+	ErrKindForbidden     ErrorKind = "forbidden"    // 站点拒绝访问（403）
+	ErrKindRateLimited   ErrorKind = "rate_limited" // 请求过多（429）
+	ErrKindTimeout       ErrorKind = "timeout"      // 请求超时
+	ErrKindNetwork       ErrorKind = "network"      // 连接失败等网络错误
+	ErrKindServer        ErrorKind = "server_error" // 服务器错误（5xx）
+	ErrKindTooShort      ErrorKind = "content_too_short"
+	ErrKindUnsupported   ErrorKind = "unsupported"    // 不支持的内容类型
+	ErrKindNotConfigured ErrorKind = "not_configured" // 爬虫缺少API Key，或API Key无效/额度用尽
+	ErrKindRobots        ErrorKind = "robots"         // robots.txt不允许
+	ErrKindCircuitOpen   ErrorKind = "circuit_open"   // 该层爬虫已熔断，跳过
+	ErrKindOther         ErrorKind = "other"
+)
+
+// 有效内容的最少字符数
+const minContentLength = 100
+
+// 短于该长度的页面才检查验证码和登录提示，避免正文中偶然提到这些词被误判
+const blockedPageMaxLength = 2000
+
+// 退避重试的初始等待时间和上限
+const (
+	retryBaseDelay = 2 * time.Second
+	retryMaxDelay  = time.Minute
+)
+
+// CrawlError 一层爬虫的失败原因
+type CrawlError struct {
+	Kind       ErrorKind
+	Layer      string        // 爬虫名称（firecrawl/jina/native）
+	StatusCode int           // HTTP状态码（有时）
+	RetryAfter time.Duration // 429时站点要求的等待时间
+	Message    string
+	Err        error
+	// RedirectURL 跳转到的登录地址：按跳转地址判断需要登录时才有，比按页面中的登录提示判断更确定
+	RedirectURL string
+	// FromAPI 状态码是第三方爬取API的响应状态码：API转述的目标站点状态，也可能是API本身的问题（如API的404）
+	FromAPI bool
+	// Provider 失败来自第三方爬取服务本身（API Key无效或额度用尽、API自身限流或5xx、连不上API），
+	// 而不是服务转述的目标站点状态（目标站点的5xx、429、超时）
+	Provider bool
+}
+
+func (e *CrawlError) Error() string {
+	message := e.Message
+	if e.Err != nil {
+		message += ": " + e.Err.Error()
+	}
+	if e.Layer == "" {
+		return message
+	}
+	return e.Layer + ": " + message
+}
+
+func (e *CrawlError) Unwrap() error {
+	return e.Err
+}
+
+// Transient 是否是临时失败，稍后重试可能成功
+func (e *CrawlError) Transient() bool {
+	switch e.Kind {
+	case ErrKindRateLimited, ErrKindTimeout, ErrKindNetwork, ErrKindServer:
+		return true
+	}
+	return false
+}
+
+// ProviderFailure 是否是爬虫服务本身的问题，用于熔断；目标站点的失败说明服务正常，不计入
+func (e *CrawlError) ProviderFailure() bool {
+	return e.Provider
+}
+
+// SiteOutcome 失败是否反映了目标站点本身的情况：不是服务本身的问题，不是未配置或熔断跳过，也不是含义不确定的API 404
+func (e *CrawlError) SiteOutcome() bool {
+	switch {
+	case e.Provider, e.Kind == ErrKindNotConfigured, e.Kind == ErrKindCircuitOpen:
+		return false
+	case e.FromAPI && e.Kind == ErrKindNotFound:
+		return false
+	}
+	return true
+}
+
+// RedirectedToLogin 是否是被跳转到登录地址（而不只是页面中出现登录提示）
+func (e *CrawlError) RedirectedToLogin() bool {
+	return e.Kind == ErrKindLoginWall && e.RedirectURL != ""
+}
+
+// LayersError 所有爬虫层都失败，Errors按尝试顺序记录每一层的失败原因
+type LayersError struct {
+	Errors []*CrawlError
+}
+
+func (e *LayersError) Error() string {
+	parts := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		parts[i] = err.Error()
+	}
+	return "所有爬虫都失败了（" + strings.Join(parts, "；") + "）"
+}
+
+// Unwrap 返回最能说明失败原因的一层，errors.As得到的*CrawlError即为该层
+func (e *LayersError) Unwrap() error {
+	if primary := e.primary(); primary != nil {
+		return primary
+	}
+	return nil
+}
+
+// primary 按确定性选出主要原因：目标站点本身的结果优先于爬取服务的问题（如服务限流不掩盖站点的验证码），
+// 同一类中页面不存在和需要登录最确定，其次是可重试的临时失败，未配置和熔断跳过的层最后
+func (e *LayersError) primary() *CrawlError {
+	rank := map[ErrorKind]int{
+		ErrKindNotFound: 0, ErrKindLoginWall: 1,
+		ErrKindRateLimited: 2, ErrKindTimeout: 3, ErrKindServer: 4, ErrKindNetwork: 5,
+		ErrKindCaptcha: 6, ErrKindForbidden: 7, ErrKindTooShort: 8, ErrKindUnsupported: 9, ErrKindOther: 10,
+		ErrKindNotConfigured: 11, ErrKindCircuitOpen: 12,
+	}
+	tier := func(err *CrawlError) int {
+		switch {
+		case err.SiteOutcome():
+			return 0
+		case err.Kind == ErrKindNotConfigured || err.Kind == ErrKindCircuitOpen:
+			return 2
+		}
+		return 1
+	}
+	var best *CrawlError
+	for _, err := range e.Errors {
+		if best == nil || tier(err) < tier(best) || (tier(err) == tier(best) && rank[err.Kind] < rank[best.Kind]) {
+			best = err
+		}
+	}
+	return best
+}
+
+// ErrorKindOf 错误的类型，不是爬取错误时为空
+func ErrorKindOf(err error) ErrorKind {
+	var crawlErr *CrawlError
+	if errors.As(err, &crawlErr) {
+		return crawlErr.Kind
+	}
+	return ""
+}
+
+// HasErrorKind 是否有任意一层爬虫以该类型失败（如主要原因是超时，但另一层触发了验证码）
+func HasErrorKind(err error, kind ErrorKind) bool {
+	var layersErr *LayersError
+	if errors.As(err, &layersErr) {
+		for _, layerErr := range layersErr.Errors {
+			if layerErr.Kind == kind {
+				return true
+			}
+		}
+		return false
+	}
+	return ErrorKindOf(err) == kind
+}
+
+// IsTransient 错误是否是临时失败（限流、超时、网络、服务器错误），值得退避重试
+func IsTransient(err error) bool {
+	var crawlErr *CrawlError
+	return errors.As(err, &crawlErr) && crawlErr.Transient()
+}
+
+// RetryDelay 第attempt次（从0开始）重试前的等待时间：指数退避，站点要求的Retry-After更长时按Retry-After，最长1分钟
+func RetryDelay(err error, attempt int) time.Duration {
+	delay := retryBaseDelay << attempt
+	var crawlErr *CrawlError
+	if errors.As(err, &crawlErr) && crawlErr.RetryAfter > delay {
+		delay = crawlErr.RetryAfter
+	}
+	return min(delay, retryMaxDelay)
+}
+
+// requestError 请求没有得到响应时的错误：超时或网络错误
+func requestError(layer string, err error) *CrawlError {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return &CrawlError{Kind: ErrKindTimeout, Layer: layer, Message: "请求超时", Err: err}
+	}
+	return &CrawlError{Kind: ErrKindNetwork, Layer: layer, Message: "请求失败", Err: err}
+}
+
+// apiRequestError 请求第三方爬取API没有得到响应：连不上API或API超时，属于服务本身的问题
+func apiRequestError(layer string, err error) *CrawlError {
+	crawlErr := requestError(layer, err)
+	crawlErr.Provider = true
+	return crawlErr
+}
+
+// siteStatusError 目标站点返回的错误状态码
+func siteStatusError(layer string, resp *http.Response) *CrawlError {
+	return statusError(layer, resp.StatusCode, resp.Header.Get("Retry-After"), fmt.Sprintf("页面返回错误: %d", resp.StatusCode), false)
+}
+
+// apiStatusError 第三方爬取API返回的错误状态码，401/402/403表示API Key无效或额度用尽；
+// 只有这些和API自身的429、5xx算服务本身的问题，408/504等是API转述的目标站点超时
+func apiStatusError(layer string, resp *http.Response, message string) *CrawlError {
+	err := statusError(layer, resp.StatusCode, resp.Header.Get("Retry-After"), message, true)
+	err.FromAPI = true
+	switch err.Kind {
+	case ErrKindNotConfigured, ErrKindRateLimited, ErrKindServer:
+		err.Provider = true
+	}
+	return err
+}
+
+func statusError(layer string, status int, retryAfter, message string, api bool) *CrawlError {
+	err := &CrawlError{Kind: ErrKindOther, Layer: layer, StatusCode: status, Message: message}
+	switch {
+	case api && (status == http.StatusUnauthorized || status == http.StatusPaymentRequired || status == http.StatusForbidden):
+		err.Kind = ErrKindNotConfigured
+	case status == http.StatusNotFound || status == http.StatusGone:
+		err.Kind = ErrKindNotFound
+	case status == http.StatusUnauthorized:
+		err.Kind = ErrKindLoginWall
+	case status == http.StatusForbidden:
+		err.Kind = ErrKindForbidden
+	case status == http.StatusTooManyRequests:
+		err.Kind = ErrKindRateLimited
+		err.RetryAfter = parseRetryAfter(retryAfter, time.Now())
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		err.Kind = ErrKindTimeout
+	case status >= 500:
+		err.Kind = ErrKindServer
+	}
+	return err
+}
+
+// parseRetryAfter 解析Retry-After（秒数或HTTP日期）
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// 验证页面和登录页面的提示语（小写）
+var (
+	captchaMarkers = []string{
+		"captcha", "安全验证", "验证码", "人机验证", "滑动验证", "are you a robot", "unusual traffic",
+		"checking your browser", "verify you are human",
+	}
+	loginMarkers = []string{
+		"请登录", "登录后查看", "登录后可查看", "登录后才能", "扫码登录", "登录/注册",
+		"log in to continue", "sign in to continue", "please log in", "please sign in", "login required",
+	}
+	loginPathMarkers = []string{"/login", "/signin", "/sign-in", "/passport", "/account/login"}
+)
+
+// checkContent 检查爬取到的内容：跳转到登录地址、过短、验证码页面或登录页面时返回对应的错误；
+// redirectURL为发生跳转时的最终地址（没有跳转或未知时为空）。登录页通常很短，跳转地址不依赖内容，先于长度检查，
+// 否则会被当作内容过短而无法判断登录会话失效；页面中的登录提示可能误判，仍只在长度足够时检查
+func checkContent(layer, markdown, redirectURL string) *CrawlError {
+	lowerURL := strings.ToLower(redirectURL)
+	for _, marker := range loginPathMarkers {
+		if strings.Contains(lowerURL, marker) {
+			return &CrawlError{Kind: ErrKindLoginWall, Layer: layer, Message: "跳转到了登录页面", RedirectURL: redirectURL}
+		}
+	}
+
+	if len(strings.TrimSpace(markdown)) < minContentLength {
+		return &CrawlError{Kind: ErrKindTooShort, Layer: layer, Message: "内容过短，可能是验证页面"}
+	}
+
+	if len(markdown) >= blockedPageMaxLength {
+		return nil
+	}
+	lower := strings.ToLower(markdown)
+	for _, marker := range captchaMarkers {
+		if strings.Contains(lower, marker) {
+			return &CrawlError{Kind: ErrKindCaptcha, Layer: layer, Message: "触发了验证码"}
+		}
+	}
+	for _, marker := range loginMarkers {
+		if strings.Contains(lower, marker) {
+			return &CrawlError{Kind: ErrKindLoginWall, Layer: layer, Message: "需要登录才能查看"}
+		}
+	}
+	return nil
+}
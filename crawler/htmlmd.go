@@ -0,0 +1,672 @@
+package crawler
+
+import (
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTMLDocument HTML转换结果
+type HTMLDocument struct {
+	Title    string
+	Markdown string
+	Metadata map[string]string
+}
+
+// 不参与正文转换的标签
+var skipTags = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Nav:      true,
+	atom.Footer:   true,
+	atom.Aside:    true,
+	atom.Form:     true,
+	atom.Iframe:   true,
+	atom.Svg:      true,
+	atom.Button:   true,
+	atom.Input:    true,
+	atom.Select:   true,
+	atom.Textarea: true,
+	atom.Template: true,
+	atom.Canvas:   true,
+	atom.Dialog:   true,
+}
+
+// boilerplateNames 导航、页脚、广告等样板区块的class/id，按完整的class名（或id）匹配；
+// 名称出现在其他位置的class（如has-sidebar、no-ads、hero-banner、related-wrap）通常是包裹正文的布局容器，不算样板。
+// 只匹配站点级页头：文章页头通常带有标题和发布时间，不能当作样板去掉
+var boilerplateNames = map[string]bool{
+	"nav": true, "navbar": true, "navigation": true, "menu": true, "footer": true,
+	"site-header": true, "masthead": true, "topbar": true, "sidebar": true, "side-bar": true,
+	"breadcrumb": true, "breadcrumbs": true, "cookie": true, "cookies": true, "banner": true,
+	"comment": true, "comments": true, "share": true, "social": true, "advert": true,
+	"ad": true, "ads": true, "popup": true, "modal": true, "subscribe": true, "newsletter": true,
+	"related": true, "recommend": true, "toolbar": true, "login": true, "signup": true,
+}
+
+// boilerplatePrefixes 以这些前缀开头的class/id也是样板区块（如nav-list、footer_links）
+var boilerplatePrefixes = []string{"nav", "navbar", "menu", "footer", "sidebar", "breadcrumb", "cookie", "ad", "ads", "advert", "popup", "modal", "newsletter"}
+
+// ConvertHTML 将HTML转换为Markdown，提取标题和元数据
+// baseURL 用于把相对链接和图片地址补全为绝对地址
+// selectors 为平台配置的正文选择器，依次尝试，都匹配不到时自动定位正文
+func ConvertHTML(doc *html.Node, baseURL *url.URL, selectors []string) *HTMLDocument {
+	metadata := extractMetadata(doc)
+
+	title := metadata["og:title"]
+	if title == "" {
+		if n := findFirst(doc, atom.Title); n != nil {
+			title = collapseSpace(textContent(n))
+		}
+	}
+
+	root := selectContent(doc, selectors)
+	if root == nil {
+		root = findMainContent(doc)
+	}
+	if title == "" {
+		if n := findFirst(root, atom.H1); n != nil {
+			title = collapseSpace(textContent(n))
+		}
+	}
+
+	conv := &markdownConverter{base: baseURL, rootText: len(collapseSpace(textContent(root)))}
+	conv.block(root)
+	markdown := conv.String()
+
+	if published := PublishedAt(doc, metadata, markdown); published != "" {
+		metadata["published_at"] = published
+	}
+
+	return &HTMLDocument{
+		Title:    title,
+		Markdown: markdown,
+		Metadata: metadata,
+	}
+}
+
+// extractMetadata 提取<meta>、canonical和语言信息
+func extractMetadata(doc *html.Node) map[string]string {
+	metadata := map[string]string{}
+
+	walk(doc, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		switch n.DataAtom {
+		case atom.Html:
+			if lang := attr(n, "lang"); lang != "" {
+				metadata["lang"] = lang
+			}
+		case atom.Meta:
+			key := strings.ToLower(attr(n, "property"))
+			if key == "" {
+				key = strings.ToLower(attr(n, "name"))
+			}
+			if key == "" {
+				key = strings.ToLower(attr(n, "itemprop"))
+			}
+			content := strings.TrimSpace(attr(n, "content"))
+			if key != "" && content != "" {
+				if _, exists := metadata[key]; !exists {
+					metadata[key] = content
+				}
+			}
+		case atom.Link:
+			if strings.EqualFold(attr(n, "rel"), "canonical") {
+				metadata["canonical"] = attr(n, "href")
+			}
+		case atom.Body:
+			return false
+		}
+		return true
+	})
+
+	return metadata
+}
+
+// selectContent 按选择器定位正文区域，返回第一个匹配且有文本的节点
+func selectContent(doc *html.Node, selectors []string) *html.Node {
+	for _, selector := range selectors {
+		if n := querySelector(doc, selector); n != nil && strings.TrimSpace(textContent(n)) != "" {
+			return n
+		}
+	}
+	return nil
+}
+
+// querySelector 按简单的CSS选择器查找第一个匹配的节点：支持标签、#id、.class及其组合（如 div.content），
+// 以空格分隔的后代选择器（如 article .body）
+func querySelector(root *html.Node, selector string) *html.Node {
+	current := root
+	for _, part := range strings.Fields(selector) {
+		var found *html.Node
+		walk(current, func(n *html.Node) bool {
+			if found != nil {
+				return false
+			}
+			if n != current && n.Type == html.ElementNode && matchSimpleSelector(n, part) {
+				found = n
+				return false
+			}
+			return true
+		})
+		if found == nil {
+			return nil
+		}
+		current = found
+	}
+	if current == root {
+		return nil
+	}
+	return current
+}
+
+// matchSimpleSelector 节点是否匹配 tag#id.class 形式的简单选择器
+func matchSimpleSelector(n *html.Node, selector string) bool {
+	// 按 # 和 . 拆分出标签、id和class
+	tag, rest := selector, ""
+	if i := strings.IndexAny(selector, "#."); i >= 0 {
+		tag, rest = selector[:i], selector[i:]
+	}
+	if tag != "" && tag != "*" && !strings.EqualFold(n.Data, tag) {
+		return false
+	}
+
+	classes := strings.Fields(attr(n, "class"))
+	for rest != "" {
+		prefix := rest[0]
+		rest = rest[1:]
+		name := rest
+		if i := strings.IndexAny(rest, "#."); i >= 0 {
+			name, rest = rest[:i], rest[i:]
+		} else {
+			rest = ""
+		}
+		if name == "" {
+			return false
+		}
+		if prefix == '#' {
+			if attr(n, "id") != name {
+				return false
+			}
+		} else if !slices.Contains(classes, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// findMainContent 定位正文区域：优先article/main，否则按文本密度选择
+func findMainContent(doc *html.Node) *html.Node {
+	if n := findFirst(doc, atom.Article); n != nil && len(textContent(n)) > mainContentMinLength {
+		return n
+	}
+	if n := findFirst(doc, atom.Main); n != nil {
+		return n
+	}
+
+	var roleMain *html.Node
+	walk(doc, func(n *html.Node) bool {
+		if roleMain != nil {
+			return false
+		}
+		if n.Type == html.ElementNode && attr(n, "role") == "main" {
+			roleMain = n
+			return false
+		}
+		return true
+	})
+	if roleMain != nil {
+		return roleMain
+	}
+
+	body := findFirst(doc, atom.Body)
+	if body == nil {
+		return doc
+	}
+
+	// 按文本密度选择容器：段落文本直接计入所在容器、折半计入上一级容器，再乘以非链接文本占比。
+	// 只比较总长度会让最外层的包裹容器（包含导航和页脚）总是胜出
+	paragraphText := map[*html.Node]float64{}
+	var candidates []*html.Node // 按文档顺序记录，分数相同时取靠前的容器
+	credit := func(n *html.Node, text float64) {
+		for _, share := range []float64{1, 0.5} {
+			if n == nil {
+				return
+			}
+			if _, ok := paragraphText[n]; !ok {
+				candidates = append(candidates, n)
+			}
+			paragraphText[n] += text * share
+			n = contentContainer(n.Parent)
+		}
+	}
+	bodyText := len(collapseSpace(textContent(body)))
+	walk(body, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		if skipTags[n.DataAtom] || isSiteHeader(n) {
+			return false
+		}
+		if isBoilerplateBlock(n, bodyText) {
+			return false
+		}
+		if n.DataAtom == atom.P || n.DataAtom == atom.Pre || n.DataAtom == atom.Blockquote {
+			credit(contentContainer(n.Parent), float64(len(collapseSpace(textContent(n)))))
+			return false
+		}
+		if contentContainer(n) != nil {
+			// 不用<p>、直接用<br>分段的正文，按容器的直接文本计分
+			text := 0
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == html.TextNode {
+					text += len(collapseSpace(c.Data))
+				}
+			}
+			if text > 0 {
+				credit(n, float64(text))
+			}
+		}
+		return true
+	})
+
+	var best *html.Node
+	bestScore := 0.0
+	for _, n := range candidates {
+		text := len(collapseSpace(textContent(n)))
+		if text < mainContentMinLength {
+			continue
+		}
+		density := float64(text-linkTextLength(n)) / float64(text)
+		if score := paragraphText[n] * density; score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	if best == nil {
+		return body
+	}
+	return best
+}
+
+// 候选正文容器的最少文本长度
+const mainContentMinLength = 200
+
+// contentContainer 返回可以作为正文候选的容器（div/section/article/main/td），其他节点返回nil
+func contentContainer(n *html.Node) *html.Node {
+	if n == nil || n.Type != html.ElementNode {
+		return nil
+	}
+	switch n.DataAtom {
+	case atom.Div, atom.Section, atom.Article, atom.Main, atom.Td:
+		return n
+	}
+	return nil
+}
+
+// linkTextLength 节点内链接文字的总长度
+func linkTextLength(n *html.Node) int {
+	length := 0
+	walk(n, func(c *html.Node) bool {
+		if c.Type == html.ElementNode && c.DataAtom == atom.A {
+			length += len(collapseSpace(textContent(c)))
+			return false
+		}
+		return true
+	})
+	return length
+}
+
+// isSiteHeader 是否为站点级页头：body下的顶层header或包含导航的header；
+// 正文里的header通常是文章标题和发布时间，需要保留
+func isSiteHeader(n *html.Node) bool {
+	if n.Type != html.ElementNode || n.DataAtom != atom.Header {
+		return false
+	}
+	if n.Parent != nil && n.Parent.DataAtom == atom.Body {
+		return true
+	}
+	return findFirst(n, atom.Nav) != nil
+}
+
+var blankLinesPattern = regexp.MustCompile(`\n{3,}`)
+
+// markdownConverter HTML到Markdown的转换器
+type markdownConverter struct {
+	base     *url.URL
+	out      strings.Builder
+	listDeep int
+	rootText int // 正文根节点的文字长度，用于判断样板class的容器是否其实包裹着正文
+}
+
+func (m *markdownConverter) String() string {
+	result := blankLinesPattern.ReplaceAllString(m.out.String(), "\n\n")
+	return strings.TrimSpace(result) + "\n"
+}
+
+// ensureBlank 保证块级元素之间有空行
+func (m *markdownConverter) ensureBlank() {
+	s := m.out.String()
+	if s == "" || strings.HasSuffix(s, "\n\n") {
+		return
+	}
+	if strings.HasSuffix(s, "\n") {
+		m.out.WriteString("\n")
+		return
+	}
+	m.out.WriteString("\n\n")
+}
+
+// block 转换块级内容
+func (m *markdownConverter) block(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		m.node(c)
+	}
+}
+
+func (m *markdownConverter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		text := collapseSpace(n.Data)
+		if text == "" {
+			if strings.TrimSpace(n.Data) == "" && n.Data != "" && !strings.HasSuffix(m.out.String(), " ") && !strings.HasSuffix(m.out.String(), "\n") {
+				m.out.WriteString(" ")
+			}
+			return
+		}
+		if strings.HasPrefix(n.Data, " ") || strings.HasPrefix(n.Data, "\n") {
+			if s := m.out.String(); s != "" && !strings.HasSuffix(s, " ") && !strings.HasSuffix(s, "\n") {
+				m.out.WriteString(" ")
+			}
+		}
+		m.out.WriteString(text)
+		if strings.HasSuffix(n.Data, " ") || strings.HasSuffix(n.Data, "\n") {
+			m.out.WriteString(" ")
+		}
+		return
+	case html.ElementNode:
+	case html.DocumentNode:
+		m.block(n)
+		return
+	default:
+		return
+	}
+
+	if skipTags[n.DataAtom] || isSiteHeader(n) || isBoilerplateBlock(n, m.rootText) || isHidden(n) {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := collapseSpace(textContent(n))
+		if text == "" {
+			return
+		}
+		level := int(n.Data[1] - '0')
+		m.ensureBlank()
+		m.out.WriteString(strings.Repeat("#", level) + " " + text)
+		m.ensureBlank()
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Figure, atom.Dl:
+		m.ensureBlank()
+		m.block(n)
+		m.ensureBlank()
+	case atom.Br:
+		m.out.WriteString("  \n")
+	case atom.Hr:
+		m.ensureBlank()
+		m.out.WriteString("---")
+		m.ensureBlank()
+	case atom.Ul, atom.Ol:
+		m.list(n, n.DataAtom == atom.Ol)
+	case atom.Pre:
+		m.ensureBlank()
+		m.out.WriteString("```\n")
+		m.out.WriteString(strings.TrimRight(textContent(n), "\n"))
+		m.out.WriteString("\n```")
+		m.ensureBlank()
+	case atom.Code:
+		m.out.WriteString("`" + collapseSpace(textContent(n)) + "`")
+	case atom.Blockquote:
+		inner := &markdownConverter{base: m.base}
+		inner.block(n)
+		m.ensureBlank()
+		for _, line := range strings.Split(strings.TrimSpace(inner.String()), "\n") {
+			m.out.WriteString("> " + line + "\n")
+		}
+		m.ensureBlank()
+	case atom.Strong, atom.B:
+		m.wrapInline(n, "**")
+	case atom.Em, atom.I:
+		m.wrapInline(n, "*")
+	case atom.A:
+		m.link(n)
+	case atom.Img:
+		m.image(n)
+	case atom.Table:
+		m.table(n)
+	case atom.Dt:
+		m.ensureBlank()
+		m.out.WriteString("**" + collapseSpace(textContent(n)) + "**")
+		m.ensureBlank()
+	default:
+		m.block(n)
+	}
+}
+
+func (m *markdownConverter) wrapInline(n *html.Node, marker string) {
+	text := collapseSpace(textContent(n))
+	if text == "" {
+		return
+	}
+	m.out.WriteString(marker + text + marker)
+}
+
+func (m *markdownConverter) link(n *html.Node) {
+	href := strings.TrimSpace(attr(n, "href"))
+	inner := &markdownConverter{base: m.base}
+	inner.block(n)
+	text := collapseSpace(strings.ReplaceAll(inner.String(), "\n", " "))
+
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		m.out.WriteString(text)
+		return
+	}
+	if text == "" {
+		return
+	}
+	m.out.WriteString("[" + text + "](" + m.resolve(href) + ")")
+}
+
+func (m *markdownConverter) image(n *html.Node) {
+	src := attr(n, "src")
+	// 懒加载图片常把真实地址放在data-src
+	if dataSrc := attr(n, "data-src"); dataSrc != "" && (src == "" || strings.HasPrefix(src, "data:")) {
+		src = dataSrc
+	}
+	if src == "" || strings.HasPrefix(src, "data:") {
+		return
+	}
+	m.out.WriteString("![" + collapseSpace(attr(n, "alt")) + "](" + m.resolve(src) + ")")
+}
+
+func (m *markdownConverter) list(n *html.Node, ordered bool) {
+	m.ensureBlank()
+	m.listDeep++
+	index := 1
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Li {
+			continue
+		}
+		inner := &markdownConverter{base: m.base, listDeep: m.listDeep}
+		inner.block(c)
+		text := strings.TrimSpace(inner.String())
+		if text == "" {
+			continue
+		}
+
+		marker := "- "
+		if ordered {
+			marker = strconv.Itoa(index) + ". "
+			index++
+		}
+		indent := strings.Repeat("  ", m.listDeep-1)
+		lines := strings.Split(text, "\n")
+		m.out.WriteString(indent + marker + lines[0] + "\n")
+		for _, line := range lines[1:] {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			m.out.WriteString(indent + "  " + line + "\n")
+		}
+	}
+	m.listDeep--
+	m.ensureBlank()
+}
+
+func (m *markdownConverter) table(n *html.Node) {
+	var rows [][]string
+	walk(n, func(c *html.Node) bool {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Tr {
+			return true
+		}
+		row := []string{}
+		for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+				text := collapseSpace(textContent(cell))
+				row = append(row, strings.ReplaceAll(text, "|", "\\|"))
+			}
+		}
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+		return false
+	})
+	if len(rows) == 0 {
+		return
+	}
+
+	columns := 0
+	for _, row := range rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+
+	m.ensureBlank()
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		m.out.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			m.out.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+		}
+	}
+	m.ensureBlank()
+}
+
+func (m *markdownConverter) resolve(ref string) string {
+	if m.base == nil {
+		return ref
+	}
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return m.base.ResolveReference(parsed).String()
+}
+
+// walk 深度优先遍历，fn返回false时不再进入子节点
+func walk(n *html.Node, fn func(*html.Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
+
+func findFirst(n *html.Node, a atom.Atom) *html.Node {
+	var found *html.Node
+	walk(n, func(c *html.Node) bool {
+		if found != nil {
+			return false
+		}
+		if c.Type == html.ElementNode && c.DataAtom == a {
+			found = c
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// textContent 获取节点内的纯文本（跳过脚本样式等）
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	walk(n, func(c *html.Node) bool {
+		if c.Type == html.ElementNode && (c.DataAtom == atom.Script || c.DataAtom == atom.Style || c.DataAtom == atom.Noscript) {
+			return false
+		}
+		if c.Type == html.TextNode {
+			sb.WriteString(c.Data)
+		}
+		return true
+	})
+	return sb.String()
+}
+
+// isBoilerplateBlock 是否为可以整体跳过的样板区块：包含total中大部分文字的容器是正文的祖先，
+// 即使class像样板也不跳过
+func isBoilerplateBlock(n *html.Node, total int) bool {
+	return isBoilerplate(n) && len(collapseSpace(textContent(n)))*2 < total
+}
+
+func isBoilerplate(n *html.Node) bool {
+	if role := attr(n, "role"); role == "navigation" || role == "banner" || role == "contentinfo" {
+		return true
+	}
+	for _, token := range append(strings.Fields(attr(n, "class")), attr(n, "id")) {
+		token = strings.ToLower(token)
+		if boilerplateNames[token] {
+			return true
+		}
+		if i := strings.IndexAny(token, "-_"); i > 0 && slices.Contains(boilerplatePrefixes, token[:i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func isHidden(n *html.Node) bool {
+	for _, a := range n.Attr {
+		if a.Key == "hidden" {
+			return true
+		}
+	}
+	if attr(n, "aria-hidden") == "true" {
+		return true
+	}
+	style := strings.ReplaceAll(strings.ToLower(attr(n, "style")), " ", "")
+	return strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden")
+}
+
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
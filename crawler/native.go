@@ -0,0 +1,139 @@
+package crawler
+
+import (
+	"competitive-analyzer/platforms"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// NativeCrawler 本地HTTP爬虫，直接抓取页面并转换为Markdown，不依赖第三方API
+type NativeCrawler struct {
+	Client       *http.Client
+	MaxBodyBytes int64
+}
+
+// NewNativeCrawler 创建本地HTTP爬虫
+func NewNativeCrawler() *NativeCrawler {
+	return &NativeCrawler{
+		Client:       &http.Client{Timeout: 30 * time.Second},
+		MaxBodyBytes: 5 << 20, // 5MB
+	}
+}
+
+func (n *NativeCrawler) Name() string {
+	return "native"
+}
+
+func (n *NativeCrawler) Crawl(ctx context.Context, rawURL string, platform *platforms.Platform) (*CrawlResult, error) {
+	client := n.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return n.fetch(ctx, n.Name(), rawURL, platform, client, nil)
+}
+
+// fetch 用client抓取页面并转换为Markdown，layer为结果和错误中的爬虫名称，headers为额外的请求头（如登录会话的请求头）
+func (n *NativeCrawler) fetch(ctx context.Context, layer, rawURL string, platform *platforms.Platform, client *http.Client, headers map[string]string) (*CrawlResult, error) {
+	pageURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, &CrawlError{Kind: ErrKindOther, Layer: layer, Message: "无效的URL", Err: err}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, &CrawlError{Kind: ErrKindOther, Layer: layer, Message: "创建请求失败", Err: err}
+	}
+
+	req.Header.Set("User-Agent", platform.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8")
+	req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9,en;q=0.8")
+	if platform.Referer != "" {
+		req.Header.Set("Referer", platform.Referer)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, requestError(layer, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, siteStatusError(layer, resp)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType != "" && !strings.Contains(contentType, "html") {
+		return nil, &CrawlError{Kind: ErrKindUnsupported, Layer: layer, Message: "不支持的内容类型: " + contentType}
+	}
+
+	maxBytes := n.MaxBodyBytes
+	if maxBytes <= 0 {
+		maxBytes = 5 << 20
+	}
+
+	// 按响应头或<meta charset>转码，兼容GBK等中文编码
+	reader, err := charset.NewReader(io.LimitReader(resp.Body, maxBytes), contentType)
+	if err != nil {
+		return nil, &CrawlError{Kind: ErrKindOther, Layer: layer, Message: "识别页面编码失败", Err: err}
+	}
+
+	doc, err := html.Parse(reader)
+	if err != nil {
+		return nil, &CrawlError{Kind: ErrKindOther, Layer: layer, Message: "解析HTML失败", Err: err}
+	}
+
+	// 以最终地址（跟随重定向后）补全相对链接
+	if resp.Request != nil && resp.Request.URL != nil {
+		pageURL = resp.Request.URL
+	}
+
+	converted := ConvertHTML(doc, pageURL, platform.ContentSelectors)
+	markdown := converted.Markdown
+
+	// 验证内容
+	redirectURL := ""
+	if pageURL.String() != rawURL {
+		redirectURL = pageURL.String()
+	}
+	if err := checkContent(layer, markdown, redirectURL); err != nil {
+		return nil, err
+	}
+
+	// 补上标题，与其他爬虫的Markdown格式保持一致
+	if converted.Title != "" && !strings.HasPrefix(markdown, "# ") {
+		markdown = "# " + converted.Title + "\n\n" + markdown
+	}
+
+	metadata := map[string]string{
+		"api":          layer + "-http",
+		"status_code":  fmt.Sprintf("%d", resp.StatusCode),
+		"content_type": contentType,
+		"final_url":    pageURL.String(),
+	}
+	for _, key := range []string{"description", "keywords", "author", "og:site_name", "og:type", "og:image", "canonical", "lang", "published_at"} {
+		if value := converted.Metadata[key]; value != "" {
+			metadata[key] = value
+		}
+	}
+
+	return &CrawlResult{
+		Success:  true,
+		Markdown: markdown,
+		Title:    converted.Title,
+		URL:      rawURL,
+		Platform: platform.Name,
+		Method:   layer,
+		Metadata: metadata,
+	}, nil
+}
@@ -0,0 +1,176 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDisallowedByRobots 目标站点的robots.txt不允许抓取该URL
+var ErrDisallowedByRobots = errors.New("robots.txt不允许抓取")
+
+const (
+	// robots.txt缓存时间，获取失败时缓存较短时间后重试
+	robotsCacheDuration      = 24 * time.Hour
+	robotsErrorCacheDuration = time.Hour
+	// Crawl-delay上限，避免个别站点设置过大的值拖住整个批量任务
+	maxCrawlDelay = time.Minute
+)
+
+// hostState 一个主机的调度状态
+type hostState struct {
+	mu        sync.Mutex
+	next      time.Time // 下一个请求最早可以开始的时间
+	robots    *RobotsRules
+	robotsExp time.Time
+}
+
+// HostScheduler 按主机控制爬取节奏：同一主机的请求按每秒请求数（和robots.txt的Crawl-delay）间隔排队，
+// 不同主机互不影响；开启robots检查时，robots.txt不允许的URL直接返回ErrDisallowedByRobots
+type HostScheduler struct {
+	interval      time.Duration // 同一主机两次请求的最小间隔
+	respectRobots bool
+	client        *http.Client
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+}
+
+// NewHostScheduler 创建主机调度器，requestsPerSecond为每个主机每秒最多请求数（<=0时不限速）
+func NewHostScheduler(requestsPerSecond float64, respectRobots bool) *HostScheduler {
+	var interval time.Duration
+	if requestsPerSecond > 0 {
+		interval = time.Duration(float64(time.Second) / requestsPerSecond)
+	}
+	return &HostScheduler{
+		interval:      interval,
+		respectRobots: respectRobots,
+		client:        &http.Client{Timeout: 10 * time.Second},
+		hosts:         make(map[string]*hostState),
+	}
+}
+
+// Wait 等待到该URL所在主机可以发起下一个请求，ctx取消时返回ctx.Err()
+func (s *HostScheduler) Wait(ctx context.Context, rawURL string) error {
+	if s == nil {
+		return nil
+	}
+	pageURL, err := url.Parse(rawURL)
+	if err != nil || pageURL.Host == "" {
+		return nil
+	}
+
+	state := s.host(pageURL.Host)
+	interval := s.interval
+	if s.respectRobots {
+		if robots := s.robots(ctx, state, pageURL); robots != nil && robots.CrawlDelay > interval {
+			interval = min(robots.CrawlDelay, maxCrawlDelay)
+		}
+	}
+
+	// 预约下一个时间段，并发请求同一主机时依次排队
+	state.mu.Lock()
+	now := time.Now()
+	slot := state.next
+	if slot.Before(now) {
+		slot = now
+	}
+	state.next = slot.Add(interval)
+	state.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Allowed 检查robots.txt是否允许抓取该URL，未开启robots检查或robots.txt获取失败时允许
+func (s *HostScheduler) Allowed(ctx context.Context, rawURL string) bool {
+	if s == nil || !s.respectRobots {
+		return true
+	}
+	pageURL, err := url.Parse(rawURL)
+	if err != nil || pageURL.Host == "" {
+		return true
+	}
+	return s.robots(ctx, s.host(pageURL.Host), pageURL).Allowed(pageURL.RequestURI())
+}
+
+// host 获取主机的调度状态
+func (s *HostScheduler) host(host string) *hostState {
+	host = strings.ToLower(host)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.hosts[host]
+	if !ok {
+		state = &hostState{}
+		s.hosts[host] = state
+	}
+	return state
+}
+
+// robots 获取主机的robots.txt规则（带缓存），获取失败时返回nil（允许所有）
+func (s *HostScheduler) robots(ctx context.Context, state *hostState, pageURL *url.URL) *RobotsRules {
+	state.mu.Lock()
+	if time.Now().Before(state.robotsExp) {
+		robots := state.robots
+		state.mu.Unlock()
+		return robots
+	}
+	state.mu.Unlock()
+
+	robots, err := s.fetchRobots(ctx, pageURL)
+	expires := time.Now().Add(robotsCacheDuration)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil // 取消导致的失败不缓存
+		}
+		log.Printf("获取robots.txt失败 %s: %v", pageURL.Host, err)
+		expires = time.Now().Add(robotsErrorCacheDuration)
+	}
+
+	state.mu.Lock()
+	state.robots, state.robotsExp = robots, expires
+	state.mu.Unlock()
+	return robots
+}
+
+// fetchRobots 获取并解析robots.txt；不存在（4xx）时返回空规则
+func (s *HostScheduler) fetchRobots(ctx context.Context, pageURL *url.URL) (*RobotsRules, error) {
+	robotsURL := (&url.URL{Scheme: pageURL.Scheme, Host: pageURL.Host, Path: "/robots.txt"}).String()
+	req, err := http.NewRequestWithContext(ctx, "GET", robotsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", robotsAgent)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return ParseRobots(io.LimitReader(resp.Body, 512<<10), robotsAgent), nil
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return &RobotsRules{}, nil
+	default:
+		return nil, fmt.Errorf("robots.txt返回错误: %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,112 @@
+package crawler
+
+import (
+	"competitive-analyzer/pubdate"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// 记录发布时间的<meta>（按可信度排序，键已转为小写）；修改时间不代表发布时间，不使用，否则旧页面改一个字就会被当作新内容
+var publishedMetaKeys = []string{
+	"article:published_time", "og:published_time", "datepublished", "publishdate", "pubdate",
+	"publish_date", "dc.date.issued", "dc.date", "date", "sailthru.date", "parsely-pub-date",
+}
+
+// 正文中发布时间的提示词，如"发布于 2024-03-05"、"Published: Mar 5, 2024"（不含"更新于"等修改时间）
+var publishedTextPattern = regexp.MustCompile(`(?i)(发布时间|发布日期|发布于|发表于|published|posted)\s*(on|:|：)?\s*`)
+
+// PublishedAt 从页面中解析发布时间：依次尝试<meta>、JSON-LD、<time datetime>和正文开头的"发布于"，返回RFC3339格式
+func PublishedAt(doc *html.Node, metadata map[string]string, markdown string) string {
+	now := time.Now()
+
+	for _, key := range publishedMetaKeys {
+		if t, ok := pubdate.Parse(metadata[key], now); ok {
+			return t.Format(time.RFC3339)
+		}
+	}
+
+	var found time.Time
+	walk(doc, func(n *html.Node) bool {
+		if !found.IsZero() || n.Type != html.ElementNode {
+			return found.IsZero()
+		}
+		switch {
+		case n.DataAtom == atom.Script && strings.EqualFold(attr(n, "type"), "application/ld+json"):
+			if n.FirstChild != nil {
+				if t, ok := jsonLDPublished(n.FirstChild.Data, now); ok {
+					found = t
+				}
+			}
+			return false
+		case n.DataAtom == atom.Time:
+			value := attr(n, "datetime")
+			if value == "" {
+				value = textContent(n)
+			}
+			if t, ok := pubdate.Parse(value, now); ok {
+				found = t
+			}
+			return false
+		}
+		return true
+	})
+	if !found.IsZero() {
+		return found.Format(time.RFC3339)
+	}
+
+	if t, ok := PublishedInText(markdown, now); ok {
+		return t.Format(time.RFC3339)
+	}
+	return ""
+}
+
+// PublishedInText 在正文开头查找"发布于""Published"等提示词后的日期
+func PublishedInText(text string, now time.Time) (time.Time, bool) {
+	if runes := []rune(text); len(runes) > 3000 {
+		text = string(runes[:3000])
+	}
+	for _, m := range publishedTextPattern.FindAllStringIndex(text, 5) {
+		following := text[m[1]:]
+		if runes := []rune(following); len(runes) > 30 {
+			following = string(runes[:30])
+		}
+		if t, ok := pubdate.Find(following, now); ok {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// jsonLDPublished 从JSON-LD（可能是数组或@graph）中读取datePublished（不使用dateModified）
+func jsonLDPublished(data string, now time.Time) (time.Time, bool) {
+	var value interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &value); err != nil {
+		return time.Time{}, false
+	}
+
+	var published string
+	var visit func(v interface{})
+	visit = func(v interface{}) {
+		switch node := v.(type) {
+		case []interface{}:
+			for _, item := range node {
+				visit(item)
+			}
+		case map[string]interface{}:
+			if s, ok := node["datePublished"].(string); ok && published == "" {
+				published = s
+			}
+			if graph, ok := node["@graph"]; ok {
+				visit(graph)
+			}
+		}
+	}
+	visit(value)
+
+	return pubdate.Parse(published, now)
+}
@@ -0,0 +1,150 @@
+package crawler
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robotsAgent 匹配robots.txt中User-agent组时使用的名称，没有对应的组时使用"*"组
+const robotsAgent = "competitive-analyzer"
+
+// robotsRule 一条Allow/Disallow规则
+type robotsRule struct {
+	path  string
+	allow bool
+}
+
+// RobotsRules 一个站点robots.txt中适用于本爬虫的规则
+type RobotsRules struct {
+	rules      []robotsRule
+	CrawlDelay time.Duration // Crawl-delay，未设置时为0
+}
+
+// robotsGroup robots.txt中的一个User-agent组
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// ParseRobots 解析robots.txt，选取名称匹配agent的组，没有时使用"*"组
+func ParseRobots(r io.Reader, agent string) *RobotsRules {
+	var groups []*robotsGroup
+	var current *robotsGroup
+	// 连续的User-agent行属于同一组，遇到规则后再出现User-agent表示新的一组
+	inRules := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if current == nil || inRules {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+				inRules = false
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			// 空的Disallow表示允许全部
+			if value != "" {
+				current.rules = append(current.rules, robotsRule{path: value, allow: key == "allow"})
+			}
+		case "crawl-delay":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+
+	agent = strings.ToLower(agent)
+	var matched, wildcard *robotsGroup
+	for _, group := range groups {
+		for _, name := range group.agents {
+			if name == "*" {
+				if wildcard == nil {
+					wildcard = group
+				}
+			} else if name != "" && strings.Contains(agent, name) && matched == nil {
+				matched = group
+			}
+		}
+	}
+	if matched == nil {
+		matched = wildcard
+	}
+	if matched == nil {
+		return &RobotsRules{}
+	}
+	return &RobotsRules{rules: matched.rules, CrawlDelay: matched.crawlDelay}
+}
+
+// Allowed 路径（含查询参数）是否允许抓取：按最长匹配的规则，长度相同时Allow优先，没有匹配的规则时允许
+func (r *RobotsRules) Allowed(path string) bool {
+	if r == nil {
+		return true
+	}
+	if path == "" {
+		path = "/"
+	}
+
+	allowed, bestLength := true, -1
+	for _, rule := range r.rules {
+		if !matchRobotsPath(rule.path, path) {
+			continue
+		}
+		length := len(rule.path)
+		if length > bestLength || (length == bestLength && rule.allow) {
+			allowed, bestLength = rule.allow, length
+		}
+	}
+	return allowed
+}
+
+// matchRobotsPath 按robots.txt的规则匹配路径前缀，支持"*"通配符和结尾的"$"
+func matchRobotsPath(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for _, part := range parts[1:] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	if !anchored {
+		return true
+	}
+	// 结尾锚定时，最后一段必须匹配到路径末尾
+	if len(parts) == 1 {
+		return rest == ""
+	}
+	return strings.HasSuffix(path, parts[len(parts)-1])
+}
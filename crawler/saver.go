@@ -1,6 +1,8 @@
 package crawler
 
 import (
+	"competitive-analyzer/platforms"
+	"context"
 	"crypto/md5"
 	"fmt"
 	"io"
@@ -16,6 +18,7 @@ import (
 // ContentSaver 内容保存器
 type ContentSaver struct {
 	StoragePath string
+	Platforms   *platforms.Registry // 平台配置，下载图片时按平台设置Referer，为nil时使用内置配置
 }
 
 // SaveResult 保存结果
@@ -26,15 +29,16 @@ type SaveResult struct {
 	URL         string   `json:"url"`
 }
 
-// NewContentSaver 创建内容保存器
-func NewContentSaver(storagePath string) *ContentSaver {
+// NewContentSaver 创建内容保存器，registry为nil时使用内置平台配置
+func NewContentSaver(storagePath string, registry *platforms.Registry) *ContentSaver {
 	return &ContentSaver{
 		StoragePath: storagePath,
+		Platforms:   registry,
 	}
 }
 
 // Save 保存爬取的内容
-func (s *ContentSaver) Save(result *CrawlResult, competitorName string) (*SaveResult, error) {
+func (s *ContentSaver) Save(ctx context.Context, result *CrawlResult, competitorName string) (*SaveResult, error) {
 	// 创建保存目录：日期_竞品名_标题
 	date := time.Now().Format("20060102")
 	title := sanitizeFilename(result.Title)
@@ -49,8 +53,12 @@ func (s *ContentSaver) Save(result *CrawlResult, competitorName string) (*SaveRe
 		return nil, fmt.Errorf("创建目录失败: %w", err)
 	}
 
-	// 下载图片并替换链接
-	markdown, imagePaths, err := s.downloadImages(result.Markdown, savePath, result.Platform)
+	// 下载图片并替换链接，图片防盗链时需要带上平台的Referer
+	referer := ""
+	if platform, err := s.Platforms.Identify(result.URL); err == nil {
+		referer = platform.Referer
+	}
+	markdown, imagePaths, err := s.downloadImages(ctx, result.Markdown, savePath, referer)
 	if err != nil {
 		// 图片下载失败不影响主流程
 		markdown = result.Markdown
@@ -85,7 +93,7 @@ crawl_time: %s
 }
 
 // downloadImages 下载图片并返回新的markdown内容
-func (s *ContentSaver) downloadImages(markdown, savePath, platform string) (string, []string, error) {
+func (s *ContentSaver) downloadImages(ctx context.Context, markdown, savePath, referer string) (string, []string, error) {
 	imagePaths := []string{}
 	imageCount := 1
 
@@ -113,8 +121,13 @@ func (s *ContentSaver) downloadImages(markdown, savePath, platform string) (stri
 				continue
 			}
 
+			// 任务取消后不再下载剩余图片
+			if ctx.Err() != nil {
+				return newMarkdown, imagePaths, nil
+			}
+
 			// 下载图片
-			localPath, err := s.downloadImage(imageURL, savePath, imageCount, platform)
+			localPath, err := s.downloadImage(ctx, imageURL, savePath, imageCount, referer)
 			if err != nil {
 				continue
 			}
@@ -131,7 +144,7 @@ func (s *ContentSaver) downloadImages(markdown, savePath, platform string) (stri
 }
 
 // downloadImage 下载单张图片
-func (s *ContentSaver) downloadImage(imageURL, savePath string, index int, platform string) (string, error) {
+func (s *ContentSaver) downloadImage(ctx context.Context, imageURL, savePath string, index int, referer string) (string, error) {
 	// 解析URL
 	parsedURL, err := url.Parse(imageURL)
 	if err != nil {
@@ -149,17 +162,15 @@ func (s *ContentSaver) downloadImage(imageURL, savePath string, index int, platf
 	localPath := filepath.Join(savePath, filename)
 
 	// 创建HTTP请求
-	req, err := http.NewRequest("GET", imageURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", imageURL, nil)
 	if err != nil {
 		return "", err
 	}
 
 	// 根据平台设置Referer
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-	if strings.Contains(platform, "小红书") {
-		req.Header.Set("Referer", "https://www.xiaohongshu.com/")
-	} else if strings.Contains(platform, "知乎") {
-		req.Header.Set("Referer", "https://www.zhihu.com/")
+	if referer != "" {
+		req.Header.Set("Referer", referer)
 	}
 
 	// 下载图片
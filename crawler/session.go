@@ -0,0 +1,107 @@
+package crawler
+
+import (
+	"competitive-analyzer/platforms"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// sessionLayer 登录会话爬虫的名称，可写在平台配置的crawlers中指定其位置
+const sessionLayer = "session"
+
+// Session 一个平台的登录会话：Cookie和额外的请求头（如Authorization）
+type Session struct {
+	Cookies []*http.Cookie
+	Headers map[string]string
+}
+
+// SessionStore 平台登录会话的来源
+type SessionStore interface {
+	// Session 平台当前可用的登录会话，没有或已失效时返回nil
+	Session(ctx context.Context, platform string) (*Session, error)
+	// ReportSuccess 使用会话爬取成功
+	ReportSuccess(platform string)
+	// ReportExpired 使用会话仍被跳转到登录页面，会话已失效
+	ReportExpired(platform, reason string)
+	// ReportLoginWall 使用会话后页面中仍有登录提示，可能只是该页面需要更高权限，连续多次时才视为失效
+	ReportLoginWall(platform, reason string)
+}
+
+// SessionCrawler 带登录会话的本地HTTP爬虫：Cookie放入Cookie Jar，跟随重定向时按域名携带，并接收站点下发的新Cookie。
+// 会话只用于直接请求目标站点，不会发送给Firecrawl、Jina等第三方服务
+type SessionCrawler struct {
+	Store  SessionStore
+	native *NativeCrawler
+}
+
+// NewSessionCrawler 创建带登录会话的爬虫
+func NewSessionCrawler(store SessionStore) *SessionCrawler {
+	return &SessionCrawler{Store: store, native: NewNativeCrawler()}
+}
+
+func (s *SessionCrawler) Name() string {
+	return sessionLayer
+}
+
+// Crawl 使用平台的登录会话抓取页面；被跳转到登录页面时报告会话失效，页面中只有登录提示时报告一次登录墙
+func (s *SessionCrawler) Crawl(ctx context.Context, rawURL string, platform *platforms.Platform) (*CrawlResult, error) {
+	session, err := s.Store.Session(ctx, platform.Name)
+	if err != nil {
+		return nil, &CrawlError{Kind: ErrKindNotConfigured, Layer: s.Name(), Message: "读取登录会话失败", Err: err}
+	}
+	if session == nil {
+		return nil, &CrawlError{Kind: ErrKindNotConfigured, Layer: s.Name(), Message: platform.Name + "没有可用的登录会话"}
+	}
+
+	pageURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, &CrawlError{Kind: ErrKindOther, Layer: s.Name(), Message: "无效的URL", Err: err}
+	}
+	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	if err != nil {
+		return nil, &CrawlError{Kind: ErrKindOther, Layer: s.Name(), Message: "创建Cookie Jar失败", Err: err}
+	}
+	setSessionCookies(jar, session.Cookies, platform, pageURL)
+
+	client := &http.Client{Timeout: 30 * time.Second, Jar: jar}
+	result, err := s.native.fetch(ctx, s.Name(), rawURL, platform, client, session.Headers)
+	var crawlErr *CrawlError
+	switch {
+	case err == nil:
+		s.Store.ReportSuccess(platform.Name)
+	case errors.As(err, &crawlErr) && crawlErr.RedirectedToLogin():
+		s.Store.ReportExpired(platform.Name, err.Error())
+	case ErrorKindOf(err) == ErrKindLoginWall:
+		s.Store.ReportLoginWall(platform.Name, err.Error())
+	}
+	return result, err
+}
+
+// setSessionCookies 把会话的Cookie放入Jar：指定了域名的Cookie按其域名设置，
+// 未指定域名的（如从浏览器复制的Cookie请求头）对平台的所有域名和当前页面的主机生效
+func setSessionCookies(jar *cookiejar.Jar, cookies []*http.Cookie, platform *platforms.Platform, pageURL *url.URL) {
+	hosts := append([]string{}, platform.Hosts...)
+	if host := pageURL.Hostname(); host != "" {
+		hosts = append(hosts, host)
+	}
+
+	for _, cookie := range cookies {
+		if cookie.Domain != "" {
+			domain := strings.TrimPrefix(cookie.Domain, ".")
+			jar.SetCookies(&url.URL{Scheme: "https", Host: domain}, []*http.Cookie{cookie})
+			continue
+		}
+		for _, host := range hosts {
+			scoped := *cookie
+			scoped.Domain = host
+			jar.SetCookies(&url.URL{Scheme: "https", Host: host}, []*http.Cookie{&scoped})
+		}
+	}
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"competitive-analyzer/models"
 	"log"
+	"strings"
 
 	"github.com/glebarez/sqlite" // 纯Go SQLite驱动，无需CGO
 	"gorm.io/gorm"
@@ -11,10 +12,13 @@ import (
 
 var DB *gorm.DB
 
+// 任务队列的worker、爬取和监控会并发写库：WAL允许读写并发，busy_timeout让写冲突时等待而不是立即返回SQLITE_BUSY
+const sqlitePragmas = "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+
 // InitDB 初始化数据库
 func InitDB(dbPath string) error {
 	var err error
-	DB, err = gorm.Open(sqlite.Open(dbPath), &gorm.Config{
+	DB, err = gorm.Open(sqlite.Open(sqliteDSN(dbPath)), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 	})
 	if err != nil {
@@ -26,21 +30,44 @@ func InitDB(dbPath string) error {
 		&models.DiscoveryTask{},
 		&models.SearchCache{},
 		&models.Competitor{},
+		&models.CompetitorAlias{},
 		&models.DataSource{},
+		&models.DomainStat{},
+		&models.PlatformSession{},
 		&models.RawContent{},
 		&models.ParsedData{},
 		&models.AnalysisReport{},
 		&models.ChangeLog{},
 		&models.MonitorTask{},
+		&models.Job{},
+		&models.CrawlJob{},
+		&models.CrawlJobItem{},
+		&models.AlertEvent{},
+		&models.NotificationDelivery{},
 	)
 	if err != nil {
 		return err
 	}
 
+	// 旧版本的搜索缓存按query唯一，现在按搜索引擎+查询+参数缓存
+	if DB.Migrator().HasIndex(&models.SearchCache{}, "idx_search_caches_query") {
+		if err := DB.Migrator().DropIndex(&models.SearchCache{}, "idx_search_caches_query"); err != nil {
+			return err
+		}
+	}
+
 	log.Println("数据库初始化成功")
 	return nil
 }
 
+// sqliteDSN 在数据库路径后加上并发相关的pragma，路径中已有参数时追加
+func sqliteDSN(dbPath string) string {
+	if strings.Contains(dbPath, "?") {
+		return dbPath + "&" + sqlitePragmas
+	}
+	return dbPath + "?" + sqlitePragmas
+}
+
 // GetDB 获取数据库实例
 func GetDB() *gorm.DB {
 	return DB
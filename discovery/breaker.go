@@ -0,0 +1,41 @@
+package discovery
+
+import (
+	"competitive-analyzer/breaker"
+	"context"
+	"errors"
+)
+
+// BreakerSearchEngine 带熔断的搜索引擎：连续失败（如额度用尽、触发安全验证）后暂时不再请求该引擎
+type BreakerSearchEngine struct {
+	engine  SearchEngine
+	breaker *breaker.Breaker
+}
+
+// NewBreakerSearchEngine 为搜索引擎添加熔断，breaker为nil时不限制
+func NewBreakerSearchEngine(engine SearchEngine, b *breaker.Breaker) *BreakerSearchEngine {
+	return &BreakerSearchEngine{engine: engine, breaker: b}
+}
+
+func (e *BreakerSearchEngine) Name() string {
+	return e.engine.Name()
+}
+
+func (e *BreakerSearchEngine) Search(ctx context.Context, query string, numResults int) ([]SearchResult, error) {
+	if err := e.breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	results, err := e.engine.Search(ctx, query, numResults)
+	switch {
+	case err == nil:
+		e.breaker.Success()
+	case errors.Is(err, ErrUnsupportedMarket) || errors.Is(err, ErrNoResults):
+		// 引擎有响应，只是不支持该市场或没有结果
+		e.breaker.Success()
+	case ctx.Err() == nil:
+		// 调用方取消导致的失败不计入
+		e.breaker.Failure(err)
+	}
+	return results, err
+}
@@ -0,0 +1,152 @@
+package discovery
+
+import (
+	"competitive-analyzer/models"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type forceRefreshKey struct{}
+
+// WithForceRefresh 返回强制刷新的ctx：跳过缓存读取，搜索结果覆盖原有缓存
+func WithForceRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceRefreshKey{}, true)
+}
+
+func isForceRefresh(ctx context.Context) bool {
+	force, _ := ctx.Value(forceRefreshKey{}).(bool)
+	return force
+}
+
+// CacheStats 本次运行以来的缓存统计
+type CacheStats struct {
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Refreshes int64 `json:"refreshes"` // 强制刷新次数
+	Writes    int64 `json:"writes"`
+}
+
+// 所有CachedSearchEngine共享的计数器
+var cacheCounters struct {
+	hits, misses, refreshes, writes atomic.Int64
+}
+
+// GetCacheStats 获取本次运行以来的缓存统计
+func GetCacheStats() CacheStats {
+	return CacheStats{
+		Hits:      cacheCounters.hits.Load(),
+		Misses:    cacheCounters.misses.Load(),
+		Refreshes: cacheCounters.refreshes.Load(),
+		Writes:    cacheCounters.writes.Load(),
+	}
+}
+
+// CachedSearchEngine 带缓存的搜索引擎，按搜索引擎+查询+参数读写SearchCache表
+type CachedSearchEngine struct {
+	engine SearchEngine
+	db     *gorm.DB
+	cache  *SearchCacheManager
+}
+
+// NewCachedSearchEngine 为搜索引擎添加缓存，cacheDays为缓存有效天数
+func NewCachedSearchEngine(engine SearchEngine, db *gorm.DB, cacheDays int) *CachedSearchEngine {
+	return &CachedSearchEngine{
+		engine: engine,
+		db:     db,
+		cache:  NewSearchCacheManager(cacheDays),
+	}
+}
+
+func (c *CachedSearchEngine) Name() string {
+	return c.engine.Name()
+}
+
+func (c *CachedSearchEngine) Search(ctx context.Context, query string, numResults int) ([]SearchResult, error) {
+	params := fmt.Sprintf("num=%d", numResults)
+	// 不同市场的搜索结果不同，语言和地区也作为缓存参数
+	if locale, ok := LocaleFromContext(ctx); ok {
+		params += fmt.Sprintf("&lang=%s&country=%s", locale.Language, locale.Country)
+	}
+	if page := pageFromContext(ctx); page > 1 {
+		params += fmt.Sprintf("&page=%d", page)
+	}
+	key := cacheKey(c.engine.Name(), query, params)
+
+	if isForceRefresh(ctx) {
+		cacheCounters.refreshes.Add(1)
+	} else if results, ok := c.lookup(key); ok {
+		cacheCounters.hits.Add(1)
+		return results, nil
+	} else {
+		cacheCounters.misses.Add(1)
+	}
+
+	results, err := c.engine.Search(ctx, query, numResults)
+	if err != nil {
+		return nil, err
+	}
+
+	// 空结果可能是临时问题，不缓存
+	if len(results) > 0 {
+		c.store(key, query, params, results)
+	}
+	return results, nil
+}
+
+// lookup 读取未过期的缓存并增加命中次数
+func (c *CachedSearchEngine) lookup(key string) ([]SearchResult, bool) {
+	var entry models.SearchCache
+	if err := c.db.Where("cache_key = ? AND expires_at > ?", key, time.Now()).First(&entry).Error; err != nil {
+		return nil, false
+	}
+
+	data, err := json.Marshal(entry.Results["results"])
+	if err != nil {
+		return nil, false
+	}
+	var results []SearchResult
+	if err := json.Unmarshal(data, &results); err != nil || len(results) == 0 {
+		return nil, false
+	}
+
+	c.db.Model(&entry).UpdateColumn("hit_count", gorm.Expr("hit_count + ?", 1))
+	return results, true
+}
+
+// store 写入或覆盖缓存
+func (c *CachedSearchEngine) store(key, query, params string, results []SearchResult) {
+	entry := models.SearchCache{
+		CacheKey:     key,
+		Query:        query,
+		SearchEngine: c.engine.Name(),
+		Params:       params,
+		Results:      models.JSONB{"results": results},
+		CachedAt:     time.Now(),
+		ExpiresAt:    c.cache.GetExpiresAt(),
+	}
+
+	err := c.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "cache_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"results", "cached_at", "expires_at"}),
+	}).Create(&entry).Error
+	if err != nil {
+		log.Printf("[搜索缓存] 保存缓存失败 %s: %v", query, err)
+		return
+	}
+	cacheCounters.writes.Add(1)
+}
+
+// cacheKey 缓存键：搜索引擎、查询和参数的哈希
+func cacheKey(engine, query, params string) string {
+	sum := sha256.Sum256([]byte(engine + "\x00" + query + "\x00" + params))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,14 +1,18 @@
 package discovery
 
 import (
+	"competitive-analyzer/platforms"
 	"net/url"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 )
 
 // LinkClassifier 链接分类器
-type LinkClassifier struct{}
+type LinkClassifier struct {
+	Platforms *platforms.Registry // 平台配置，用户评价、电商、社交媒体等平台的链接按平台的分类，为nil时使用内置配置
+}
 
 // LinkCategory 链接分类
 type LinkCategory struct {
@@ -37,7 +41,6 @@ func (c *LinkClassifier) classifyByRules(link string) *LinkCategory {
 	}
 
 	path := strings.ToLower(parsedURL.Path)
-	host := strings.ToLower(parsedURL.Host)
 
 	// 官网首页
 	if regexp.MustCompile(`^https?://[\w-]+\.(com|cn|io|ai|net)/?$`).MatchString(link) {
@@ -73,21 +76,13 @@ func (c *LinkClassifier) classifyByRules(link string) *LinkCategory {
 		return &LinkCategory{Type: "博客文章", Priority: 3, Score: 6.0}
 	}
 
-	// 用户评价平台
-	if strings.Contains(host, "xiaohongshu.com") || strings.Contains(host, "zhihu.com") ||
-		strings.Contains(host, "douban.com") {
-		return &LinkCategory{Type: "用户评价", Priority: 2, Score: 8.0}
-	}
-
-	// 电商平台
-	if strings.Contains(host, "taobao.com") || strings.Contains(host, "jd.com") ||
-		strings.Contains(host, "tmall.com") {
-		return &LinkCategory{Type: "电商", Priority: 2, Score: 7.5}
-	}
-
-	// 社交媒体
-	if strings.Contains(host, "weixin.qq.com") || strings.Contains(host, "weibo.com") {
-		return &LinkCategory{Type: "社交媒体", Priority: 3, Score: 6.5}
+	// 用户评价、电商、社交媒体等平台，按平台配置的分类
+	if platform := c.Platforms.Lookup(parsedURL.Hostname()); platform != nil && platform.Category != nil {
+		return &LinkCategory{
+			Type:     platform.Category.Type,
+			Priority: platform.Category.Priority,
+			Score:    platform.Category.Score,
+		}
 	}
 
 	return nil
@@ -130,7 +125,10 @@ func (c *LinkClassifier) classifyByContent(title, description string) *LinkCateg
 }
 
 // LinkScorer 链接评分器
-type LinkScorer struct{}
+type LinkScorer struct {
+	TotalEngines int                      // 参与搜索的引擎数，大于1时多个引擎都返回的链接得分更高
+	Domains      map[string]DomainQuality // 域名的历史质量（按SiteDomain），来自实际爬取和分析结果
+}
 
 // ScoreLink 为链接评分
 func (s *LinkScorer) ScoreLink(result *SearchResult, category *LinkCategory) float64 {
@@ -143,29 +141,81 @@ func (s *LinkScorer) ScoreLink(result *SearchResult, category *LinkCategory) flo
 	// 信息价值评分（来自分类）
 	valueScore := category.Score / 10.0
 
-	// 时效性评分（暂时给固定值，后续可以解析时间）
-	freshnessScore := 0.8
+	// 时效性评分（按发布时间）
+	freshnessScore := FreshnessScore(result.PublishedAt, time.Now())
+
+	// 只有一个搜索引擎时没有一致性可言
+	if s.TotalEngines <= 1 {
+		return s.withDomainQuality(result.URL, relevanceScore*0.4+valueScore*0.4+freshnessScore*0.2)
+	}
+
+	// 跨引擎一致性：返回该链接的引擎占比
+	engines := len(result.Engines)
+	if engines == 0 {
+		engines = 1
+	}
+	agreementScore := float64(engines) / float64(s.TotalEngines)
+	if agreementScore > 1 {
+		agreementScore = 1
+	}
 
 	// 综合评分
-	finalScore := relevanceScore*0.4 + valueScore*0.4 + freshnessScore*0.2
+	finalScore := relevanceScore*0.3 + valueScore*0.4 + freshnessScore*0.15 + agreementScore*0.15
+
+	return s.withDomainQuality(result.URL, finalScore)
+}
+
+// FreshnessScore 按发布时间计算时效性评分：三个月内满分，越旧越低；发布时间未知时为0.8
+func FreshnessScore(publishedAt *time.Time, now time.Time) float64 {
+	if publishedAt == nil {
+		return 0.8
+	}
+	age := now.Sub(*publishedAt)
+	switch {
+	case age <= 90*24*time.Hour:
+		return 1.0
+	case age <= 365*24*time.Hour:
+		return 0.85
+	case age <= 2*365*24*time.Hour:
+		return 0.6
+	default:
+		return 0.4
+	}
+}
 
-	return finalScore
+// IsStale 发布时间是否早于maxAge之前，发布时间未知或maxAge<=0时不算过时
+func IsStale(publishedAt *time.Time, maxAge time.Duration, now time.Time) bool {
+	return publishedAt != nil && maxAge > 0 && now.Sub(*publishedAt) > maxAge
+}
+
+// withDomainQuality 按置信度混入域名的历史质量，样本充足时占40%
+func (s *LinkScorer) withDomainQuality(link string, score float64) float64 {
+	quality, ok := s.Domains[SiteDomain(link)]
+	if !ok {
+		return score
+	}
+	weight := 0.4 * quality.Confidence
+	return score*(1-weight) + quality.Score*weight
 }
 
 // DataSourceInfo 数据源信息
 type DataSourceInfo struct {
-	URL          string  `json:"url"`
-	Title        string  `json:"title"`
-	Description  string  `json:"description"`
-	Type         string  `json:"type"`
-	Priority     int     `json:"priority"`
-	QualityScore float64 `json:"quality_score"`
+	URL          string   `json:"url"`
+	Title        string   `json:"title"`
+	Description  string   `json:"description"`
+	Type         string   `json:"type"`
+	Priority     int      `json:"priority"`
+	QualityScore float64  `json:"quality_score"`
+	Engines      []string `json:"engines,omitempty"` // 返回该链接的搜索引擎
+
+	PublishedAt *time.Time `json:"published_at,omitempty"` // 发布时间
 }
 
-// ProcessSearchResults 处理搜索结果，分类和评分
-func ProcessSearchResults(results []SearchResult) []*DataSourceInfo {
-	classifier := &LinkClassifier{}
-	scorer := &LinkScorer{}
+// ProcessSearchResults 处理搜索结果，分类和评分，按质量分从高到低排序；
+// totalEngines为参与搜索的引擎数，domains为域名的历史质量（可为nil），registry为平台配置（为nil时使用内置配置）
+func ProcessSearchResults(results []SearchResult, totalEngines int, domains map[string]DomainQuality, registry *platforms.Registry) []*DataSourceInfo {
+	classifier := &LinkClassifier{Platforms: registry}
+	scorer := &LinkScorer{TotalEngines: totalEngines, Domains: domains}
 
 	dataSources := []*DataSourceInfo{}
 
@@ -188,9 +238,14 @@ func ProcessSearchResults(results []SearchResult) []*DataSourceInfo {
 			Type:         category.Type,
 			Priority:     category.Priority,
 			QualityScore: score,
+			Engines:      result.Engines,
+			PublishedAt:  result.PublishedAt,
 		})
 	}
 
+	sort.SliceStable(dataSources, func(i, j int) bool {
+		return dataSources[i].QualityScore > dataSources[j].QualityScore
+	})
 	return dataSources
 }
 
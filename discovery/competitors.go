@@ -0,0 +1,182 @@
+package discovery
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 对比/盘点类文章的标题和URL关键词
+var articleKeywords = []string{
+	"竞品", "对比", "比较", "替代", "排行", "排名", "推荐", "盘点", "哪个好", "横评", "评测", "清单",
+	"vs", "versus", "alternative", "best", "top", "compare", "comparison", "review",
+}
+
+// IsComparisonArticle 判断搜索结果是否为对比/盘点类文章
+func IsComparisonArticle(result SearchResult) bool {
+	text := strings.ToLower(result.Title + " " + result.URL)
+	for _, keyword := range articleKeywords {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectArticles 选出用于提取竞品的文章：优先对比/盘点类文章，不足时用其他结果补充。
+// 发布时间早于maxAge之前的文章已经过时，不会被选中（maxAge<=0时不过滤）
+func SelectArticles(results []SearchResult, maxArticles int, maxAge time.Duration) []SearchResult {
+	var comparisons, others []SearchResult
+	seen := make(map[string]bool)
+	now := time.Now()
+
+	for _, result := range results {
+		if result.URL == "" || seen[result.URL] || IsStale(result.PublishedAt, maxAge, now) {
+			continue
+		}
+		seen[result.URL] = true
+
+		if IsComparisonArticle(result) {
+			comparisons = append(comparisons, result)
+		} else {
+			others = append(others, result)
+		}
+	}
+
+	articles := append(comparisons, others...)
+	if len(articles) > maxArticles {
+		articles = articles[:maxArticles]
+	}
+	return articles
+}
+
+// CompetitorMention 文章中提到的一个竞品
+type CompetitorMention struct {
+	Name       string
+	Confidence float64
+	Reason     string
+	SourceURL  string
+}
+
+// CompetitorVote 竞品在多篇文章中的投票结果
+type CompetitorVote struct {
+	Name       string   `json:"name"`
+	Votes      int      `json:"votes"`      // 提到该竞品的文章数
+	Confidence float64  `json:"confidence"` // 提到该竞品的文章中的平均置信度
+	Score      float64  `json:"score"`      // 各文章置信度之和 / 文章总数
+	Reasons    []string `json:"reasons"`
+	Sources    []string `json:"sources"`
+}
+
+const maxVoteReasons = 3
+
+// VoteCompetitors 汇总多篇文章的竞品提及：同一篇文章中的重复提及只计一票（取最高置信度），
+// 按得分排序。articleCount为参与提取的文章数，topic本身不作为竞品
+func VoteCompetitors(mentions []CompetitorMention, articleCount int, topic string) []CompetitorVote {
+	if articleCount <= 0 {
+		articleCount = 1
+	}
+
+	type tally struct {
+		vote     CompetitorVote
+		perURL   map[string]float64
+		spelling map[string]int
+		total    float64
+		order    int
+	}
+
+	topicKey := normalizeCompetitorName(topic)
+	tallies := make(map[string]*tally)
+
+	for _, mention := range mentions {
+		name := strings.TrimSpace(mention.Name)
+		key := normalizeCompetitorName(name)
+		if key == "" || key == topicKey {
+			continue
+		}
+
+		t, ok := tallies[key]
+		if !ok {
+			t = &tally{perURL: make(map[string]float64), spelling: make(map[string]int), order: len(tallies)}
+			tallies[key] = t
+		}
+		t.spelling[name]++
+
+		confidence := mention.Confidence
+		if confidence <= 0 || confidence > 1 {
+			confidence = 0.5 // 缺失或超出范围的置信度按中等处理
+		}
+		previous, counted := t.perURL[mention.SourceURL]
+		if !counted {
+			t.vote.Sources = append(t.vote.Sources, mention.SourceURL)
+		}
+		if !counted || confidence > previous {
+			t.perURL[mention.SourceURL] = confidence
+		}
+
+		if mention.Reason != "" && len(t.vote.Reasons) < maxVoteReasons && !containsString(t.vote.Reasons, mention.Reason) {
+			t.vote.Reasons = append(t.vote.Reasons, mention.Reason)
+		}
+	}
+
+	ordered := make([]*tally, 0, len(tallies))
+	for _, t := range tallies {
+		// 使用出现次数最多的写法作为名称
+		best := 0
+		for spelling, count := range t.spelling {
+			if count > best || (count == best && spelling < t.vote.Name) {
+				t.vote.Name, best = spelling, count
+			}
+		}
+
+		for _, confidence := range t.perURL {
+			t.total += confidence
+		}
+		t.vote.Votes = len(t.perURL)
+		t.vote.Confidence = round2(t.total / float64(t.vote.Votes))
+		t.vote.Score = round2(t.total / float64(articleCount))
+		ordered = append(ordered, t)
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := ordered[i], ordered[j]
+		if a.total != b.total {
+			return a.total > b.total
+		}
+		if a.vote.Votes != b.vote.Votes {
+			return a.vote.Votes > b.vote.Votes
+		}
+		return a.order < b.order
+	})
+
+	votes := make([]CompetitorVote, len(ordered))
+	for i, t := range ordered {
+		votes[i] = t.vote
+	}
+	return votes
+}
+
+// normalizeCompetitorName 归一化竞品名称用于合并：忽略大小写、空格和标点
+func normalizeCompetitorName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func round2(v float64) float64 {
+	return float64(int(v*100+0.5)) / 100
+}
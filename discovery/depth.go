@@ -0,0 +1,87 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+)
+
+// 搜索深度
+const (
+	DepthQuick    = "quick"
+	DepthStandard = "standard"
+	DepthDeep     = "deep"
+)
+
+// DepthPlan 搜索深度对应的发现策略
+type DepthPlan struct {
+	Depth            string `json:"depth"`
+	QueryVariants    int    `json:"query_variants"`    // 使用的竞品发现查询数（按模板顺序），0表示全部
+	ResultsPerQuery  int    `json:"results_per_query"` // 每个查询每页的结果数
+	Pages            int    `json:"pages"`             // 每个查询翻页数
+	Articles         int    `json:"articles"`          // 用于提取竞品的对比/盘点文章数
+	Snowball         int    `json:"snowball"`          // 对排名前N的竞品追加"X vs 主题""X 替代品"查询，0为不追加
+	SnowballArticles int    `json:"snowball_articles"` // 追加查询中额外提取的文章数
+	SourcesPerType   int    `json:"sources_per_type"`  // 每种数据源类型的搜索结果数
+	CrawlBudget      int    `json:"crawl_budget"`      // 全流程自动化时每个竞品最多爬取的URL数
+}
+
+var depthPlans = map[string]DepthPlan{
+	DepthQuick: {
+		Depth:           DepthQuick,
+		QueryVariants:   3,
+		ResultsPerQuery: 5,
+		Pages:           1,
+		Articles:        3,
+		SourcesPerType:  3,
+		CrawlBudget:     2,
+	},
+	DepthStandard: {
+		Depth:            DepthStandard,
+		QueryVariants:    6,
+		ResultsPerQuery:  10,
+		Pages:            1,
+		Articles:         5,
+		Snowball:         2,
+		SnowballArticles: 2,
+		SourcesPerType:   5,
+		CrawlBudget:      3,
+	},
+	DepthDeep: {
+		Depth:            DepthDeep,
+		QueryVariants:    0,
+		ResultsPerQuery:  10,
+		Pages:            2,
+		Articles:         8,
+		Snowball:         5,
+		SnowballArticles: 5,
+		SourcesPerType:   8,
+		CrawlBudget:      5,
+	},
+}
+
+// PlanForDepth 搜索深度对应的发现策略，depth为空时使用standard
+func PlanForDepth(depth string) (DepthPlan, error) {
+	if depth == "" {
+		depth = DepthStandard
+	}
+	plan, ok := depthPlans[depth]
+	if !ok {
+		return DepthPlan{}, fmt.Errorf("不支持的搜索深度: %s（可选 quick/standard/deep）", depth)
+	}
+	return plan, nil
+}
+
+type pageKey struct{}
+
+// WithPage 设置搜索结果页码（从1开始）
+func WithPage(ctx context.Context, page int) context.Context {
+	return context.WithValue(ctx, pageKey{}, page)
+}
+
+// pageFromContext 搜索结果页码，未设置时为1
+func pageFromContext(ctx context.Context) int {
+	if page, ok := ctx.Value(pageKey{}).(int); ok && page > 1 {
+		return page
+	}
+	return 1
+}
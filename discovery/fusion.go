@@ -0,0 +1,107 @@
+package discovery
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// 倒数排名融合（RRF）的平滑常数，常用取值60
+const rrfK = 60
+
+// EngineResults 一个搜索引擎返回的结果列表
+type EngineResults struct {
+	Engine  string
+	Results []SearchResult
+}
+
+// FuseResults 用倒数排名融合合并多个搜索引擎的结果：每条结果得分为各引擎中 1/(k+排名) 之和，
+// 多个引擎都靠前的结果排在前面。同一URL（忽略www、末尾斜杠和锚点）只保留一条，并记录返回它的引擎
+func FuseResults(lists []EngineResults, maxResults int) []SearchResult {
+	type fused struct {
+		result SearchResult
+		score  float64
+		order  int
+	}
+
+	merged := make(map[string]*fused)
+	var order []string
+
+	for _, list := range lists {
+		for i, result := range list.Results {
+			if result.URL == "" {
+				continue
+			}
+			rank := result.Position
+			if rank <= 0 {
+				rank = i + 1
+			}
+
+			key := fusionKey(result.URL)
+			f, ok := merged[key]
+			if !ok {
+				f = &fused{result: result, order: len(order)}
+				f.result.Engines = nil
+				merged[key] = f
+				order = append(order, key)
+			}
+			if f.result.Description == "" {
+				f.result.Description = result.Description
+			}
+			if f.result.PublishedAt == nil {
+				f.result.PublishedAt = result.PublishedAt
+			}
+			if !containsString(f.result.Engines, list.Engine) {
+				f.score += 1.0 / float64(rrfK+rank)
+				f.result.Engines = append(f.result.Engines, list.Engine)
+			}
+		}
+	}
+
+	ranked := make([]*fused, 0, len(merged))
+	for _, key := range order {
+		ranked = append(ranked, merged[key])
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		return ranked[i].order < ranked[j].order
+	})
+
+	if maxResults > 0 && len(ranked) > maxResults {
+		ranked = ranked[:maxResults]
+	}
+
+	results := make([]SearchResult, len(ranked))
+	for i, f := range ranked {
+		results[i] = f.result
+		results[i].Position = i + 1
+	}
+	return results
+}
+
+// mergeEngines 合并两条相同URL结果的引擎列表
+func mergeEngines(engines, more []string) []string {
+	for _, engine := range more {
+		if !containsString(engines, engine) {
+			engines = append(engines, engine)
+		}
+	}
+	return engines
+}
+
+// fusionKey 用于判断不同引擎返回的是否为同一URL
+func fusionKey(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	path := strings.TrimRight(u.EscapedPath(), "/")
+	key := host + path
+	if u.RawQuery != "" {
+		key += "?" + u.RawQuery
+	}
+	return key
+}
@@ -0,0 +1,346 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+// 抓取搜索结果页使用的浏览器UA，搜索引擎会拦截默认的Go客户端UA
+const browserUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Safari/537.36"
+
+// BaiduSearchEngine 百度搜索（解析网页结果，无需API Key）
+type BaiduSearchEngine struct{}
+
+func (b *BaiduSearchEngine) Name() string {
+	return "baidu"
+}
+
+func (b *BaiduSearchEngine) Search(ctx context.Context, query string, numResults int) ([]SearchResult, error) {
+	if locale, ok := LocaleFromContext(ctx); ok && locale.Country != "" && locale.Country != "cn" {
+		return nil, fmt.Errorf("百度搜索%w: %s", ErrUnsupportedMarket, locale.Market)
+	}
+
+	searchURL := fmt.Sprintf("https://www.baidu.com/s?wd=%s&rn=%d&ie=utf-8", url.QueryEscape(query), numResults)
+	if page := pageFromContext(ctx); page > 1 {
+		searchURL += fmt.Sprintf("&pn=%d", (page-1)*numResults)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, finalURL, err := fetchResultPage(req)
+	if err != nil {
+		return nil, fmt.Errorf("百度搜索失败: %w", err)
+	}
+	if strings.Contains(finalURL, "wappass.baidu.com") || strings.Contains(htmlText(doc), "百度安全验证") {
+		return nil, errors.New("百度搜索触发了安全验证")
+	}
+
+	results := []SearchResult{}
+	walkHTML(doc, func(n *html.Node) bool {
+		if len(results) >= numResults {
+			return false
+		}
+		if n.DataAtom != atom.Div || !hasClass(n, "c-container") || hasClass(n, "result-op") {
+			return true
+		}
+
+		heading := findElement(n, atom.H3)
+		if heading == nil {
+			return false
+		}
+		link := findElement(heading, atom.A)
+		if link == nil {
+			return false
+		}
+
+		// 结果链接是百度跳转地址，真实地址在容器的mu属性中，没有时再解析跳转
+		target := htmlAttr(n, "mu")
+		if target == "" {
+			target = resolveBaiduLink(ctx, htmlAttr(link, "href"))
+		}
+		if target == "" || !strings.HasPrefix(target, "http") {
+			return false
+		}
+
+		results = append(results, SearchResult{
+			Title:       htmlText(heading),
+			URL:         target,
+			Description: baiduAbstract(n, htmlText(heading)),
+			Position:    len(results) + 1,
+		})
+		return false
+	})
+
+	if len(results) == 0 {
+		return nil, fmt.Errorf("百度%w", ErrNoResults)
+	}
+	return results, nil
+}
+
+// resolveBaiduLink 请求百度跳转链接（不跟随跳转），从Location取得真实地址
+func resolveBaiduLink(ctx context.Context, link string) string {
+	if !strings.Contains(link, "baidu.com/link") {
+		return link
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "HEAD", link, nil)
+	if err != nil {
+		return ""
+	}
+	req.Header.Set("User-Agent", browserUserAgent)
+
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ""
+	}
+	resp.Body.Close()
+	return resp.Header.Get("Location")
+}
+
+// baiduAbstract 结果摘要：优先取摘要元素，没有时取容器中除标题外的文字
+func baiduAbstract(container *html.Node, title string) string {
+	var abstract string
+	walkHTML(container, func(n *html.Node) bool {
+		if abstract != "" {
+			return false
+		}
+		if n.Type == html.ElementNode && (hasClassPrefix(n, "c-abstract") || hasClassPrefix(n, "content-right")) {
+			abstract = htmlText(n)
+			return false
+		}
+		return true
+	})
+	if abstract == "" {
+		abstract = strings.TrimSpace(strings.TrimPrefix(htmlText(container), title))
+	}
+	return truncateRunes(abstract, 300)
+}
+
+// DuckDuckGoSearchEngine DuckDuckGo搜索（解析HTML版结果页，无需API Key）
+type DuckDuckGoSearchEngine struct {
+	Region string // 可选，地区代码，为空时使用搜索市场的地区，默认 cn-zh
+}
+
+func (d *DuckDuckGoSearchEngine) Name() string {
+	return "duckduckgo"
+}
+
+func (d *DuckDuckGoSearchEngine) Search(ctx context.Context, query string, numResults int) ([]SearchResult, error) {
+	region := d.Region
+	if locale, ok := LocaleFromContext(ctx); ok && region == "" {
+		region = locale.DuckDuckGoRegion
+	}
+	if region == "" {
+		region = "cn-zh"
+	}
+
+	form := url.Values{}
+	form.Set("q", query)
+	form.Set("kl", region)
+	// HTML版每页约30条结果，这里只取前numResults条，按numResults计算下一页的起始位置，避免跳过结果
+	if page := pageFromContext(ctx); page > 1 {
+		form.Set("s", fmt.Sprint((page-1)*numResults))
+		form.Set("dc", fmt.Sprint((page-1)*numResults+1))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://html.duckduckgo.com/html/", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	doc, _, err := fetchResultPage(req)
+	if err != nil {
+		return nil, fmt.Errorf("DuckDuckGo搜索失败: %w", err)
+	}
+
+	results := []SearchResult{}
+	walkHTML(doc, func(n *html.Node) bool {
+		if len(results) >= numResults {
+			return false
+		}
+		if n.DataAtom != atom.Div || !hasClass(n, "result") || hasClass(n, "result--ad") {
+			return true
+		}
+
+		var link, snippet *html.Node
+		walkHTML(n, func(child *html.Node) bool {
+			if child.Type == html.ElementNode {
+				if link == nil && hasClass(child, "result__a") {
+					link = child
+				}
+				if snippet == nil && hasClass(child, "result__snippet") {
+					snippet = child
+				}
+			}
+			return true
+		})
+		if link == nil {
+			return false
+		}
+
+		target := duckDuckGoTarget(htmlAttr(link, "href"))
+		if target == "" {
+			return false
+		}
+
+		result := SearchResult{Title: htmlText(link), URL: target, Position: len(results) + 1}
+		if snippet != nil {
+			result.Description = htmlText(snippet)
+		}
+		results = append(results, result)
+		return false
+	})
+
+	if len(results) == 0 {
+		return nil, fmt.Errorf("DuckDuckGo%w", ErrNoResults)
+	}
+	return results, nil
+}
+
+// duckDuckGoTarget 结果链接可能是 //duckduckgo.com/l/?uddg=<真实地址> 形式的跳转地址
+func duckDuckGoTarget(href string) string {
+	if strings.HasPrefix(href, "//") {
+		href = "https:" + href
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	if strings.HasSuffix(u.Hostname(), "duckduckgo.com") {
+		if target := u.Query().Get("uddg"); target != "" {
+			return target
+		}
+		return ""
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ""
+	}
+	return href
+}
+
+// fetchResultPage 请求搜索结果页并解析HTML，返回文档和最终地址
+func fetchResultPage(req *http.Request) (*html.Node, string, error) {
+	req.Header.Set("User-Agent", browserUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8")
+	acceptLanguage := "zh-CN,zh;q=0.9,en;q=0.8"
+	if locale, ok := LocaleFromContext(req.Context()); ok && locale.Language != "" {
+		acceptLanguage = locale.Language + ",en;q=0.8"
+	}
+	req.Header.Set("Accept-Language", acceptLanguage)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("返回错误 %d", resp.StatusCode)
+	}
+
+	reader, err := charset.NewReader(io.LimitReader(resp.Body, 5<<20), resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, "", err
+	}
+	doc, err := html.Parse(reader)
+	if err != nil {
+		return nil, "", fmt.Errorf("解析HTML失败: %w", err)
+	}
+	return doc, resp.Request.URL.String(), nil
+}
+
+// walkHTML 深度优先遍历，fn返回false时不再进入子节点
+func walkHTML(n *html.Node, fn func(*html.Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walkHTML(c, fn)
+	}
+}
+
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	var found *html.Node
+	walkHTML(n, func(c *html.Node) bool {
+		if found != nil {
+			return false
+		}
+		if c.DataAtom == a {
+			found = c
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+func htmlAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(htmlAttr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+func hasClassPrefix(n *html.Node, prefix string) bool {
+	for _, c := range strings.Fields(htmlAttr(n, "class")) {
+		if strings.HasPrefix(c, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// htmlText 节点的文字内容，合并空白，忽略脚本和样式
+func htmlText(n *html.Node) string {
+	var b strings.Builder
+	walkHTML(n, func(c *html.Node) bool {
+		if c.DataAtom == atom.Script || c.DataAtom == atom.Style {
+			return false
+		}
+		if c.Type == html.TextNode {
+			b.WriteString(c.Data)
+			b.WriteByte(' ')
+		}
+		return true
+	})
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func truncateRunes(s string, max int) string {
+	if runes := []rune(s); len(runes) > max {
+		return string(runes[:max])
+	}
+	return s
+}
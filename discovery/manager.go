@@ -1,83 +1,197 @@
 package discovery
 
 import (
+	"competitive-analyzer/pubdate"
+	"context"
 	"fmt"
 	"sync"
+	"time"
 )
 
-// QueryGenerator 查询生成器
-type QueryGenerator struct{}
+// QueryGenerator 查询生成器，按市场使用对应的查询模板
+type QueryGenerator struct {
+	templates *QueryTemplates
+}
+
+// NewQueryGenerator 创建查询生成器，templates为nil时使用内置模板
+func NewQueryGenerator(templates *QueryTemplates) *QueryGenerator {
+	if templates == nil {
+		templates = mustDefaultTemplates()
+	}
+	return &QueryGenerator{templates: templates}
+}
 
 // GenerateCompetitorQueries 生成竞品发现查询
-func (q *QueryGenerator) GenerateCompetitorQueries(topic string) []string {
-	return []string{
-		fmt.Sprintf("%s 竞品", topic),
-		fmt.Sprintf("%s 对比", topic),
-		fmt.Sprintf("%s 替代品", topic),
-		fmt.Sprintf("best %s alternatives", topic),
-		fmt.Sprintf("%s vs", topic),
-		fmt.Sprintf("%s 排行榜", topic),
-		fmt.Sprintf("%s 推荐", topic),
-		fmt.Sprintf("top %s tools", topic),
-	}
-}
-
-// GenerateDataSourceQueries 生成数据源查询
-func (q *QueryGenerator) GenerateDataSourceQueries(competitorName string) map[string][]string {
-	return map[string][]string{
-		"官网": {
-			fmt.Sprintf("%s 官网", competitorName),
-			fmt.Sprintf("%s official website", competitorName),
-		},
-		"产品功能": {
-			fmt.Sprintf("%s features", competitorName),
-			fmt.Sprintf("%s 功能介绍", competitorName),
-			fmt.Sprintf("%s 产品", competitorName),
-		},
-		"定价": {
-			fmt.Sprintf("%s pricing", competitorName),
-			fmt.Sprintf("%s 价格", competitorName),
-			fmt.Sprintf("%s 套餐", competitorName),
-		},
-		"用户评价": {
-			fmt.Sprintf("%s 评价 site:xiaohongshu.com", competitorName),
-			fmt.Sprintf("%s 怎么样 site:zhihu.com", competitorName),
-			fmt.Sprintf("%s reviews", competitorName),
-		},
-		"电商": {
-			fmt.Sprintf("%s site:taobao.com", competitorName),
-			fmt.Sprintf("%s site:jd.com", competitorName),
-		},
-		"社交媒体": {
-			fmt.Sprintf("%s 公众号", competitorName),
-			fmt.Sprintf("%s 微博", competitorName),
-		},
+func (q *QueryGenerator) GenerateCompetitorQueries(topic, market string) []string {
+	_, templates := q.templates.Resolve(market)
+
+	queries := make([]string, len(templates.Competitor))
+	for i, template := range templates.Competitor {
+		queries[i] = fillTemplate(template, map[string]string{"topic": topic})
+	}
+	return queries
+}
+
+// GenerateSnowballQueries 生成针对已发现竞品的追加查询
+func (q *QueryGenerator) GenerateSnowballQueries(topic, competitorName, market string) []string {
+	_, templates := q.templates.Resolve(market)
+
+	list := templates.Snowball
+	if len(list) == 0 {
+		list = []string{"{name} vs {topic}", "alternatives to {name}"}
 	}
+
+	queries := make([]string, len(list))
+	for i, template := range list {
+		queries[i] = fillTemplate(template, map[string]string{"topic": topic, "name": competitorName})
+	}
+	return queries
 }
 
+// GenerateDataSourceQueries 生成数据源查询；已知官网域名时，官网、产品功能和定价等查询限定在官网内（site:）
+func (q *QueryGenerator) GenerateDataSourceQueries(competitorName, officialDomain, market string) map[string][]string {
+	_, templates := q.templates.Resolve(market)
+	values := map[string]string{"name": competitorName, "domain": officialDomain}
+
+	queries := make(map[string][]string, len(templates.DataSources))
+	for sourceType, list := range templates.DataSources {
+		if scoped, ok := templates.OfficialSite[sourceType]; ok && officialDomain != "" {
+			list = scoped
+		}
+		for _, template := range list {
+			queries[sourceType] = append(queries[sourceType], fillTemplate(template, values))
+		}
+	}
+	return queries
+}
+
+// 搜索模式
+const (
+	SearchModeFanout   = "fanout"   // 同时查询所有搜索引擎，用倒数排名融合合并结果
+	SearchModeFallback = "fallback" // 按顺序使用第一个成功的搜索引擎
+)
+
 // SearchManager 搜索管理器
 type SearchManager struct {
-	engines       []SearchEngine
-	queryGen      *QueryGenerator
-	maxWorkers    int
+	engines    []SearchEngine
+	queryGen   *QueryGenerator
+	maxWorkers int
+	mode       string
 }
 
-// NewSearchManager 创建搜索管理器
-func NewSearchManager(engines []SearchEngine) *SearchManager {
+// NewSearchManager 创建搜索管理器，mode为空时使用fallback，templates为nil时使用内置查询模板
+func NewSearchManager(engines []SearchEngine, mode string, templates *QueryTemplates) *SearchManager {
+	if mode != SearchModeFanout {
+		mode = SearchModeFallback
+	}
 	return &SearchManager{
 		engines:    engines,
-		queryGen:   &QueryGenerator{},
+		queryGen:   NewQueryGenerator(templates),
 		maxWorkers: 5,
+		mode:       mode,
+	}
+}
+
+// WithMarket 按市场设置搜索的语言和地区，之后的查询使用该市场的查询模板
+func (m *SearchManager) WithMarket(ctx context.Context, market string) context.Context {
+	return WithLocale(ctx, m.queryGen.templates.Locale(market))
+}
+
+// market 当前搜索的市场
+func market(ctx context.Context) string {
+	locale, _ := LocaleFromContext(ctx)
+	return locale.Market
+}
+
+// EngineCount 每个查询使用的搜索引擎数量，用于跨引擎一致性评分：fanout模式为已配置的引擎数，fallback模式只用一个引擎，为1
+func (m *SearchManager) EngineCount() int {
+	if m.mode == SearchModeFallback {
+		return min(len(m.engines), 1)
 	}
+	return len(m.engines)
 }
 
-// SearchCompetitors 搜索竞品
-func (m *SearchManager) SearchCompetitors(topic string, maxResults int) ([]SearchResult, error) {
-	queries := m.queryGen.GenerateCompetitorQueries(topic)
+// searchQuery 执行一个查询：fanout模式查询所有引擎并融合结果，fallback模式使用第一个返回结果的引擎
+func (m *SearchManager) searchQuery(ctx context.Context, query string, numResults, pages int) ([]SearchResult, error) {
+	if m.mode == SearchModeFallback || len(m.engines) == 1 {
+		for _, engine := range m.engines {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			results, err := searchPages(ctx, engine, query, numResults, pages)
+			if err == nil && len(results) > 0 {
+				return results, nil
+			}
+		}
+		return nil, fmt.Errorf("所有搜索引擎都失败")
+	}
 
-	// 并发搜索
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		lists = make([]EngineResults, len(m.engines))
+	)
+	for i, engine := range m.engines {
+		wg.Add(1)
+		go func(i int, engine SearchEngine) {
+			defer wg.Done()
+			results, err := searchPages(ctx, engine, query, numResults, pages)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			lists[i] = EngineResults{Engine: engine.Name(), Results: results}
+			mu.Unlock()
+		}(i, engine)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	fused := FuseResults(lists, numResults*max(pages, 1))
+	if len(fused) == 0 {
+		return nil, fmt.Errorf("所有搜索引擎都失败")
+	}
+	return fused, nil
+}
+
+// searchPages 用一个引擎搜索多页结果，排名按页顺延；某一页结果不足时不再翻页
+func searchPages(ctx context.Context, engine SearchEngine, query string, numResults, pages int) ([]SearchResult, error) {
+	var all []SearchResult
+	for page := 1; page <= max(pages, 1); page++ {
+		results, err := engine.Search(WithPage(ctx, page), query, numResults)
+		if err != nil {
+			if page == 1 {
+				return nil, err
+			}
+			break
+		}
+
+		for i := range results {
+			results[i].Engines = []string{engine.Name()}
+			if results[i].Position <= 0 {
+				results[i].Position = i + 1
+			}
+			results[i].Position += (page - 1) * numResults
+			if results[i].PublishedAt == nil {
+				if t, ok := pubdate.FromSnippet(results[i].Description, time.Now()); ok {
+					results[i].PublishedAt = &t
+				}
+			}
+		}
+		all = append(all, results...)
+
+		if len(results) < numResults {
+			break
+		}
+	}
+	return all, nil
+}
+
+// searchAll 并发执行多个查询，合并去重结果
+func (m *SearchManager) searchAll(ctx context.Context, queries []string, numResults, pages int) ([]SearchResult, error) {
 	resultChan := make(chan []SearchResult, len(queries))
-	errorChan := make(chan error, len(queries))
 	semaphore := make(chan struct{}, m.maxWorkers)
 
 	var wg sync.WaitGroup
@@ -89,15 +203,11 @@ func (m *SearchManager) SearchCompetitors(topic string, maxResults int) ([]Searc
 			semaphore <- struct{}{}        // 获取信号量
 			defer func() { <-semaphore }() // 释放信号量
 
-			// 使用第一个可用的搜索引擎
-			for _, engine := range m.engines {
-				results, err := engine.Search(q, maxResults)
-				if err == nil {
-					resultChan <- results
-					return
-				}
+			results, err := m.searchQuery(ctx, q, numResults, pages)
+			if err != nil {
+				return
 			}
-			errorChan <- fmt.Errorf("所有搜索引擎都失败")
+			resultChan <- results
 		}(query)
 	}
 
@@ -105,7 +215,6 @@ func (m *SearchManager) SearchCompetitors(topic string, maxResults int) ([]Searc
 	go func() {
 		wg.Wait()
 		close(resultChan)
-		close(errorChan)
 	}()
 
 	// 收集结果
@@ -114,19 +223,50 @@ func (m *SearchManager) SearchCompetitors(topic string, maxResults int) ([]Searc
 		allResults = append(allResults, results...)
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// 去重
-	uniqueResults := m.deduplicateResults(allResults)
+	return m.deduplicateResults(allResults), nil
+}
 
-	if len(uniqueResults) == 0 {
+// SearchCompetitors 按搜索深度策略搜索竞品：使用的查询数、每页结果数和翻页数由plan决定
+func (m *SearchManager) SearchCompetitors(ctx context.Context, topic string, plan DepthPlan) ([]SearchResult, error) {
+	queries := m.queryGen.GenerateCompetitorQueries(topic, market(ctx))
+	if plan.QueryVariants > 0 && len(queries) > plan.QueryVariants {
+		queries = queries[:plan.QueryVariants]
+	}
+
+	results, err := m.searchAll(ctx, queries, plan.ResultsPerQuery, plan.Pages)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
 		return nil, fmt.Errorf("未找到任何搜索结果")
 	}
+	return results, nil
+}
 
-	return uniqueResults, nil
+// SearchSnowball 滚雪球发现：对已发现的竞品追加"X vs 主题""X 替代品"等查询，找到首轮没有覆盖的对比文章
+func (m *SearchManager) SearchSnowball(ctx context.Context, topic string, competitors []string, plan DepthPlan) ([]SearchResult, error) {
+	if plan.Snowball <= 0 || len(competitors) == 0 {
+		return nil, nil
+	}
+	if len(competitors) > plan.Snowball {
+		competitors = competitors[:plan.Snowball]
+	}
+
+	var queries []string
+	for _, name := range competitors {
+		queries = append(queries, m.queryGen.GenerateSnowballQueries(topic, name, market(ctx))...)
+	}
+	return m.searchAll(ctx, queries, plan.ResultsPerQuery, 1)
 }
 
-// SearchDataSources 搜索数据源
-func (m *SearchManager) SearchDataSources(competitorName string, sourceTypes []string, maxPerType int) (map[string][]SearchResult, error) {
-	allQueries := m.queryGen.GenerateDataSourceQueries(competitorName)
+// SearchDataSources 搜索数据源，officialDomain为已验证的官网域名（未知时为空）
+func (m *SearchManager) SearchDataSources(ctx context.Context, competitorName, officialDomain string, sourceTypes []string, maxPerType int) (map[string][]SearchResult, error) {
+	allQueries := m.queryGen.GenerateDataSourceQueries(competitorName, officialDomain, market(ctx))
 
 	// 过滤需要的数据源类型
 	queries := make(map[string][]string)
@@ -154,15 +294,11 @@ func (m *SearchManager) SearchDataSources(competitorName string, sourceTypes []s
 				semaphore <- struct{}{}
 				defer func() { <-semaphore }()
 
-				// 使用第一个可用的搜索引擎
-				for _, engine := range m.engines {
-					searchResults, err := engine.Search(q, maxPerType)
-					if err == nil && len(searchResults) > 0 {
-						mu.Lock()
-						results[st] = append(results[st], searchResults...)
-						mu.Unlock()
-						break
-					}
+				searchResults, err := m.searchQuery(ctx, q, maxPerType, 1)
+				if err == nil {
+					mu.Lock()
+					results[st] = append(results[st], searchResults...)
+					mu.Unlock()
 				}
 			}(sourceType, query)
 		}
@@ -170,6 +306,10 @@ func (m *SearchManager) SearchDataSources(competitorName string, sourceTypes []s
 
 	wg.Wait()
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// 去重每个类型的结果
 	for sourceType, res := range results {
 		results[sourceType] = m.deduplicateResults(res)
@@ -178,16 +318,21 @@ func (m *SearchManager) SearchDataSources(competitorName string, sourceTypes []s
 	return results, nil
 }
 
-// deduplicateResults 去重搜索结果
+// deduplicateResults 去重搜索结果，合并不同查询中返回同一URL的搜索引擎
 func (m *SearchManager) deduplicateResults(results []SearchResult) []SearchResult {
-	seen := make(map[string]bool)
+	seen := make(map[string]int)
 	unique := []SearchResult{}
 
 	for _, result := range results {
-		if !seen[result.URL] {
-			seen[result.URL] = true
-			unique = append(unique, result)
+		if i, ok := seen[result.URL]; ok {
+			unique[i].Engines = mergeEngines(unique[i].Engines, result.Engines)
+			if unique[i].PublishedAt == nil {
+				unique[i].PublishedAt = result.PublishedAt
+			}
+			continue
 		}
+		seen[result.URL] = len(unique)
+		unique = append(unique, result)
 	}
 
 	return unique
@@ -0,0 +1,168 @@
+package discovery
+
+import (
+	"competitive-analyzer/models"
+	"math"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 成功爬取的内容达到该长度（字符）时内容得分为满分
+const fullContentLength = 3000
+
+// 样本数（爬取次数+分析次数）达到该值时完全信任域名的历史质量
+const fullConfidenceSamples = 10
+
+// DomainQuality 域名的历史质量
+type DomainQuality struct {
+	Score      float64 `json:"score"`      // 0-1
+	Confidence float64 `json:"confidence"` // 0-1，随样本数增加
+}
+
+// Expected 按置信度在历史质量和中性值0.5之间插值，用于排序
+func (q DomainQuality) Expected() float64 {
+	return q.Score*q.Confidence + 0.5*(1-q.Confidence)
+}
+
+// CrawlOutcome 一次爬取的结果
+type CrawlOutcome struct {
+	Success       bool
+	Captcha       bool // 触发了验证码或安全验证
+	ContentLength int  // 成功时的内容字符数
+}
+
+// Score 单次爬取的得分，用于更新数据源质量
+func (o CrawlOutcome) Score() float64 {
+	if !o.Success {
+		return 0
+	}
+	return 0.5 + 0.5*math.Min(float64(o.ContentLength)/fullContentLength, 1)
+}
+
+// DomainQualityStore 按域名记录爬取和分析效果（DomainStat表），计算域名质量并更新数据源质量分
+type DomainQualityStore struct {
+	db *gorm.DB
+}
+
+// NewDomainQualityStore 创建域名质量记录
+func NewDomainQualityStore(db *gorm.DB) *DomainQualityStore {
+	return &DomainQualityStore{db: db}
+}
+
+// RecordCrawl 记录一次爬取结果，更新域名统计和该URL数据源的质量分
+func (s *DomainQualityStore) RecordCrawl(rawURL string, outcome CrawlOutcome) {
+	updates := map[string]interface{}{
+		"crawl_attempts": gorm.Expr("crawl_attempts + 1"),
+	}
+	if outcome.Success {
+		updates["crawl_successes"] = gorm.Expr("crawl_successes + 1")
+		updates["content_length"] = gorm.Expr("content_length + ?", outcome.ContentLength)
+	}
+	if outcome.Captcha {
+		updates["captcha_hits"] = gorm.Expr("captcha_hits + 1")
+	}
+	s.updateDomain(rawURL, updates)
+	s.updateSource(rawURL, outcome.Score())
+}
+
+// RecordExtraction 记录一个数据源的内容参与AI分析的结果：内容中是否包含分析出的定价和功能
+func (s *DomainQualityStore) RecordExtraction(rawURL string, pricing, features bool) {
+	updates := map[string]interface{}{
+		"extractions": gorm.Expr("extractions + 1"),
+	}
+	score := 0.5
+	if pricing {
+		updates["pricing_hits"] = gorm.Expr("pricing_hits + 1")
+		score += 0.25
+	}
+	if features {
+		updates["feature_hits"] = gorm.Expr("feature_hits + 1")
+		score += 0.25
+	}
+	s.updateDomain(rawURL, updates)
+	s.updateSource(rawURL, score)
+}
+
+// updateDomain 累加域名统计并重新计算质量分
+func (s *DomainQualityStore) updateDomain(rawURL string, updates map[string]interface{}) {
+	domain := SiteDomain(rawURL)
+	if s.db == nil || domain == "" {
+		return
+	}
+
+	s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.DomainStat{Domain: domain, QualityScore: 0.5})
+	s.db.Model(&models.DomainStat{}).Where("domain = ?", domain).Updates(updates)
+
+	var stat models.DomainStat
+	if err := s.db.Where("domain = ?", domain).First(&stat).Error; err != nil {
+		return
+	}
+	s.db.Model(&stat).Update("quality_score", round2(domainScore(&stat)))
+}
+
+// updateSource 用本次结果的得分平滑更新该URL数据源的质量分
+func (s *DomainQualityStore) updateSource(rawURL string, score float64) {
+	if s.db == nil {
+		return
+	}
+	s.db.Model(&models.DataSource{}).Where("url = ?", rawURL).
+		Update("quality_score", gorm.Expr("ROUND(quality_score * 0.7 + ? * 0.3, 2)", score))
+}
+
+// Load 查询URL所在域名的历史质量，没有记录的域名不返回
+func (s *DomainQualityStore) Load(urls []string) map[string]DomainQuality {
+	qualities := make(map[string]DomainQuality)
+	if s == nil || s.db == nil {
+		return qualities
+	}
+
+	var domains []string
+	seen := make(map[string]bool)
+	for _, u := range urls {
+		if domain := SiteDomain(u); domain != "" && !seen[domain] {
+			seen[domain] = true
+			domains = append(domains, domain)
+		}
+	}
+	if len(domains) == 0 {
+		return qualities
+	}
+
+	var stats []models.DomainStat
+	s.db.Where("domain IN ?", domains).Find(&stats)
+	for i := range stats {
+		qualities[stats[i].Domain] = domainQuality(&stats[i])
+	}
+	return qualities
+}
+
+// domainQuality 域名统计对应的质量和置信度
+func domainQuality(stat *models.DomainStat) DomainQuality {
+	samples := float64(stat.CrawlAttempts + stat.Extractions)
+	return DomainQuality{
+		Score:      stat.QualityScore,
+		Confidence: round2(math.Min(samples/fullConfidenceSamples, 1)),
+	}
+}
+
+// domainScore 域名质量：爬取成功率45%，分析产出（定价/功能）25%，没有验证码15%，内容长度15%。
+// 成功率和产出使用加一平滑，样本少时接近0.5
+func domainScore(stat *models.DomainStat) float64 {
+	success := float64(stat.CrawlSuccesses+1) / float64(stat.CrawlAttempts+2)
+
+	captchaFree := 1.0
+	if stat.CrawlAttempts > 0 {
+		captchaFree = 1 - float64(stat.CaptchaHits)/float64(stat.CrawlAttempts)
+	}
+
+	content := 0.5
+	if stat.CrawlSuccesses > 0 {
+		average := float64(stat.ContentLength) / float64(stat.CrawlSuccesses)
+		content = math.Min(average/fullContentLength, 1)
+	}
+
+	yield := float64(stat.PricingHits+stat.FeatureHits+1) / float64(2*stat.Extractions+2)
+
+	return success*0.45 + yield*0.25 + captchaFree*0.15 + content*0.15
+}
@@ -2,27 +2,39 @@ package discovery
 
 import (
 	"bytes"
+	"competitive-analyzer/pubdate"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
 // SearchEngine 搜索引擎接口
 type SearchEngine interface {
-	Search(query string, numResults int) ([]SearchResult, error)
+	Search(ctx context.Context, query string, numResults int) ([]SearchResult, error)
 	Name() string
 }
 
+// 搜索引擎正常工作但没有给出结果的错误，不计入熔断
+var (
+	ErrUnsupportedMarket = errors.New("不支持该市场")         // 引擎不覆盖当前市场（如百度只搜中国市场）
+	ErrNoResults         = errors.New("搜索结果为空或页面结构已变化") // 结果页没有解析出任何结果
+)
+
 // SearchResult 搜索结果
 type SearchResult struct {
-	Title       string `json:"title"`
-	URL         string `json:"url"`
-	Description string `json:"description"`
-	Position    int    `json:"position"`
+	Title       string   `json:"title"`
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	Position    int      `json:"position"`
+	Engines     []string `json:"engines,omitempty"` // 返回该结果的搜索引擎
+
+	PublishedAt *time.Time `json:"published_at,omitempty"` // 发布时间（引擎返回或从摘要中解析）
 }
 
 // SerperSearchEngine Serper搜索引擎
@@ -34,7 +46,7 @@ func (s *SerperSearchEngine) Name() string {
 	return "serper"
 }
 
-func (s *SerperSearchEngine) Search(query string, numResults int) ([]SearchResult, error) {
+func (s *SerperSearchEngine) Search(ctx context.Context, query string, numResults int) ([]SearchResult, error) {
 	if s.APIKey == "" {
 		return nil, errors.New("Serper API Key未配置")
 	}
@@ -45,13 +57,20 @@ func (s *SerperSearchEngine) Search(query string, numResults int) ([]SearchResul
 		"gl":  "cn", // 中国地区
 		"hl":  "zh-cn",
 	}
+	if page := pageFromContext(ctx); page > 1 {
+		requestBody["page"] = page
+	}
+	if locale, ok := LocaleFromContext(ctx); ok {
+		requestBody["gl"] = locale.Country
+		requestBody["hl"] = googleLanguage(locale.Language)
+	}
 
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", "https://google.serper.dev/search", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://google.serper.dev/search", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, err
 	}
@@ -101,12 +120,14 @@ func (s *SerperSearchEngine) Search(query string, numResults int) ([]SearchResul
 		title, _ := itemMap["title"].(string)
 		link, _ := itemMap["link"].(string)
 		snippet, _ := itemMap["snippet"].(string)
+		date, _ := itemMap["date"].(string) // 如"Mar 5, 2024"、"3 days ago"
 
 		results = append(results, SearchResult{
 			Title:       title,
 			URL:         link,
 			Description: snippet,
 			Position:    i + 1,
+			PublishedAt: parsePublished(date),
 		})
 	}
 
@@ -123,7 +144,7 @@ func (g *GoogleSearchEngine) Name() string {
 	return "google"
 }
 
-func (g *GoogleSearchEngine) Search(query string, numResults int) ([]SearchResult, error) {
+func (g *GoogleSearchEngine) Search(ctx context.Context, query string, numResults int) ([]SearchResult, error) {
 	if g.APIKey == "" || g.EngineID == "" {
 		return nil, errors.New("Google API Key或Engine ID未配置")
 	}
@@ -132,16 +153,33 @@ func (g *GoogleSearchEngine) Search(query string, numResults int) ([]SearchResul
 		"https://www.googleapis.com/customsearch/v1?key=%s&cx=%s&q=%s&num=%d",
 		g.APIKey, g.EngineID, url.QueryEscape(query), numResults,
 	)
+	if locale, ok := LocaleFromContext(ctx); ok {
+		apiURL += fmt.Sprintf("&gl=%s&hl=%s", url.QueryEscape(locale.Country), url.QueryEscape(googleLanguage(locale.Language)))
+	}
+	if page := pageFromContext(ctx); page > 1 {
+		apiURL += fmt.Sprintf("&start=%d", (page-1)*numResults+1)
+	}
 
-	resp, err := http.Get(apiURL)
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 	if err != nil {
 		return nil, err
 	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		// 请求地址带有API Key，错误信息中去掉地址，只保留底层错误
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("Google搜索失败: %w", err)
+	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("Google搜索失败: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -175,6 +213,7 @@ func (g *GoogleSearchEngine) Search(query string, numResults int) ([]SearchResul
 			URL:         link,
 			Description: snippet,
 			Position:    i + 1,
+			PublishedAt: googlePublished(itemMap),
 		})
 	}
 
@@ -190,17 +229,25 @@ func (b *BingSearchEngine) Name() string {
 	return "bing"
 }
 
-func (b *BingSearchEngine) Search(query string, numResults int) ([]SearchResult, error) {
+func (b *BingSearchEngine) Search(ctx context.Context, query string, numResults int) ([]SearchResult, error) {
 	if b.APIKey == "" {
 		return nil, errors.New("Bing API Key未配置")
 	}
 
+	market := "zh-CN"
+	if locale, ok := LocaleFromContext(ctx); ok {
+		market = locale.Language
+	}
+
 	apiURL := fmt.Sprintf(
-		"https://api.bing.microsoft.com/v7.0/search?q=%s&count=%d&mkt=zh-CN",
-		url.QueryEscape(query), numResults,
+		"https://api.bing.microsoft.com/v7.0/search?q=%s&count=%d&mkt=%s",
+		url.QueryEscape(query), numResults, url.QueryEscape(market),
 	)
+	if page := pageFromContext(ctx); page > 1 {
+		apiURL += fmt.Sprintf("&offset=%d", (page-1)*numResults)
+	}
 
-	req, err := http.NewRequest("GET", apiURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -249,14 +296,57 @@ func (b *BingSearchEngine) Search(query string, numResults int) ([]SearchResult,
 		name, _ := itemMap["name"].(string)
 		url, _ := itemMap["url"].(string)
 		snippet, _ := itemMap["snippet"].(string)
+		// dateLastCrawled是Bing的抓取时间，不是发布时间，不使用
+		published, _ := itemMap["datePublished"].(string)
 
 		results = append(results, SearchResult{
 			Title:       name,
 			URL:         url,
 			Description: snippet,
 			Position:    i + 1,
+			PublishedAt: parsePublished(published),
 		})
 	}
 
 	return results, nil
 }
+
+// googleLanguage Google的界面语言参数：中文区分简繁（zh-cn/zh-tw），其他语言只取语言代码（en、ja）
+func googleLanguage(language string) string {
+	language = strings.ToLower(language)
+	if strings.HasPrefix(language, "zh") {
+		return language
+	}
+	if i := strings.Index(language, "-"); i > 0 {
+		return language[:i]
+	}
+	return language
+}
+
+// parsePublished 解析搜索引擎返回的发布时间，无法解析时返回nil
+func parsePublished(value string) *time.Time {
+	if t, ok := pubdate.Parse(value, time.Now()); ok {
+		return &t
+	}
+	return nil
+}
+
+// googlePublished 从Google结果的pagemap.metatags中读取发布时间
+func googlePublished(item map[string]interface{}) *time.Time {
+	pagemap, _ := item["pagemap"].(map[string]interface{})
+	metatags, _ := pagemap["metatags"].([]interface{})
+	for _, tags := range metatags {
+		tagMap, ok := tags.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, key := range []string{"article:published_time", "og:published_time", "datepublished", "date"} {
+			if value, ok := tagMap[key].(string); ok {
+				if published := parsePublished(value); published != nil {
+					return published
+				}
+			}
+		}
+	}
+	return nil
+}
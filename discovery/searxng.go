@@ -0,0 +1,139 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SearxNGSearchEngine 自建SearxNG实例（JSON API），实例的settings.yml需要在search.formats中启用json
+type SearxNGSearchEngine struct {
+	BaseURL  string // 如 http://localhost:8888
+	Engines  string // 可选，指定SearxNG使用的上游引擎，如 "google,bing"
+	Language string // 可选，为空时使用搜索市场的语言，默认 zh-CN
+}
+
+func (s *SearxNGSearchEngine) Name() string {
+	return "searxng"
+}
+
+// SearxNG每页的结果数由上游引擎决定，不能指定；最多读取的SearxNG页数
+const searxMaxPages = 10
+
+// Search 搜索第page页（每页numResults条）：SearxNG的页大小固定且未知，从第1页开始逐页读取，
+// 跳过前面各页的结果后取numResults条，避免每页截断导致跳过结果
+func (s *SearxNGSearchEngine) Search(ctx context.Context, query string, numResults int) ([]SearchResult, error) {
+	if s.BaseURL == "" {
+		return nil, errors.New("SearxNG地址未配置")
+	}
+
+	language := s.Language
+	if locale, ok := LocaleFromContext(ctx); ok && language == "" {
+		language = locale.Language
+	}
+	if language == "" {
+		language = "zh-CN"
+	}
+
+	offset := (pageFromContext(ctx) - 1) * numResults
+	skipped := 0
+	results := []SearchResult{}
+	for pageno := 1; pageno <= searxMaxPages && len(results) < numResults; pageno++ {
+		items, err := s.searchPage(ctx, query, language, pageno)
+		if err != nil {
+			if len(results) > 0 {
+				break
+			}
+			return nil, err
+		}
+		if len(items) == 0 {
+			break
+		}
+
+		for _, item := range items {
+			if len(results) >= numResults {
+				break
+			}
+			if skipped < offset {
+				skipped++
+				continue
+			}
+			item.Position = len(results) + 1
+			results = append(results, item)
+		}
+	}
+
+	return results, nil
+}
+
+// searchPage 请求SearxNG的第pageno页，返回该页的全部结果
+func (s *SearxNGSearchEngine) searchPage(ctx context.Context, query, language string, pageno int) ([]SearchResult, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("format", "json")
+	params.Set("language", language)
+	params.Set("categories", "general")
+	params.Set("pageno", fmt.Sprint(pageno))
+	if s.Engines != "" {
+		params.Set("engines", s.Engines)
+	}
+	apiURL := strings.TrimRight(s.BaseURL, "/") + "/search?" + params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusForbidden {
+		return nil, errors.New("SearxNG拒绝了JSON请求，请在settings.yml的search.formats中启用json")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("SearxNG返回错误 %d: %s", resp.StatusCode, string(body))
+	}
+
+	// 解析响应
+	var result struct {
+		Results []struct {
+			Title         string `json:"title"`
+			URL           string `json:"url"`
+			Content       string `json:"content"`
+			PublishedDate string `json:"publishedDate"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("SearxNG响应格式错误: %w", err)
+	}
+
+	results := []SearchResult{}
+	for _, item := range result.Results {
+		if item.URL == "" {
+			continue
+		}
+		results = append(results, SearchResult{
+			Title:       item.Title,
+			URL:         item.URL,
+			Description: item.Content,
+			PublishedAt: parsePublished(item.PublishedDate),
+		})
+	}
+	return results, nil
+}
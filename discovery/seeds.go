@@ -0,0 +1,154 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// IsSeedURL 判断种子是网址还是产品名称
+func IsSeedURL(seed string) bool {
+	seed = strings.TrimSpace(seed)
+	if strings.HasPrefix(seed, "http://") || strings.HasPrefix(seed, "https://") {
+		return true
+	}
+	if strings.ContainsAny(seed, " \t") || !strings.Contains(seed, ".") {
+		return false
+	}
+	// 顶级域名必须是字母，排除 "v1.2" 这类名称
+	host := strings.SplitN(seed, "/", 2)[0]
+	tld := host[strings.LastIndex(host, ".")+1:]
+	if len(tld) < 2 {
+		return false
+	}
+	for _, r := range tld {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// SeedURL 种子网址补全协议
+func SeedURL(seed string) string {
+	seed = strings.TrimSpace(seed)
+	if !strings.HasPrefix(seed, "http://") && !strings.HasPrefix(seed, "https://") {
+		seed = "https://" + seed
+	}
+	return seed
+}
+
+// 网页标题中分隔产品名和宣传语的符号
+var titleSeparators = []string{" | ", " - ", " – ", " — ", "｜", "|", "_", "：", ": ", "—", "-"}
+
+// SeedName 从种子网站推断产品名称：优先 og:site_name，其次标题中与域名最接近的片段，最后用域名主体
+func SeedName(title, siteName, rawURL string) string {
+	if name := strings.TrimSpace(siteName); name != "" {
+		return name
+	}
+
+	domain := SiteDomain(rawURL)
+	label := domain
+	if i := strings.Index(label, "."); i > 0 {
+		label = label[:i]
+	}
+
+	parts := []string{strings.TrimSpace(title)}
+	for _, sep := range titleSeparators {
+		var next []string
+		for _, part := range parts {
+			for _, piece := range strings.Split(part, sep) {
+				if piece = strings.TrimSpace(piece); piece != "" {
+					next = append(next, piece)
+				}
+			}
+		}
+		parts = next
+	}
+
+	best, bestScore := "", 0.5
+	for _, part := range parts {
+		if score := brandSimilarity(compactText(part), label); score > bestScore {
+			best, bestScore = part, score
+		}
+	}
+	if best != "" {
+		return best
+	}
+
+	if label == "" {
+		return ""
+	}
+	return strings.ToUpper(label[:1]) + label[1:]
+}
+
+// GenerateAlternativeQueries 生成查找种子产品替代品的查询
+func (q *QueryGenerator) GenerateAlternativeQueries(seedName, market string) []string {
+	_, templates := q.templates.Resolve(market)
+
+	list := templates.Alternatives
+	if len(list) == 0 {
+		list = []string{"alternatives to {name}", "{name} vs", "{name} competitors"}
+	}
+
+	queries := make([]string, len(list))
+	for i, template := range list {
+		queries[i] = fillTemplate(template, map[string]string{"name": seedName})
+	}
+	return queries
+}
+
+// SearchAlternatives 基于种子产品搜索替代品：每个种子的"X 替代品""X vs"等查询，
+// 加上推断出的品类的竞品发现查询（category为空时只用种子查询）
+func (m *SearchManager) SearchAlternatives(ctx context.Context, seeds []string, category string, plan DepthPlan) ([]SearchResult, error) {
+	var queries []string
+	for _, seed := range seeds {
+		queries = append(queries, m.queryGen.GenerateAlternativeQueries(seed, market(ctx))...)
+	}
+	if category != "" {
+		categoryQueries := m.queryGen.GenerateCompetitorQueries(category, market(ctx))
+		if plan.QueryVariants > 0 && len(categoryQueries) > plan.QueryVariants {
+			categoryQueries = categoryQueries[:plan.QueryVariants]
+		}
+		queries = append(queries, categoryQueries...)
+	}
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("没有可用的种子产品")
+	}
+
+	results, err := m.searchAll(ctx, queries, plan.ResultsPerQuery, plan.Pages)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("未找到任何搜索结果")
+	}
+	return results, nil
+}
+
+// ExcludeCompetitors 从投票结果中去掉种子产品本身（按名称或官网域名主体匹配）
+func ExcludeCompetitors(votes []CompetitorVote, names []string) []CompetitorVote {
+	excluded := make(map[string]bool)
+	for _, name := range names {
+		if key := normalizeCompetitorName(name); key != "" {
+			excluded[key] = true
+		}
+		if IsSeedURL(name) {
+			if u, err := url.Parse(SeedURL(name)); err == nil {
+				label := SiteDomain(u.Hostname())
+				if i := strings.Index(label, "."); i > 0 {
+					excluded[normalizeCompetitorName(label[:i])] = true
+				}
+			}
+		}
+	}
+
+	kept := votes[:0:0]
+	for _, vote := range votes {
+		if !excluded[normalizeCompetitorName(vote.Name)] {
+			kept = append(kept, vote)
+		}
+	}
+	return kept
+}
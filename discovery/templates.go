@@ -0,0 +1,137 @@
+package discovery
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// 内置的查询模板，QUERY_TEMPLATES_PATH 指定的文件格式与之相同
+//
+//go:embed query_templates.json
+var defaultQueryTemplates []byte
+
+// QueryTemplates 按市场区分的查询模板
+type QueryTemplates struct {
+	Default string                      `json:"default"` // 未指定或无法识别市场时使用
+	Markets map[string]*MarketTemplates `json:"markets"`
+}
+
+// MarketTemplates 一个市场的语言、地区和查询模板。
+// 模板中 {topic} 替换为主题，{name} 替换为竞品名称，{domain} 替换为已验证的官网域名
+type MarketTemplates struct {
+	Aliases          []string            `json:"aliases"`           // 市场的其他写法，如 "中国"、"china"
+	Language         string              `json:"language"`          // 语言，如 zh-CN、en-US
+	Country          string              `json:"country"`           // 国家/地区代码，如 cn、us
+	DuckDuckGoRegion string              `json:"duckduckgo_region"` // DuckDuckGo的地区参数，如 cn-zh、us-en
+	Competitor       []string            `json:"competitor"`        // 竞品发现查询
+	Snowball         []string            `json:"snowball"`          // 对已发现竞品的追加查询，{name}为竞品名称
+	Alternatives     []string            `json:"alternatives"`      // 种子产品的替代品查询，{name}为种子产品名称
+	DataSources      map[string][]string `json:"data_sources"`      // 按数据源类型的查询
+	OfficialSite     map[string][]string `json:"official_site"`     // 已知官网时替换对应类型的查询
+}
+
+// LoadQueryTemplates 加载查询模板，path为空时使用内置模板
+func LoadQueryTemplates(path string) (*QueryTemplates, error) {
+	data := defaultQueryTemplates
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取查询模板失败: %w", err)
+		}
+		data = content
+	}
+
+	var templates QueryTemplates
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return nil, fmt.Errorf("解析查询模板失败: %w", err)
+	}
+	if len(templates.Markets) == 0 {
+		return nil, fmt.Errorf("查询模板中没有任何市场")
+	}
+
+	// 市场代码统一为大写
+	markets := make(map[string]*MarketTemplates, len(templates.Markets))
+	for code, market := range templates.Markets {
+		if len(market.Competitor) == 0 || len(market.DataSources) == 0 {
+			return nil, fmt.Errorf("市场 %s 缺少竞品发现或数据源查询模板", code)
+		}
+		markets[strings.ToUpper(code)] = market
+	}
+	templates.Markets = markets
+	templates.Default = strings.ToUpper(templates.Default)
+	if templates.Markets[templates.Default] == nil {
+		return nil, fmt.Errorf("默认市场 %s 不存在", templates.Default)
+	}
+	return &templates, nil
+}
+
+// mustDefaultTemplates 内置模板（内置文件有误时直接panic）
+func mustDefaultTemplates() *QueryTemplates {
+	templates, err := LoadQueryTemplates("")
+	if err != nil {
+		panic(err)
+	}
+	return templates
+}
+
+// Resolve 按市场代码或别名（不区分大小写）查找市场，无法识别时返回默认市场
+func (t *QueryTemplates) Resolve(market string) (string, *MarketTemplates) {
+	key := strings.ToLower(strings.TrimSpace(market))
+	if key != "" {
+		for code, templates := range t.Markets {
+			if strings.ToLower(code) == key {
+				return code, templates
+			}
+			for _, alias := range templates.Aliases {
+				if strings.ToLower(alias) == key {
+					return code, templates
+				}
+			}
+		}
+	}
+	return t.Default, t.Markets[t.Default]
+}
+
+// Locale 搜索使用的市场、语言和地区，各搜索引擎转换为自己的参数
+type Locale struct {
+	Market           string
+	Language         string
+	Country          string
+	DuckDuckGoRegion string
+}
+
+// Locale 市场对应的搜索地区设置
+func (t *QueryTemplates) Locale(market string) Locale {
+	code, templates := t.Resolve(market)
+	return Locale{
+		Market:           code,
+		Language:         templates.Language,
+		Country:          templates.Country,
+		DuckDuckGoRegion: templates.DuckDuckGoRegion,
+	}
+}
+
+type localeKey struct{}
+
+// WithLocale 设置搜索使用的市场和语言
+func WithLocale(ctx context.Context, locale Locale) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// LocaleFromContext 读取搜索使用的市场和语言，未设置时ok为false
+func LocaleFromContext(ctx context.Context) (Locale, bool) {
+	locale, ok := ctx.Value(localeKey{}).(Locale)
+	return locale, ok
+}
+
+// fillTemplate 替换模板中的占位符
+func fillTemplate(template string, values map[string]string) string {
+	for key, value := range values {
+		template = strings.ReplaceAll(template, "{"+key+"}", value)
+	}
+	return template
+}
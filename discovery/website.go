@@ -0,0 +1,266 @@
+package discovery
+
+import (
+	"competitive-analyzer/entity"
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// 候选官网评分低于该值时不再验证
+const minOfficialSiteScore = 0.35
+
+// 不可能是竞品官网的站点（媒体、问答、电商、社交、应用商店等）
+var nonOfficialHosts = []string{
+	"zhihu.com", "xiaohongshu.com", "douban.com", "weibo.com", "weixin.qq.com",
+	"baidu.com", "sohu.com", "163.com", "sina.com.cn", "csdn.net", "36kr.com", "huxiu.com", "jianshu.com",
+	"bilibili.com", "douyin.com", "taobao.com", "tmall.com", "jd.com",
+	"wikipedia.org", "github.com", "medium.com", "reddit.com", "quora.com", "youtube.com",
+	"twitter.com", "x.com", "facebook.com", "linkedin.com", "instagram.com",
+	"g2.com", "capterra.com", "producthunt.com", "trustpilot.com", "crunchbase.com",
+	"apps.apple.com", "play.google.com",
+}
+
+// OfficialSite 竞品官网识别结果
+type OfficialSite struct {
+	URL      string   `json:"url"`      // 官网首页
+	Domain   string   `json:"domain"`   // 用于 site: 查询的域名
+	Score    float64  `json:"score"`    // 候选评分 0-1
+	Verified bool     `json:"verified"` // 抓取首页后确认标题或正文提到了竞品名称
+	Reasons  []string `json:"reasons"`
+}
+
+// officialSiteHit 官网查询的一条搜索结果
+type officialSiteHit struct {
+	engine string
+	result SearchResult
+}
+
+// FindOfficialSites 用所有搜索引擎搜索竞品官网，按评分返回候选官网（未验证）
+func (m *SearchManager) FindOfficialSites(ctx context.Context, competitorName string) ([]OfficialSite, error) {
+	queries := m.queryGen.GenerateDataSourceQueries(competitorName, "", market(ctx))["官网"]
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		hits []officialSiteHit
+	)
+	semaphore := make(chan struct{}, m.maxWorkers)
+
+	// 不同引擎结果的一致性是判断官网的依据之一，所以每个引擎都要搜索
+	for _, engine := range m.engines {
+		for _, query := range queries {
+			wg.Add(1)
+			go func(engine SearchEngine, q string) {
+				defer wg.Done()
+				semaphore <- struct{}{}
+				defer func() { <-semaphore }()
+
+				if ctx.Err() != nil {
+					return
+				}
+				results, err := engine.Search(ctx, q, 10)
+				if err != nil {
+					return
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				for _, result := range results {
+					hits = append(hits, officialSiteHit{engine: engine.Name(), result: result})
+				}
+			}(engine, query)
+		}
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(hits) == 0 {
+		return nil, fmt.Errorf("未找到 %s 的官网", competitorName)
+	}
+	return rankOfficialSites(competitorName, hits), nil
+}
+
+// rankOfficialSites 按域名汇总搜索结果并评分：
+// 品牌名与域名的相似度、链接分类为"官网首页"、多个搜索引擎的一致性、搜索排名
+func rankOfficialSites(competitorName string, hits []officialSiteHit) []OfficialSite {
+	type candidate struct {
+		site     OfficialSite
+		engines  map[string]bool
+		homepage bool
+		rootHost bool
+		position int
+	}
+
+	classifier := &LinkClassifier{}
+	brand := entity.Normalize(competitorName)
+	allEngines := make(map[string]bool)
+	candidates := make(map[string]*candidate)
+	var order []string
+
+	for _, hit := range hits {
+		allEngines[hit.engine] = true
+
+		u, err := url.Parse(hit.result.URL)
+		if err != nil || u.Hostname() == "" || isNonOfficialHost(u.Hostname()) {
+			continue
+		}
+		host := strings.ToLower(u.Hostname())
+		domain := SiteDomain(hit.result.URL)
+
+		c, ok := candidates[domain]
+		if !ok {
+			c = &candidate{
+				site:     OfficialSite{URL: homepageURL(u), Domain: domain},
+				engines:  make(map[string]bool),
+				position: hit.result.Position,
+			}
+			candidates[domain] = c
+			order = append(order, domain)
+		}
+		c.engines[hit.engine] = true
+		if hit.result.Position > 0 && (c.position <= 0 || hit.result.Position < c.position) {
+			c.position = hit.result.Position
+		}
+
+		category := classifier.ClassifyLink(hit.result.URL, hit.result.Title, hit.result.Description)
+		if category.Type == "官网首页" {
+			c.homepage = true
+		}
+		// 优先使用 www 或裸域名作为首页，而不是 docs.xxx.com 之类的子站
+		if !c.rootHost && (host == domain || host == "www."+domain) {
+			c.site.URL = homepageURL(u)
+			c.rootHost = true
+		}
+	}
+
+	sites := make([]OfficialSite, 0, len(candidates))
+	for _, domain := range order {
+		c := candidates[domain]
+		var reasons []string
+
+		similarity := brandSimilarity(brand, domain)
+		if similarity >= 0.8 {
+			reasons = append(reasons, "域名与品牌名一致")
+		}
+
+		score := similarity * 0.5
+		if c.homepage {
+			score += 0.2
+			reasons = append(reasons, "链接分类为官网首页")
+		}
+
+		score += 0.2 * float64(len(c.engines)) / float64(len(allEngines))
+		if len(c.engines) > 1 {
+			reasons = append(reasons, fmt.Sprintf("%d个搜索引擎都返回该域名", len(c.engines)))
+		}
+
+		if c.position > 0 && c.position <= 10 {
+			score += 0.1 * (1 - float64(c.position-1)/10)
+		}
+
+		if score < minOfficialSiteScore {
+			continue
+		}
+		c.site.Score = round2(score)
+		c.site.Reasons = reasons
+		sites = append(sites, c.site)
+	}
+
+	sort.SliceStable(sites, func(i, j int) bool {
+		return sites[i].Score > sites[j].Score
+	})
+	return sites
+}
+
+// VerifyOfficialSite 根据首页的标题和正文确认是否为竞品官网，返回是否通过和原因
+func VerifyOfficialSite(competitorName, title, content string) (bool, string) {
+	brand := entity.Normalize(competitorName)
+	if brand == "" {
+		return false, "竞品名称为空"
+	}
+
+	if strings.Contains(compactText(title), brand) {
+		return true, "首页标题包含品牌名"
+	}
+	if mentions := strings.Count(compactText(content), brand); mentions >= 3 {
+		return true, fmt.Sprintf("首页正文提到品牌名%d次", mentions)
+	}
+	return false, "首页未提到品牌名"
+}
+
+// SiteDomain URL的站点域名（去掉 www. 及其他子域名，保留 com.cn 等二级后缀）
+func SiteDomain(rawURL string) string {
+	host := rawURL
+	if u, err := url.Parse(rawURL); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	labels := strings.Split(host, ".")
+	keep := 2
+	if len(labels) >= 3 && secondLevelSuffix(labels[len(labels)-2]) {
+		keep = 3
+	}
+	if len(labels) <= keep {
+		return host
+	}
+	return strings.Join(labels[len(labels)-keep:], ".")
+}
+
+func secondLevelSuffix(label string) bool {
+	switch label {
+	case "com", "net", "org", "gov", "edu", "co", "ac":
+		return true
+	}
+	return false
+}
+
+func isNonOfficialHost(host string) bool {
+	host = strings.ToLower(host)
+	for _, blocked := range nonOfficialHosts {
+		if host == blocked || strings.HasSuffix(host, "."+blocked) {
+			return true
+		}
+	}
+	return false
+}
+
+func homepageURL(u *url.URL) string {
+	scheme := u.Scheme
+	if scheme == "" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/", scheme, u.Host)
+}
+
+// brandSimilarity 品牌名与域名主体的相似度；域名主体包含品牌名（或反过来）时视为较高相似
+func brandSimilarity(brand, domain string) float64 {
+	label := entity.Normalize(domain)
+	if brand == "" || label == "" {
+		return 0
+	}
+	similarity := entity.Similarity(brand, label)
+	if similarity < 0.8 && len(brand) >= 3 && len(label) >= 3 &&
+		(strings.Contains(label, brand) || strings.Contains(brand, label)) {
+		similarity = 0.8
+	}
+	return similarity
+}
+
+// compactText 小写并去掉空格和标点，便于查找品牌名
+func compactText(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,269 @@
+package entity
+
+import (
+	"competitive-analyzer/alerts"
+	"competitive-analyzer/models"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// MergeResult 合并结果
+type MergeResult struct {
+	Competitor  models.Competitor `json:"competitor"`   // 保留的竞品
+	MergedIDs   []uint            `json:"merged_ids"`   // 被合并（已删除）的竞品
+	DataSources int               `json:"data_sources"` // 转移的数据源（URL重复的数据源合并到已有数据源）
+	ChangeLogs  int64             `json:"change_logs"`
+	Monitors    int               `json:"monitors"`        // 更新了竞品ID的监控任务
+	Reports     int               `json:"reports"`         // 更新了竞品ID的分析报告
+	CrawlItems  int64             `json:"crawl_job_items"` // 竞品名称改为保留竞品的批量爬取URL
+}
+
+// Merge 把sourceIDs对应的竞品合并到targetID：数据源、爬取内容、分析结果、变化日志、告警、别名、
+// 监控任务和分析报告中的竞品ID、批量爬取中的竞品名称都转移到保留的竞品上，然后删除被合并的竞品
+func (r *Resolver) Merge(targetID uint, sourceIDs []uint) (*MergeResult, error) {
+	if len(sourceIDs) == 0 {
+		return nil, errors.New("没有需要合并的竞品")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := &MergeResult{}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var target models.Competitor
+		if err := tx.First(&target, targetID).Error; err != nil {
+			return fmt.Errorf("竞品 #%d 不存在", targetID)
+		}
+
+		merged := make(map[uint]bool)
+		for _, sourceID := range sourceIDs {
+			if sourceID == targetID || merged[sourceID] {
+				continue
+			}
+
+			var source models.Competitor
+			if err := tx.First(&source, sourceID).Error; err != nil {
+				return fmt.Errorf("竞品 #%d 不存在", sourceID)
+			}
+
+			moved, err := mergeDataSources(tx, source.ID, target.ID)
+			if err != nil {
+				return err
+			}
+			result.DataSources += moved
+
+			changeLogs := tx.Model(&models.ChangeLog{}).Where("competitor_id = ?", source.ID).Update("competitor_id", target.ID)
+			if changeLogs.Error != nil {
+				return changeLogs.Error
+			}
+			result.ChangeLogs += changeLogs.RowsAffected
+
+			if err := tx.Model(&models.AlertEvent{}).Where("competitor_id = ?", source.ID).Update("competitor_id", target.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.CompetitorAlias{}).Where("competitor_id = ?", source.ID).Update("competitor_id", target.ID).Error; err != nil {
+				return err
+			}
+			addAlias(tx, target.ID, source.Name, AliasMerge)
+
+			crawlItems := tx.Model(&models.CrawlJobItem{}).Where("competitor = ?", source.Name).Update("competitor", target.Name)
+			if crawlItems.Error != nil {
+				return crawlItems.Error
+			}
+			result.CrawlItems += crawlItems.RowsAffected
+
+			// 保留竞品缺少的信息从被合并的竞品补充
+			if target.Company == "" {
+				target.Company = source.Company
+			}
+			if target.Website == "" {
+				target.Website = source.Website
+			}
+			if target.Category == "" {
+				target.Category = source.Category
+			}
+			if target.DiscoveryTaskID == nil {
+				target.DiscoveryTaskID = source.DiscoveryTaskID
+			}
+			if source.Confidence > target.Confidence {
+				target.Confidence = source.Confidence
+			}
+
+			if err := tx.Delete(&models.Competitor{}, source.ID).Error; err != nil {
+				return err
+			}
+			merged[source.ID] = true
+			result.MergedIDs = append(result.MergedIDs, source.ID)
+		}
+
+		if len(merged) == 0 {
+			return errors.New("没有需要合并的竞品")
+		}
+
+		addAlias(tx, target.ID, target.Name, AliasName)
+		if err := tx.Save(&target).Error; err != nil {
+			return err
+		}
+
+		monitors, err := remapMonitors(tx, merged, target.ID)
+		if err != nil {
+			return err
+		}
+		result.Monitors = monitors
+
+		reports, err := remapReports(tx, merged, target.ID)
+		if err != nil {
+			return err
+		}
+		result.Reports = reports
+
+		return tx.Preload("Aliases").First(&result.Competitor, target.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// mergeDataSources 转移数据源；保留的竞品已有相同URL的数据源时，把爬取内容和变化日志并入已有数据源
+func mergeDataSources(tx *gorm.DB, sourceID, targetID uint) (int, error) {
+	var dataSources []models.DataSource
+	if err := tx.Where("competitor_id = ?", sourceID).Find(&dataSources).Error; err != nil {
+		return 0, err
+	}
+
+	for _, ds := range dataSources {
+		var existing models.DataSource
+		err := tx.Where("competitor_id = ? AND url = ?", targetID, ds.URL).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := tx.Model(&ds).Update("competitor_id", targetID).Error; err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if err := tx.Model(&models.RawContent{}).Where("source_id = ?", ds.ID).Update("source_id", existing.ID).Error; err != nil {
+			return 0, err
+		}
+		if err := tx.Model(&models.ChangeLog{}).Where("source_id = ?", ds.ID).Update("source_id", existing.ID).Error; err != nil {
+			return 0, err
+		}
+		if ds.LastCrawlTime != nil && (existing.LastCrawlTime == nil || ds.LastCrawlTime.After(*existing.LastCrawlTime)) {
+			tx.Model(&existing).Update("last_crawl_time", ds.LastCrawlTime)
+		}
+		if err := tx.Delete(&ds).Error; err != nil {
+			return 0, err
+		}
+	}
+	return len(dataSources), nil
+}
+
+// remapMonitors 把监控任务和告警规则中被合并的竞品ID替换为保留的竞品ID
+func remapMonitors(tx *gorm.DB, merged map[uint]bool, targetID uint) (int, error) {
+	var tasks []models.MonitorTask
+	if err := tx.Find(&tasks).Error; err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, task := range tasks {
+		raw, _ := task.CompetitorIDs["ids"].([]interface{})
+		ids, changed := remapIDs(toIDs(raw), merged, targetID)
+
+		rules, err := alerts.ParseRules(task.AlertRules)
+		if err != nil {
+			return 0, err
+		}
+		rulesChanged := false
+		for i := range rules {
+			var ruleChanged bool
+			rules[i].CompetitorIDs, ruleChanged = remapIDs(rules[i].CompetitorIDs, merged, targetID)
+			rulesChanged = rulesChanged || ruleChanged
+		}
+
+		if !changed && !rulesChanged {
+			continue
+		}
+
+		updates := map[string]interface{}{}
+		if changed {
+			list := make([]interface{}, len(ids))
+			for i, id := range ids {
+				list[i] = id
+			}
+			updates["competitor_ids"] = models.JSONB{"ids": list}
+		}
+		if rulesChanged {
+			updates["alert_rules"] = alerts.EncodeRules(rules)
+		}
+		if err := tx.Model(&task).Updates(updates).Error; err != nil {
+			return 0, err
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// remapReports 把分析报告中被合并的竞品ID替换为保留的竞品ID
+func remapReports(tx *gorm.DB, merged map[uint]bool, targetID uint) (int, error) {
+	var reports []models.AnalysisReport
+	if err := tx.Select("id", "competitors").Find(&reports).Error; err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, report := range reports {
+		raw, _ := report.Competitors["ids"].([]interface{})
+		ids, changed := remapIDs(toIDs(raw), merged, targetID)
+		if !changed {
+			continue
+		}
+		list := make([]interface{}, len(ids))
+		for i, id := range ids {
+			list[i] = id
+		}
+		if err := tx.Model(&report).Update("competitors", models.JSONB{"ids": list}).Error; err != nil {
+			return 0, err
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// remapIDs 替换并去重竞品ID
+func remapIDs(ids []uint, merged map[uint]bool, targetID uint) ([]uint, bool) {
+	changed := false
+	seen := make(map[uint]bool)
+	result := make([]uint, 0, len(ids))
+
+	for _, id := range ids {
+		if merged[id] {
+			id, changed = targetID, true
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	return result, changed
+}
+
+// toIDs 解析JSONB中的ID列表（JSON数字解析为float64）
+func toIDs(raw []interface{}) []uint {
+	ids := make([]uint, 0, len(raw))
+	for _, v := range raw {
+		switch id := v.(type) {
+		case float64:
+			ids = append(ids, uint(id))
+		case uint:
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,184 @@
+package entity
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// 名称末尾的修饰词（中文直接去掉，英文需要以空格等分隔）
+var cjkSuffixes = []string{
+	"中文官网", "官方网站", "官网首页", "官网", "官方", "中文版", "网页版", "客户端", "下载",
+	"有限公司", "公司", "软件", "平台", "app",
+}
+
+var latinSuffixes = []string{
+	"official website", "official site", "website", "homepage",
+	"app", "ai", "inc", "ltd", "llc", "corp", "co",
+}
+
+// 常见的二级域名后缀，如 example.com.cn
+var secondLevelSuffixes = map[string]bool{
+	"com": true, "net": true, "org": true, "gov": true, "edu": true, "co": true, "ac": true,
+}
+
+// Normalize 归一化竞品名称，用于判断是否为同一竞品：
+// 域名取主体部分（notion.so → notion），去掉"官网""App""AI"等修饰词，忽略大小写、空格和标点
+func Normalize(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if domain := domainName(name); domain != "" {
+		name = domain
+	}
+	name = stripSuffixes(name)
+
+	var b strings.Builder
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// IsDomain 名称是否为URL或域名
+func IsDomain(name string) bool {
+	return domainName(strings.ToLower(strings.TrimSpace(name))) != ""
+}
+
+// domainName 名称是URL或域名时返回域名主体，否则返回空
+func domainName(name string) string {
+	host := name
+	if strings.Contains(name, "://") {
+		u, err := url.Parse(name)
+		if err != nil {
+			return ""
+		}
+		host = u.Hostname()
+	} else if i := strings.IndexAny(name, "/?#"); i >= 0 {
+		host = name[:i]
+	}
+
+	if strings.ContainsAny(host, " \t") || !strings.Contains(host, ".") {
+		return ""
+	}
+
+	labels := strings.Split(strings.TrimSuffix(host, "."), ".")
+	for _, label := range labels {
+		if label == "" {
+			return ""
+		}
+		for _, r := range label {
+			if !(r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+				return ""
+			}
+		}
+	}
+
+	// 去掉顶级域名和常见的二级域名后缀，取剩余的最后一段
+	labels = labels[:len(labels)-1]
+	if len(labels) > 1 && secondLevelSuffixes[labels[len(labels)-1]] {
+		labels = labels[:len(labels)-1]
+	}
+	if len(labels) == 0 {
+		return ""
+	}
+	return labels[len(labels)-1]
+}
+
+// stripSuffixes 反复去掉名称末尾的修饰词，不会把名称去成空串
+func stripSuffixes(name string) string {
+	for {
+		trimmed := strings.TrimRightFunc(name, func(r rune) bool {
+			return unicode.IsSpace(r) || unicode.IsPunct(r)
+		})
+		stripped := trimmed
+
+		for _, suffix := range cjkSuffixes {
+			if strings.HasSuffix(trimmed, suffix) && len(trimmed) > len(suffix) {
+				prefix := trimmed[:len(trimmed)-len(suffix)]
+				// app 等英文修饰词紧跟在英文名后面时不是修饰词（如 whatsapp）
+				if isASCIIWord(suffix) && endsWithLatin(prefix) {
+					continue
+				}
+				stripped = prefix
+				break
+			}
+		}
+		if stripped == trimmed {
+			for _, suffix := range latinSuffixes {
+				if strings.HasSuffix(trimmed, suffix) && len(trimmed) > len(suffix) {
+					prefix := trimmed[:len(trimmed)-len(suffix)]
+					if last := lastRune(prefix); unicode.IsSpace(last) || last == '-' || last == '_' {
+						stripped = prefix
+						break
+					}
+				}
+			}
+		}
+
+		stripped = strings.TrimRightFunc(stripped, func(r rune) bool {
+			return unicode.IsSpace(r) || unicode.IsPunct(r)
+		})
+		if stripped == trimmed || stripped == "" {
+			return trimmed
+		}
+		name = stripped
+	}
+}
+
+func isASCIIWord(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+func endsWithLatin(s string) bool {
+	r := lastRune(s)
+	return r <= unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+func lastRune(s string) rune {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return 0
+	}
+	return runes[len(runes)-1]
+}
+
+// Similarity 两个归一化名称的相似度（0-1），基于编辑距离
+func Similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+
+	maxLen := len(ra)
+	if len(rb) > maxLen {
+		maxLen = len(rb)
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(maxLen)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
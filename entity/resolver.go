@@ -0,0 +1,265 @@
+package entity
+
+import (
+	"competitive-analyzer/models"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 疑似重复的相似度阈值：相似的名称只列入疑似重复供人工合并，不自动合并（如 Notion 和 Motion 是不同产品）；
+// 归一化后过短的名称不做相似度比较
+const (
+	FuzzyThreshold = 0.85
+	minFuzzyLength = 5
+)
+
+// 别名来源
+const (
+	AliasName   = "name"   // 解析时出现的名称
+	AliasFuzzy  = "fuzzy"  // 模糊匹配到已有竞品的名称（旧版本自动模糊合并时记录）
+	AliasManual = "manual" // 手动添加
+	AliasMerge  = "merge"  // 合并竞品时保留的原名称
+)
+
+// Resolver 竞品实体解析：把同一竞品的不同写法解析到同一条竞品记录
+type Resolver struct {
+	db *gorm.DB
+	mu sync.Mutex // 避免并发解析同一名称时重复创建竞品
+}
+
+// NewResolver 创建实体解析器
+func NewResolver(db *gorm.DB) *Resolver {
+	return &Resolver{db: db}
+}
+
+// Resolve 查找名称对应的竞品，没有匹配时创建新竞品；created表示是否新建
+func (r *Resolver) Resolve(name string) (competitor *models.Competitor, created bool, err error) {
+	name = strings.TrimSpace(name)
+	key := Normalize(name)
+	if key == "" {
+		return nil, false, errors.New("竞品名称为空")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	competitor, source, err := r.match(key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if competitor == nil {
+		competitor = &models.Competitor{Name: name, Status: "active"}
+		if err := r.db.Create(competitor).Error; err != nil {
+			return nil, false, fmt.Errorf("创建竞品失败: %w", err)
+		}
+		created, source = true, AliasName
+	} else if IsDomain(competitor.Name) && !IsDomain(name) {
+		// 先出现的是域名时，用产品名称作为竞品名称
+		competitor.Name = name
+		r.db.Model(competitor).Update("name", name)
+	}
+
+	addAlias(r.db, competitor.ID, name, source)
+	return competitor, created, nil
+}
+
+// Find 查找名称对应的竞品，不创建；没有匹配时返回nil
+func (r *Resolver) Find(name string) (*models.Competitor, error) {
+	key := Normalize(name)
+	if key == "" {
+		return nil, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	competitor, _, err := r.match(key)
+	return competitor, err
+}
+
+// AddAlias 为竞品手动添加别名，别名已属于其他竞品时返回错误
+func (r *Resolver) AddAlias(competitorID uint, alias string) (*models.CompetitorAlias, error) {
+	key := Normalize(alias)
+	if key == "" {
+		return nil, errors.New("别名为空")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var existing models.CompetitorAlias
+	if err := r.db.Where("normalized = ?", key).First(&existing).Error; err == nil {
+		if existing.CompetitorID != competitorID {
+			return nil, fmt.Errorf("别名 %s 已属于竞品 #%d，请使用合并", alias, existing.CompetitorID)
+		}
+		return &existing, nil
+	}
+
+	record := &models.CompetitorAlias{CompetitorID: competitorID, Alias: strings.TrimSpace(alias), Normalized: key, Source: AliasManual}
+	if err := r.db.Create(record).Error; err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// match 按 别名精确匹配 → 竞品名称精确匹配 的顺序查找竞品；只是相似的名称不匹配，见Duplicates
+func (r *Resolver) match(key string) (*models.Competitor, string, error) {
+	var alias models.CompetitorAlias
+	err := r.db.Where("normalized = ?", key).First(&alias).Error
+	if err == nil {
+		var competitor models.Competitor
+		if err := r.db.First(&competitor, alias.CompetitorID).Error; err == nil {
+			return &competitor, AliasName, nil
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", err
+	}
+
+	candidates, err := r.candidates()
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, c := range candidates {
+		if c.key == key {
+			return r.load(c.competitorID, AliasName)
+		}
+	}
+	return nil, "", nil
+}
+
+func (r *Resolver) load(id uint, source string) (*models.Competitor, string, error) {
+	var competitor models.Competitor
+	if err := r.db.First(&competitor, id).Error; err != nil {
+		return nil, "", err
+	}
+	return &competitor, source, nil
+}
+
+// candidate 用于匹配的竞品名称或别名
+type candidate struct {
+	competitorID uint
+	name         string
+	key          string
+}
+
+// candidates 所有竞品的名称和别名
+func (r *Resolver) candidates() ([]candidate, error) {
+	var competitors []models.Competitor
+	if err := r.db.Select("id", "name").Order("id").Find(&competitors).Error; err != nil {
+		return nil, err
+	}
+	var aliases []models.CompetitorAlias
+	if err := r.db.Order("id").Find(&aliases).Error; err != nil {
+		return nil, err
+	}
+
+	list := make([]candidate, 0, len(competitors)+len(aliases))
+	for _, c := range competitors {
+		list = append(list, candidate{competitorID: c.ID, name: c.Name, key: Normalize(c.Name)})
+	}
+	for _, a := range aliases {
+		list = append(list, candidate{competitorID: a.CompetitorID, name: a.Alias, key: a.Normalized})
+	}
+	return list, nil
+}
+
+// Duplicate 疑似重复的两个竞品
+type Duplicate struct {
+	CompetitorID  uint    `json:"competitor_id"`
+	Name          string  `json:"name"`
+	DuplicateID   uint    `json:"duplicate_id"`
+	DuplicateName string  `json:"duplicate_name"`
+	MatchedName   string  `json:"matched_name"` // 匹配到的名称或别名
+	Similarity    float64 `json:"similarity"`
+}
+
+// Duplicates 找出名称或别名相同/相似的竞品，供人工确认后合并
+func (r *Resolver) Duplicates() ([]Duplicate, error) {
+	candidates, err := r.candidates()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[uint]string)
+	for _, c := range candidates {
+		if _, ok := names[c.competitorID]; !ok {
+			names[c.competitorID] = c.name // 竞品名称排在别名前面
+		}
+	}
+
+	type pair struct{ a, b uint }
+	found := make(map[pair]*Duplicate)
+
+	for i := range candidates {
+		for j := i + 1; j < len(candidates); j++ {
+			a, b := candidates[i], candidates[j]
+			if a.competitorID == b.competitorID || a.key == "" || b.key == "" {
+				continue
+			}
+
+			score := 1.0
+			if a.key != b.key {
+				if len([]rune(a.key)) < minFuzzyLength || len([]rune(b.key)) < minFuzzyLength {
+					continue
+				}
+				score = Similarity(a.key, b.key)
+				if score < FuzzyThreshold {
+					continue
+				}
+			}
+
+			if a.competitorID > b.competitorID {
+				a, b = b, a
+			}
+			p := pair{a.competitorID, b.competitorID}
+			if d, ok := found[p]; ok && d.Similarity >= score {
+				continue
+			}
+			found[p] = &Duplicate{
+				CompetitorID:  a.competitorID,
+				Name:          names[a.competitorID],
+				DuplicateID:   b.competitorID,
+				DuplicateName: names[b.competitorID],
+				MatchedName:   b.name,
+				Similarity:    float64(int(score*100+0.5)) / 100,
+			}
+		}
+	}
+
+	duplicates := make([]Duplicate, 0, len(found))
+	for _, d := range found {
+		duplicates = append(duplicates, *d)
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		if duplicates[i].Similarity != duplicates[j].Similarity {
+			return duplicates[i].Similarity > duplicates[j].Similarity
+		}
+		if duplicates[i].CompetitorID != duplicates[j].CompetitorID {
+			return duplicates[i].CompetitorID < duplicates[j].CompetitorID
+		}
+		return duplicates[i].DuplicateID < duplicates[j].DuplicateID
+	})
+	return duplicates, nil
+}
+
+// addAlias 记录别名，归一化名称已存在时忽略
+func addAlias(db *gorm.DB, competitorID uint, name, source string) {
+	key := Normalize(name)
+	if key == "" {
+		return
+	}
+	db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.CompetitorAlias{
+		CompetitorID: competitorID,
+		Alias:        strings.TrimSpace(name),
+		Normalized:   key,
+		Source:       source,
+	})
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"competitive-analyzer/alerts"
+	"competitive-analyzer/database"
+	"competitive-analyzer/models"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// monitorRules 监控任务及其告警规则
+type monitorRules struct {
+	task          models.MonitorTask
+	competitorIDs map[uint]bool
+	rules         []alerts.Rule
+}
+
+// loadMonitorRules 读取所有有效监控任务的告警规则
+func loadMonitorRules() []monitorRules {
+	var tasks []models.MonitorTask
+	database.DB.Where("status = ?", "active").Find(&tasks)
+
+	var result []monitorRules
+	for _, task := range tasks {
+		rules, err := alerts.ParseRules(task.AlertRules)
+		if err != nil {
+			log.Printf("[告警] 监控任务 #%d 的告警规则无效: %v", task.ID, err)
+			continue
+		}
+		if len(rules) == 0 {
+			continue
+		}
+
+		ids := make(map[uint]bool)
+		for _, id := range monitorCompetitorIDs(&task) {
+			ids[id] = true
+		}
+		result = append(result, monitorRules{task: task, competitorIDs: ids, rules: rules})
+	}
+	return result
+}
+
+// evaluateAlerts 按监控任务的告警规则评估新的变化，记录触发的告警
+func evaluateAlerts(changeLogs ...*models.ChangeLog) []models.AlertEvent {
+	if len(changeLogs) == 0 {
+		return nil
+	}
+
+	db := database.DB
+	monitors := loadMonitorRules()
+	competitorNames := make(map[uint]string)
+
+	var events []models.AlertEvent
+	for _, changeLog := range changeLogs {
+		name, ok := competitorNames[changeLog.CompetitorID]
+		if !ok {
+			var competitor models.Competitor
+			db.Select("name").First(&competitor, changeLog.CompetitorID)
+			name = competitor.Name
+			competitorNames[changeLog.CompetitorID] = name
+		}
+
+		change := &alerts.Change{
+			Log:            changeLog,
+			CompetitorName: name,
+			Text:           changeText(changeLog),
+		}
+
+		for _, m := range monitors {
+			if !m.competitorIDs[changeLog.CompetitorID] {
+				continue
+			}
+			for _, match := range alerts.Evaluate(m.rules, change) {
+				event := models.AlertEvent{
+					MonitorTaskID: m.task.ID,
+					ChangeLogID:   changeLog.ID,
+					CompetitorID:  changeLog.CompetitorID,
+					RuleID:        match.Rule.ID,
+					RuleName:      match.Rule.Name,
+					RuleType:      match.Rule.Type,
+					ImpactLevel:   changeLog.ImpactLevel,
+					Message:       match.Message,
+				}
+				if err := db.Create(&event).Error; err != nil {
+					log.Printf("[告警] 保存告警失败: %v", err)
+					continue
+				}
+				events = append(events, event)
+				log.Printf("[告警] 监控任务 #%d 规则 %s 触发: %s", m.task.ID, match.Rule.ID, match.Message)
+			}
+		}
+
+		// notified 在通知发送成功后由 runNotifyJob 设置
+		changeLog.Evaluated = true
+		db.Model(changeLog).Update("evaluated", true)
+	}
+
+	notifyAlerts(events)
+	return events
+}
+
+// changeText 变化中新增的内容，用于关键词规则匹配
+func changeText(changeLog *models.ChangeLog) string {
+	parts := []string{changeLog.FieldName, changeLog.NewValue}
+
+	// 内容变化的完整新增行在差异报告中
+	if changeLog.DiffPath != "" {
+		if data, err := os.ReadFile(changeLog.DiffPath); err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				if strings.HasPrefix(line, "+ ") {
+					parts = append(parts, line[2:])
+				}
+			}
+		}
+	}
+
+	return strings.Join(parts, "\n")
+}
+
+// ListAlerts 获取告警记录
+func ListAlerts(c *gin.Context) {
+	db := database.DB
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	query := db.Model(&models.AlertEvent{})
+	if monitorID := c.Query("monitor_id"); monitorID != "" {
+		query = query.Where("monitor_task_id = ?", monitorID)
+	}
+	if competitorID := c.Query("competitor_id"); competitorID != "" {
+		query = query.Where("competitor_id = ?", competitorID)
+	}
+	if ruleType := c.Query("rule_type"); ruleType != "" {
+		query = query.Where("rule_type = ?", ruleType)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var events []models.AlertEvent
+	query.Preload("ChangeLog").
+		Order("id DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&events)
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+		"alerts":    events,
+	})
+}
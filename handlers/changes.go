@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"competitive-analyzer/ai"
+	"competitive-analyzer/changes"
+	"competitive-analyzer/crawler"
+	"competitive-analyzer/database"
+	"competitive-analyzer/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 变化日志中保存的新旧内容摘要长度
+const changeExcerptRunes = 500
+
+// contentSnapshot 数据源上一次爬取的内容
+type contentSnapshot struct {
+	RawContent models.RawContent
+	Body       string
+}
+
+// loadPreviousSnapshot 读取数据源上一次爬取的内容，首次爬取时返回nil
+// 同一天重复爬取会覆盖同一个文件，必须在保存新内容之前读取
+func loadPreviousSnapshot(competitorID uint, url string) *contentSnapshot {
+	var previous models.RawContent
+	err := database.DB.
+		Joins("JOIN data_sources ON data_sources.id = raw_contents.source_id").
+		Where("data_sources.competitor_id = ? AND data_sources.url = ?", competitorID, url).
+		Order("raw_contents.id DESC").
+		First(&previous).Error
+	if err != nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(previous.ContentPath)
+	if err != nil {
+		log.Printf("读取上次爬取内容失败 %s: %v", previous.ContentPath, err)
+		return nil
+	}
+
+	return &contentSnapshot{
+		RawContent: previous,
+		Body:       changes.StripFrontMatter(string(data)),
+	}
+}
+
+// detectContentChange 比较新旧内容，有变化时保存差异报告并写入变化日志
+func detectContentChange(dataSource *models.DataSource, previous *contentSnapshot, rawContent *models.RawContent, saveResult *crawler.SaveResult) *models.ChangeLog {
+	if previous == nil || previous.RawContent.ContentHash == rawContent.ContentHash {
+		return nil
+	}
+
+	data, err := os.ReadFile(saveResult.ContentPath)
+	if err != nil {
+		log.Printf("读取爬取内容失败 %s: %v", saveResult.ContentPath, err)
+		return nil
+	}
+
+	diff := changes.DiffMarkdown(previous.Body, changes.StripFrontMatter(string(data)))
+	if diff.Empty() {
+		return nil // 只有空行等格式差异
+	}
+
+	diffPath := filepath.Join(filepath.Dir(saveResult.ContentPath), fmt.Sprintf("changes_%d.md", rawContent.ID))
+	if err := os.WriteFile(diffPath, []byte(diff.Render(saveResult.Title)), 0644); err != nil {
+		log.Printf("保存差异报告失败 %s: %v", diffPath, err)
+		diffPath = ""
+	}
+
+	oldExcerpt, newExcerpt := diff.Excerpts(changeExcerptRunes)
+	changeLog := &models.ChangeLog{
+		CompetitorID: dataSource.CompetitorID,
+		SourceID:     &dataSource.ID,
+		RawContentID: &rawContent.ID,
+		ChangeType:   "content_change",
+		FieldName:    strings.Join(diff.Headings(), ", "),
+		OldValue:     oldExcerpt,
+		NewValue:     newExcerpt,
+		ImpactLevel:  diff.ImpactLevel(),
+		DiffPath:     diffPath,
+		DetectedAt:   time.Now(),
+	}
+	if err := database.DB.Create(changeLog).Error; err != nil {
+		log.Printf("保存变化日志失败: %v", err)
+		return nil
+	}
+
+	log.Printf("检测到内容变化 %s: +%d/-%d 行，影响等级 %s", dataSource.URL, diff.Added, diff.Removed, changeLog.ImpactLevel)
+	evaluateAlerts(changeLog)
+	return changeLog
+}
+
+// loadPreviousProductInfo 读取竞品最近一次分析提取的产品信息
+func loadPreviousProductInfo(competitorID uint) *ai.ProductInfo {
+	var previous models.ParsedData
+	err := database.DB.
+		Joins("JOIN raw_contents ON raw_contents.id = parsed_data.raw_content_id").
+		Joins("JOIN data_sources ON data_sources.id = raw_contents.source_id").
+		Where("data_sources.competitor_id = ? AND parsed_data.data_type = ?", competitorID, "product_info").
+		Order("parsed_data.id DESC").
+		First(&previous).Error
+	if err != nil {
+		return nil
+	}
+
+	productInfoStr, ok := previous.ExtractedData["product_info"].(string)
+	if !ok {
+		return nil
+	}
+	var productInfo ai.ProductInfo
+	if err := json.Unmarshal([]byte(productInfoStr), &productInfo); err != nil {
+		return nil
+	}
+	return &productInfo
+}
+
+// storeProductAnalysis 保存分析结果，并与上一次分析比较，把价格、套餐、功能的变化写入变化日志
+func storeProductAnalysis(competitorID, rawContentID uint, productInfo *ai.ProductInfo, swotAnalysis *ai.SWOTAnalysis) (*models.ParsedData, error) {
+	db := database.DB
+
+	previous := loadPreviousProductInfo(competitorID)
+
+	productInfoJSON, _ := json.Marshal(productInfo)
+	swotJSON, _ := json.Marshal(swotAnalysis)
+
+	parsedData := &models.ParsedData{
+		RawContentID: rawContentID,
+		DataType:     "product_info",
+		ExtractedData: models.JSONB{
+			"product_info":  string(productInfoJSON),
+			"swot_analysis": string(swotJSON),
+		},
+		Confidence: 0.8,
+		ParsedAt:   time.Now(),
+	}
+	if err := db.Create(parsedData).Error; err != nil {
+		return nil, fmt.Errorf("保存分析结果失败: %w", err)
+	}
+
+	fieldChanges := changes.DiffProductInfo(previous, productInfo)
+	changeLogs := make([]*models.ChangeLog, 0, len(fieldChanges))
+	now := time.Now()
+	for _, fc := range fieldChanges {
+		changeLog := &models.ChangeLog{
+			CompetitorID:  competitorID,
+			ParsedDataID:  &parsedData.ID,
+			ChangeType:    fc.ChangeType,
+			FieldName:     fc.FieldName,
+			OldValue:      fc.OldValue,
+			NewValue:      fc.NewValue,
+			ImpactLevel:   fc.ImpactLevel,
+			ChangePercent: fc.Percent,
+			DetectedAt:    now,
+		}
+		if err := db.Create(changeLog).Error; err != nil {
+			log.Printf("保存变化日志失败: %v", err)
+			continue
+		}
+		changeLogs = append(changeLogs, changeLog)
+	}
+	if len(changeLogs) > 0 {
+		log.Printf("竞品 #%d 产品信息有 %d 处变化", competitorID, len(changeLogs))
+		evaluateAlerts(changeLogs...)
+	}
+
+	return parsedData, nil
+}
+
+// GetCompetitorChanges 获取竞品的变化日志
+func GetCompetitorChanges(c *gin.Context) {
+	db := database.DB
+
+	competitorID := c.Param("id")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	var competitor models.Competitor
+	if err := db.First(&competitor, competitorID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "竞品不存在"})
+		return
+	}
+
+	query := db.Model(&models.ChangeLog{}).Where("competitor_id = ?", competitor.ID)
+	if changeType := c.Query("change_type"); changeType != "" {
+		query = query.Where("change_type = ?", changeType)
+	}
+	if impact := c.Query("impact_level"); impact != "" {
+		query = query.Where("impact_level = ?", impact)
+	}
+	if sourceID := c.Query("source_id"); sourceID != "" {
+		query = query.Where("source_id = ?", sourceID)
+	}
+	if since := c.Query("since"); since != "" {
+		if t, err := time.Parse("2006-01-02", since); err == nil {
+			query = query.Where("detected_at >= ?", t)
+		}
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var changeLogs []models.ChangeLog
+	query.Order("detected_at DESC, id DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&changeLogs)
+
+	// 可选附带差异报告内容
+	items := make([]gin.H, 0, len(changeLogs))
+	includeDiff := c.Query("include_diff") == "true"
+	for _, changeLog := range changeLogs {
+		item := gin.H{"change": changeLog}
+		if includeDiff && changeLog.DiffPath != "" {
+			if data, err := os.ReadFile(changeLog.DiffPath); err == nil {
+				item["diff"] = string(data)
+			}
+		}
+		items = append(items, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"competitor": competitor.Name,
+		"total":      total,
+		"page":       page,
+		"page_size":  pageSize,
+		"changes":    items,
+	})
+}
@@ -0,0 +1,397 @@
+package handlers
+
+import (
+	"competitive-analyzer/config"
+	"competitive-analyzer/crawler"
+	"competitive-analyzer/database"
+	"competitive-analyzer/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 批量爬取的最大并发数
+const maxCrawlConcurrency = 10
+
+var (
+	hostSchedulerOnce sync.Once
+	sharedScheduler   *crawler.HostScheduler
+)
+
+// hostScheduler 按主机限速和检查robots.txt的调度器（所有爬虫共享，同一主机的请求统一排队）
+func hostScheduler() *crawler.HostScheduler {
+	hostSchedulerOnce.Do(func() {
+		cfg := config.AppConfig
+		sharedScheduler = crawler.NewHostScheduler(cfg.CrawlHostRPS, cfg.CrawlRespectRobots)
+	})
+	return sharedScheduler
+}
+
+// defaultCrawlConcurrency 未指定并发数时使用的配置值
+func defaultCrawlConcurrency() int {
+	concurrent := config.AppConfig.CrawlConcurrency
+	if concurrent <= 0 {
+		return 1
+	}
+	return min(concurrent, maxCrawlConcurrency)
+}
+
+// createCrawlJob 创建批量爬取任务及其URL明细
+func createCrawlJob(urls []URLItem, concurrent int, taskID *uint) (*models.CrawlJob, error) {
+	crawlJob := &models.CrawlJob{
+		Status:     "pending",
+		Concurrent: concurrent,
+		TaskID:     taskID,
+		TotalItems: len(urls),
+	}
+	for _, item := range urls {
+		crawlJob.Items = append(crawlJob.Items, models.CrawlJobItem{
+			URL:        item.URL,
+			Competitor: item.Competitor,
+			SourceType: item.SourceType,
+			Status:     "pending",
+		})
+	}
+
+	if err := database.DB.Create(crawlJob).Error; err != nil {
+		return nil, fmt.Errorf("创建爬取任务失败: %w", err)
+	}
+	return crawlJob, nil
+}
+
+// executeBatchCrawl 执行批量爬取，只处理尚未成功的URL，ctx取消后不再开始新的URL
+func (h *CrawlHandler) executeBatchCrawl(ctx context.Context, crawlJobID uint) error {
+	db := database.DB
+
+	var crawlJob models.CrawlJob
+	if err := db.First(&crawlJob, crawlJobID).Error; err != nil {
+		return fmt.Errorf("爬取任务不存在: %w", err)
+	}
+
+	var items []models.CrawlJobItem
+	db.Where("crawl_job_id = ? AND status <> ?", crawlJobID, "succeeded").Order("id").Find(&items)
+	// 历史质量高的域名先爬，再按主机交错排列，让并发的爬取分散到不同主机
+	sortByDomainQuality(items)
+	items = interleaveByHost(items)
+
+	crawlJob.Status = "running"
+	db.Model(&crawlJob).Update("status", crawlJob.Status)
+
+	concurrent := crawlJob.Concurrent
+	if concurrent <= 0 {
+		concurrent = 1
+	}
+
+	// 使用信号量控制并发
+	sem := make(chan struct{}, concurrent)
+	var wg sync.WaitGroup
+
+	for i := range items {
+		wg.Add(1)
+		go func(item *models.CrawlJobItem) {
+			defer wg.Done()
+			sem <- struct{}{}        // 获取信号量
+			defer func() { <-sem }() // 释放信号量
+
+			// 同一主机的请求间隔由主机调度器控制
+			if ctx.Err() != nil {
+				log.Printf("爬取已取消 %s", item.URL)
+				return
+			}
+
+			h.crawlItem(ctx, item)
+		}(&items[i])
+	}
+
+	wg.Wait()
+
+	h.finishCrawlJob(ctx, &crawlJob)
+	log.Printf("批量爬取任务完成 #%d: 成功 %d，失败 %d", crawlJob.ID, crawlJob.SucceededItems, crawlJob.FailedItems)
+
+	return ctx.Err()
+}
+
+// interleaveByHost 按主机轮流排列爬取项（各主机内保持原顺序），避免并发的爬取集中在同一主机上排队
+func interleaveByHost(items []models.CrawlJobItem) []models.CrawlJobItem {
+	var hosts []string
+	queues := make(map[string][]models.CrawlJobItem)
+	for _, item := range items {
+		host := item.URL
+		if parsed, err := url.Parse(item.URL); err == nil && parsed.Host != "" {
+			host = strings.ToLower(parsed.Host)
+		}
+		if _, ok := queues[host]; !ok {
+			hosts = append(hosts, host)
+		}
+		queues[host] = append(queues[host], item)
+	}
+
+	interleaved := make([]models.CrawlJobItem, 0, len(items))
+	for len(interleaved) < len(items) {
+		for _, host := range hosts {
+			if queue := queues[host]; len(queue) > 0 {
+				interleaved = append(interleaved, queue[0])
+				queues[host] = queue[1:]
+			}
+		}
+	}
+	return interleaved
+}
+
+// crawlItem 爬取单个URL并记录结果
+func (h *CrawlHandler) crawlItem(ctx context.Context, item *models.CrawlJobItem) {
+	db := database.DB
+
+	startedAt := time.Now()
+	item.Status = "running"
+	item.StartedAt = &startedAt
+	item.Error = ""
+	item.ErrorKind = ""
+	db.Save(item)
+
+	// 爬取（带重试）
+	var result *crawler.CrawlResult
+	var err error
+
+	// 最多尝试3次，只有限流、超时、网络和服务器错误这类临时失败才重试，按指数退避（或Retry-After）等待
+	for retry := 0; retry < 3; retry++ {
+		item.Attempts++
+		result, err = h.crawler.Crawl(ctx, item.URL)
+		if err == nil || ctx.Err() != nil || !crawler.IsTransient(err) {
+			break
+		}
+
+		if retry < 2 {
+			waitTime := crawler.RetryDelay(err, retry)
+			log.Printf("爬取失败 %s (重试 %d/2): %v，等待%v后重试...", item.URL, retry+1, err, waitTime)
+			if sleepContext(ctx, waitTime) != nil {
+				break
+			}
+		}
+	}
+
+	crawlErr := err
+	if err == nil {
+		var rawContent *models.RawContent
+		rawContent, _, err = h.storeCrawlResult(ctx, URLItem{
+			URL:        item.URL,
+			Competitor: item.Competitor,
+			SourceType: item.SourceType,
+		}, result)
+		if err == nil {
+			item.RawContentID = &rawContent.ID
+		}
+	}
+	// 数据源在保存时才创建，保存后再记录爬取结果，首次爬取也能更新数据源质量分
+	if ctx.Err() == nil {
+		recordCrawlOutcome(item.URL, result, crawlErr)
+	}
+
+	finishedAt := time.Now()
+	item.FinishedAt = &finishedAt
+	if result != nil {
+		item.CrawlerLayer = result.Method
+	}
+
+	switch {
+	case err != nil && ctx.Err() != nil:
+		// 批量任务被取消或超时，未完成的URL不算爬取失败，重置为待爬取以便重试
+		item.Status = "pending"
+		item.FinishedAt = nil
+		log.Printf("爬取已取消 %s", item.URL)
+	case err != nil:
+		item.Status = "failed"
+		item.Error = err.Error()
+		item.ErrorKind = string(crawler.ErrorKindOf(err))
+		log.Printf("爬取最终失败 %s: %v", item.URL, err)
+	default:
+		item.Status = "succeeded"
+		log.Printf("爬取成功: %s", item.URL)
+	}
+	db.Save(item)
+}
+
+// finishCrawlJob 汇总URL状态并更新任务状态
+func (h *CrawlHandler) finishCrawlJob(ctx context.Context, crawlJob *models.CrawlJob) {
+	db := database.DB
+
+	var succeeded, failed int64
+	db.Model(&models.CrawlJobItem{}).Where("crawl_job_id = ? AND status = ?", crawlJob.ID, "succeeded").Count(&succeeded)
+	db.Model(&models.CrawlJobItem{}).Where("crawl_job_id = ? AND status = ?", crawlJob.ID, "failed").Count(&failed)
+
+	crawlJob.SucceededItems = int(succeeded)
+	crawlJob.FailedItems = int(failed)
+
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		crawlJob.Status = "cancelled"
+	case crawlJob.SucceededItems == crawlJob.TotalItems:
+		crawlJob.Status = "completed"
+	case crawlJob.SucceededItems == 0:
+		crawlJob.Status = "failed"
+	default:
+		crawlJob.Status = "partial"
+	}
+
+	// 未执行到的URL（取消或超时）重置为待爬取，便于重试
+	db.Model(&models.CrawlJobItem{}).
+		Where("crawl_job_id = ? AND status = ?", crawlJob.ID, "running").
+		Updates(map[string]interface{}{"status": "pending"})
+
+	now := time.Now()
+	crawlJob.CompletedAt = &now
+	db.Model(crawlJob).Updates(map[string]interface{}{
+		"status":          crawlJob.Status,
+		"succeeded_items": crawlJob.SucceededItems,
+		"failed_items":    crawlJob.FailedItems,
+		"completed_at":    crawlJob.CompletedAt,
+	})
+}
+
+// crawlAttempts 爬取结果中每一层爬虫的尝试结果（ok或失败类型）
+func crawlAttempts(result *crawler.CrawlResult) map[string]string {
+	attempts := make(map[string]string)
+	for key, value := range result.Metadata {
+		if layer, ok := strings.CutPrefix(key, "attempt_"); ok {
+			attempts[layer] = value
+		}
+	}
+	return attempts
+}
+
+// crawledPublishedAt 爬取结果元数据中的发布时间，没有时返回nil
+func crawledPublishedAt(result *crawler.CrawlResult) *time.Time {
+	if result == nil || result.Metadata["published_at"] == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, result.Metadata["published_at"])
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// storeCrawlResult 保存爬取内容到本地文件和数据库，并与上一次爬取的内容比较
+func (h *CrawlHandler) storeCrawlResult(ctx context.Context, item URLItem, result *crawler.CrawlResult) (*models.RawContent, *crawler.SaveResult, error) {
+	// 通过实体解析查找或创建竞品，同一竞品的不同写法归到同一条记录
+	name := item.Competitor
+	if strings.TrimSpace(name) == "" {
+		name = extractBrandFromURL(item.URL)
+	}
+	competitor, _, err := competitorResolver().Resolve(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析竞品失败: %w", err)
+	}
+
+	previous := loadPreviousSnapshot(competitor.ID, item.URL)
+
+	saveResult, err := h.saver.Save(ctx, result, competitor.Name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("保存失败: %w", err)
+	}
+
+	db := database.DB
+
+	// 查找或创建数据源
+	var dataSource models.DataSource
+	db.Where(models.DataSource{CompetitorID: competitor.ID, URL: item.URL}).
+		Attrs(models.DataSource{QualityScore: 0.5}).
+		FirstOrCreate(&dataSource)
+	if dataSource.SourceType == "" && item.SourceType != "" {
+		dataSource.SourceType = item.SourceType
+	}
+	now := time.Now()
+	dataSource.LastCrawlTime = &now
+	publishedAt := crawledPublishedAt(result)
+	if publishedAt != nil {
+		dataSource.PublishedAt = publishedAt
+	}
+	db.Save(&dataSource)
+
+	// 保存原始内容
+	rawContent := &models.RawContent{
+		SourceID:    dataSource.ID,
+		ContentPath: saveResult.ContentPath,
+		ContentHash: crawler.CalculateHash(result.Markdown),
+		CrawlTime:   time.Now(),
+		PublishedAt: publishedAt,
+		Metadata: models.JSONB{
+			"title":    result.Title,
+			"platform": result.Platform,
+			"method":   result.Method,
+			"url":      item.URL,
+			"attempts": crawlAttempts(result),
+		},
+	}
+	if err := db.Create(rawContent).Error; err != nil {
+		return nil, nil, fmt.Errorf("保存原始内容失败: %w", err)
+	}
+
+	detectContentChange(&dataSource, previous, rawContent, saveResult)
+
+	return rawContent, saveResult, nil
+}
+
+// GetCrawlJob 查询批量爬取任务及每个URL的状态
+func (h *CrawlHandler) GetCrawlJob(c *gin.Context) {
+	var crawlJob models.CrawlJob
+	err := database.DB.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).First(&crawlJob, c.Param("id")).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "爬取任务不存在"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"job": crawlJob,
+	})
+}
+
+// RetryCrawlJob 重新爬取失败的URL
+func (h *CrawlHandler) RetryCrawlJob(c *gin.Context) {
+	db := database.DB
+
+	var crawlJob models.CrawlJob
+	if err := db.First(&crawlJob, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "爬取任务不存在"})
+		return
+	}
+
+	if crawlJob.Status == "pending" || crawlJob.Status == "running" {
+		c.JSON(http.StatusConflict, gin.H{"error": "爬取任务正在执行", "status": crawlJob.Status})
+		return
+	}
+
+	result := db.Model(&models.CrawlJobItem{}).
+		Where("crawl_job_id = ? AND status IN ?", crawlJob.ID, []string{"failed", "pending"}).
+		Updates(map[string]interface{}{"status": "pending", "error": ""})
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "没有需要重试的URL"})
+		return
+	}
+
+	db.Model(&crawlJob).Updates(map[string]interface{}{"status": "pending", "completed_at": nil})
+
+	job, err := jobQueue.Enqueue(jobCrawlBatch, crawlBatchPayload{CrawlJobID: crawlJob.ID}, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建任务失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"crawl_job_id": crawlJob.ID,
+		"job_id":       job.ID,
+		"retry_urls":   result.RowsAffected,
+	})
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"competitive-analyzer/ai"
+	"competitive-analyzer/crawler"
+	"competitive-analyzer/database"
+	"competitive-analyzer/discovery"
+	"competitive-analyzer/models"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	domainQualityOnce sync.Once
+	domainQuality     *discovery.DomainQualityStore
+)
+
+// domainQualityStore 域名质量记录（所有处理器共享）
+func domainQualityStore() *discovery.DomainQualityStore {
+	domainQualityOnce.Do(func() {
+		domainQuality = discovery.NewDomainQualityStore(database.DB)
+	})
+	return domainQuality
+}
+
+// processSearchResults 分类和评分搜索结果，评分时参考域名的历史质量
+func (h *DiscoveryHandler) processSearchResults(results []discovery.SearchResult) []*discovery.DataSourceInfo {
+	urls := make([]string, len(results))
+	for i, result := range results {
+		urls[i] = result.URL
+	}
+	return discovery.ProcessSearchResults(results, h.searchManager.EngineCount(), domainQualityStore().Load(urls), platformRegistry())
+}
+
+// taskSources 发现任务结果中的数据源，按URL索引
+func taskSources(task *models.DiscoveryTask) map[string]*discovery.DataSourceInfo {
+	sources := make(map[string]*discovery.DataSourceInfo)
+	raw, ok := task.ResultData["data_sources"].(map[string]interface{})
+	if !ok {
+		return sources
+	}
+	var lists map[string][]*discovery.DataSourceInfo
+	if err := decodeJSONB(raw, &lists); err != nil {
+		return sources
+	}
+	for _, list := range lists {
+		for _, source := range list {
+			sources[source.URL] = source
+		}
+	}
+	return sources
+}
+
+// recordCrawlOutcome 记录爬取结果到域名统计
+func recordCrawlOutcome(rawURL string, result *crawler.CrawlResult, err error) {
+	outcome := discovery.CrawlOutcome{Success: err == nil && result != nil}
+	if outcome.Success {
+		outcome.ContentLength = len([]rune(result.Markdown))
+	} else if err != nil {
+		outcome.Captcha = crawler.HasErrorKind(err, crawler.ErrKindCaptcha)
+	}
+	domainQualityStore().RecordCrawl(rawURL, outcome)
+}
+
+// recordAnalysisOutcome 分析完成后，按每个数据源的内容是否包含分析出的定价和功能，记录到域名统计
+func recordAnalysisOutcome(dataSources []models.DataSource, rawContents []models.RawContent, productInfo *ai.ProductInfo) {
+	sourceURLs := make(map[uint]string, len(dataSources))
+	for _, ds := range dataSources {
+		sourceURLs[ds.ID] = ds.URL
+	}
+
+	// 同一数据源有多次爬取时只记录一次
+	recorded := make(map[uint]bool)
+	for _, rc := range rawContents {
+		if recorded[rc.SourceID] || rc.ContentPath == "" || sourceURLs[rc.SourceID] == "" {
+			continue
+		}
+		content, err := os.ReadFile(rc.ContentPath)
+		if err != nil {
+			continue
+		}
+		recorded[rc.SourceID] = true
+
+		pricing, features := contentYield(string(content), productInfo)
+		domainQualityStore().RecordExtraction(sourceURLs[rc.SourceID], pricing, features)
+	}
+}
+
+// 价格：货币符号或单位紧邻数字，或"免费/free"
+var priceNearPattern = regexp.MustCompile(`(?i)[¥￥$€£]\s?\d|\d\s*(?:元|美元|usd|rmb|eur)|\d\s*/\s*(?:mo|month|yr|year|月|年|人)|免费|\bfree\b`)
+
+// 套餐名称前后该范围（字节）内出现价格，才算内容包含定价
+const priceNearWindow = 120
+
+// contentYield 判断内容中是否包含分析结果的定价（套餐名称附近有价格）和功能（功能名称）；
+// 套餐名称（如"Pro""基础版"）单独出现很常见，不能说明页面有定价
+func contentYield(content string, productInfo *ai.ProductInfo) (pricing, features bool) {
+	if productInfo == nil {
+		return false, false
+	}
+	lower := strings.ToLower(content)
+	contains := func(name string) bool {
+		name = strings.ToLower(strings.TrimSpace(name))
+		return len([]rune(name)) >= 2 && strings.Contains(lower, name)
+	}
+	nearPrice := func(name string) bool {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if len([]rune(name)) < 2 {
+			return false
+		}
+		for offset := 0; ; {
+			i := strings.Index(lower[offset:], name)
+			if i < 0 {
+				return false
+			}
+			start, end := offset+i, offset+i+len(name)
+			if priceNearPattern.MatchString(lower[max(start-priceNearWindow, 0):min(end+priceNearWindow, len(lower))]) {
+				return true
+			}
+			offset = end
+		}
+	}
+
+	for _, tier := range productInfo.Pricing.Tiers {
+		if nearPrice(tier.Name) {
+			pricing = true
+			break
+		}
+	}
+	for _, feature := range productInfo.CoreFeatures {
+		if contains(feature.Name) {
+			features = true
+			break
+		}
+	}
+	return pricing, features
+}
+
+// sortByDomainQuality 按域名的历史质量排序爬取项，质量高的先爬，没有记录的域名按中等处理
+func sortByDomainQuality(items []models.CrawlJobItem) {
+	urls := make([]string, len(items))
+	for i, item := range items {
+		urls[i] = item.URL
+	}
+	qualities := domainQualityStore().Load(urls)
+
+	expected := func(rawURL string) float64 {
+		if quality, ok := qualities[discovery.SiteDomain(rawURL)]; ok {
+			return quality.Expected()
+		}
+		return 0.5
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return expected(items[i].URL) > expected(items[j].URL)
+	})
+}
+
+// GetDomainStats 获取按域名统计的爬取和分析效果，按质量分排序
+func GetDomainStats(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	query := database.DB.Model(&models.DomainStat{})
+	if domain := c.Query("domain"); domain != "" {
+		query = query.Where("domain = ?", discovery.SiteDomain(domain))
+	}
+
+	order := "quality_score DESC"
+	if c.Query("order") == "worst" {
+		order = "quality_score ASC"
+	}
+
+	stats := []models.DomainStat{}
+	query.Order(order).Order("crawl_attempts DESC").Limit(limit).Find(&stats)
+
+	c.JSON(http.StatusOK, gin.H{
+		"domains": stats,
+	})
+}
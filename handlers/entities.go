@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"competitive-analyzer/database"
+	"competitive-analyzer/entity"
+	"competitive-analyzer/models"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	resolverOnce sync.Once
+	resolver     *entity.Resolver
+)
+
+// competitorResolver 竞品实体解析器（所有处理器共享，避免并发创建重复竞品）
+func competitorResolver() *entity.Resolver {
+	resolverOnce.Do(func() {
+		resolver = entity.NewResolver(database.DB)
+	})
+	return resolver
+}
+
+// MergeCompetitorsRequest 合并竞品请求
+type MergeCompetitorsRequest struct {
+	TargetID  uint   `json:"target_id" binding:"required"`  // 保留的竞品
+	SourceIDs []uint `json:"source_ids" binding:"required"` // 合并到target后删除的竞品
+}
+
+// MergeCompetitors 合并重复的竞品
+func MergeCompetitors(c *gin.Context) {
+	var req MergeCompetitorsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := competitorResolver().Merge(req.TargetID, req.SourceIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"result":  result,
+	})
+}
+
+// GetCompetitorDuplicates 列出名称或别名相同/相似的疑似重复竞品
+func GetCompetitorDuplicates(c *gin.Context) {
+	duplicates, err := competitorResolver().Duplicates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"duplicates": duplicates,
+	})
+}
+
+// AddCompetitorAliasRequest 添加别名请求
+type AddCompetitorAliasRequest struct {
+	Alias string `json:"alias" binding:"required"`
+}
+
+// AddCompetitorAlias 为竞品手动添加别名
+func AddCompetitorAlias(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的竞品ID"})
+		return
+	}
+
+	var req AddCompetitorAliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var competitor models.Competitor
+	if err := database.DB.First(&competitor, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "竞品不存在"})
+		return
+	}
+
+	alias, err := competitorResolver().AddAlias(competitor.ID, req.Alias)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"alias":   alias,
+	})
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"competitive-analyzer/ai"
+	"competitive-analyzer/config"
+	"competitive-analyzer/crawler"
+	"competitive-analyzer/discovery"
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// 每篇文章最多发送给LLM的字符数
+const maxArticleRunes = 8000
+
+// 同时抓取和提取的文章数
+const extractionWorkers = 3
+
+// competitorExtraction 竞品提取结果
+type competitorExtraction struct {
+	Competitors []discovery.CompetitorVote `json:"competitors"`
+	Articles    []string                   `json:"articles"` // 成功提取的文章
+	Method      string                     `json:"method"`   // llm：从文章中提取；heuristic：LLM提取失败，从搜索结果的域名和标题推测
+	// 滚雪球查询中额外提取的文章（也包含在Articles中）
+	SnowballArticles []string `json:"snowball_articles,omitempty"`
+
+	// 基于种子产品发现时的种子识别结果和使用的品类
+	Seeds    []seedProduct `json:"seeds,omitempty"`
+	Category string        `json:"category,omitempty"`
+}
+
+// Names 竞品名称列表
+func (e *competitorExtraction) Names() []string {
+	names := make([]string, len(e.Competitors))
+	for i, competitor := range e.Competitors {
+		names[i] = competitor.Name
+	}
+	return names
+}
+
+// extractCompetitors 抓取排名靠前的对比/盘点类文章，用LLM提取竞品并按置信度投票汇总。
+// 搜索深度策略要求滚雪球时，再对排名靠前的竞品追加"X vs 主题""X 替代品"查询，从新文章中补充提取
+func (h *DiscoveryHandler) extractCompetitors(ctx context.Context, results []discovery.SearchResult, topic string, plan discovery.DepthPlan, limit int) (*competitorExtraction, error) {
+	extract := func(ctx context.Context, content string) ([]ai.CompetitorInfo, error) {
+		return h.extractor.ExtractCompetitors(ctx, topic, content)
+	}
+
+	articles := discovery.SelectArticles(results, plan.Articles, staleArticleAge())
+	mentions, extracted := h.collectMentions(ctx, articles, extract)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	extraction := &competitorExtraction{Articles: extracted, Method: "llm"}
+	extraction.Competitors = discovery.VoteCompetitors(mentions, len(extracted), topic)
+
+	// 滚雪球：首轮提取到竞品时才追加查询
+	if plan.Snowball > 0 && len(extraction.Competitors) > 0 {
+		snowballResults, err := h.searchManager.SearchSnowball(ctx, topic, extraction.Names(), plan)
+		if err != nil {
+			log.Printf("[竞品提取] 滚雪球搜索失败: %v", err)
+		}
+
+		used := make(map[string]bool)
+		for _, article := range articles {
+			used[article.URL] = true
+		}
+		var fresh []discovery.SearchResult
+		for _, result := range snowballResults {
+			if !used[result.URL] {
+				fresh = append(fresh, result)
+			}
+		}
+
+		moreMentions, moreExtracted := h.collectMentions(ctx, discovery.SelectArticles(fresh, plan.SnowballArticles, staleArticleAge()), extract)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if len(moreExtracted) > 0 {
+			mentions = append(mentions, moreMentions...)
+			extraction.Articles = append(extraction.Articles, moreExtracted...)
+			extraction.SnowballArticles = moreExtracted
+			extraction.Competitors = discovery.VoteCompetitors(mentions, len(extraction.Articles), topic)
+		}
+	}
+
+	// LLM不可用或文章都抓取失败时，退回到从搜索结果推测
+	if len(extraction.Competitors) == 0 {
+		log.Printf("[竞品提取] 未能从文章中提取竞品，改为从搜索结果推测: %s", topic)
+		extraction.Method = "heuristic"
+		for _, name := range extractCompetitorNamesFromResults(results, limit) {
+			extraction.Competitors = append(extraction.Competitors, discovery.CompetitorVote{Name: name})
+		}
+	}
+
+	if len(extraction.Competitors) > limit {
+		extraction.Competitors = extraction.Competitors[:limit]
+	}
+	return extraction, nil
+}
+
+// staleArticleAge 文章过时的期限，0表示不过滤
+func staleArticleAge() time.Duration {
+	return time.Duration(config.AppConfig.StaleArticleDays) * 24 * time.Hour
+}
+
+// isStaleContent 爬取结果中的发布时间是否已经过时
+func isStaleContent(result *crawler.CrawlResult) bool {
+	publishedAt := crawledPublishedAt(result)
+	return discovery.IsStale(publishedAt, staleArticleAge(), time.Now())
+}
+
+// collectMentions 并发抓取文章并用extract提取竞品提及，返回提及和成功提取的文章
+func (h *DiscoveryHandler) collectMentions(ctx context.Context, articles []discovery.SearchResult, extract func(context.Context, string) ([]ai.CompetitorInfo, error)) ([]discovery.CompetitorMention, []string) {
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		mentions  []discovery.CompetitorMention
+		extracted []string
+	)
+	semaphore := make(chan struct{}, extractionWorkers)
+
+	for _, article := range articles {
+		wg.Add(1)
+		go func(article discovery.SearchResult) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			result, err := h.crawler.Crawl(ctx, article.URL)
+			if err != nil {
+				log.Printf("[竞品提取] 抓取文章失败 %s: %v", article.URL, err)
+				return
+			}
+
+			// 搜索结果中没有发布时间的文章，按页面中解析出的发布时间再过滤一次
+			if article.PublishedAt == nil && isStaleContent(result) {
+				log.Printf("[竞品提取] 跳过过时文章 %s（发布于 %s）", article.URL, result.Metadata["published_at"])
+				return
+			}
+
+			content := result.Markdown
+			if runes := []rune(content); len(runes) > maxArticleRunes {
+				content = string(runes[:maxArticleRunes])
+			}
+
+			competitors, err := extract(ctx, content)
+			if err != nil {
+				log.Printf("[竞品提取] LLM提取失败 %s: %v", article.URL, err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			extracted = append(extracted, article.URL)
+			for _, competitor := range competitors {
+				mentions = append(mentions, discovery.CompetitorMention{
+					Name:       competitor.Name,
+					Confidence: competitor.Confidence,
+					Reason:     competitor.Reason,
+					SourceURL:  article.URL,
+				})
+			}
+		}(article)
+	}
+	wg.Wait()
+
+	return mentions, extracted
+}
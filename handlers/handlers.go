@@ -6,8 +6,11 @@ import (
 	"competitive-analyzer/crawler"
 	"competitive-analyzer/database"
 	"competitive-analyzer/discovery"
+	"competitive-analyzer/jobs"
 	"competitive-analyzer/models"
+	"competitive-analyzer/notify"
 	"competitive-analyzer/report"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -15,7 +18,6 @@ import (
 	"os"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -25,44 +27,107 @@ import (
 type DiscoveryHandler struct {
 	searchManager *discovery.SearchManager
 	llmClient     *ai.LLMClient
+	crawler       *crawler.ThreeLayerCrawler
+	extractor     *ai.CompetitorExtractor
 }
 
 // NewDiscoveryHandler 创建处理器
 func NewDiscoveryHandler() *DiscoveryHandler {
 	cfg := config.AppConfig
 
-	// 初始化搜索引擎
-	engines := []discovery.SearchEngine{}
+	engines := newSearchEngines(cfg)
+	if len(engines) == 0 {
+		log.Println("未配置任何搜索引擎，数据源发现不可用")
+	}
+
+	// 搜索结果缓存在SearchCache表中，SEARCH_CACHE_DAYS为0时不缓存
+	if cfg.SearchCacheDays > 0 {
+		for i, engine := range engines {
+			engines[i] = discovery.NewCachedSearchEngine(engine, database.DB, cfg.SearchCacheDays)
+		}
+	}
+
+	templates, err := discovery.LoadQueryTemplates(cfg.QueryTemplates)
+	if err != nil {
+		log.Printf("加载查询模板失败，使用内置模板: %v", err)
+		templates = nil
+	}
+
+	searchManager := discovery.NewSearchManager(engines, cfg.SearchMode, templates)
+	llmClient := newLLMClient(cfg)
+
+	return &DiscoveryHandler{
+		searchManager: searchManager,
+		llmClient:     llmClient,
+		crawler:       newThreeLayerCrawler(cfg),
+		extractor:     ai.NewCompetitorExtractor(llmClient),
+	}
+}
+
+// newSearchEngines 按配置创建搜索引擎：SEARCH_ENGINES指定启用的引擎及优先级，
+// 未指定时启用所有已配置的引擎（百度和DuckDuckGo需要在SEARCH_ENGINES中显式启用）
+func newSearchEngines(cfg *config.Config) []discovery.SearchEngine {
+	available := map[string]discovery.SearchEngine{}
 
 	if cfg.SerperAPIKey != "" {
-		engines = append(engines, &discovery.SerperSearchEngine{APIKey: cfg.SerperAPIKey})
+		available["serper"] = &discovery.SerperSearchEngine{APIKey: cfg.SerperAPIKey}
 	}
 	if cfg.GoogleAPIKey != "" && cfg.GoogleEngineID != "" {
-		engines = append(engines, &discovery.GoogleSearchEngine{
+		available["google"] = &discovery.GoogleSearchEngine{
 			APIKey:   cfg.GoogleAPIKey,
 			EngineID: cfg.GoogleEngineID,
-		})
+		}
 	}
 	if cfg.BingAPIKey != "" {
-		engines = append(engines, &discovery.BingSearchEngine{APIKey: cfg.BingAPIKey})
+		available["bing"] = &discovery.BingSearchEngine{APIKey: cfg.BingAPIKey}
+	}
+	if cfg.SearxNGURL != "" {
+		available["searxng"] = &discovery.SearxNGSearchEngine{BaseURL: cfg.SearxNGURL, Engines: cfg.SearxNGEngines}
 	}
+	available["baidu"] = &discovery.BaiduSearchEngine{}
+	available["duckduckgo"] = &discovery.DuckDuckGoSearchEngine{}
 
-	searchManager := discovery.NewSearchManager(engines)
-	llmClient := ai.NewLLMClient(cfg.OpenAIAPIKey, cfg.LLMModel, cfg.LLMTemperature, cfg.LLMMaxTokens, cfg.LLMBaseURL)
+	names := cfg.SearchEngines
+	if len(names) == 0 {
+		names = []string{"serper", "google", "bing", "searxng"}
+	}
 
-	return &DiscoveryHandler{
-		searchManager: searchManager,
-		llmClient:     llmClient,
+	engines := []discovery.SearchEngine{}
+	for _, name := range names {
+		engine, ok := available[name]
+		if !ok {
+			if len(cfg.SearchEngines) > 0 {
+				log.Printf("搜索引擎 %s 未配置或不支持，已跳过", name)
+			}
+			continue
+		}
+		// 连续失败（额度用尽、触发安全验证等）后暂时跳过该引擎
+		engines = append(engines, discovery.NewBreakerSearchEngine(engine, providerBreakers().Get("search:"+name)))
+	}
+	return engines
+}
+
+// newLLMClient 根据配置创建LLM客户端
+func newLLMClient(cfg *config.Config) *ai.LLMClient {
+	llmClient := ai.NewLLMClient(cfg.OpenAIAPIKey, cfg.LLMModel, cfg.LLMTemperature, cfg.LLMMaxTokens, cfg.LLMBaseURL)
+	if cfg.LLMTimeout > 0 {
+		llmClient.Timeout = time.Duration(cfg.LLMTimeout) * time.Second
 	}
+	llmClient.Breaker = providerBreakers().Get("llm")
+	return llmClient
 }
 
 // SearchRequest 搜索请求
 type SearchRequest struct {
-	Topic           string   `json:"topic" binding:"required"`
+	Topic           string   `json:"topic"`
+	Seeds           []string `json:"seeds"` // 种子产品名称或网址（通常是自己的产品），提供时查找它们的替代品
 	Market          string   `json:"market"`
 	CompetitorCount int      `json:"competitor_count"`
 	SourceTypes     []string `json:"source_types"`
-	Depth           string   `json:"depth"` // quick/standard/deep
+	Depth           string   `json:"depth"`         // quick/standard/deep
+	ForceRefresh    bool     `json:"force_refresh"` // 忽略搜索缓存，重新搜索
+
+	NotifyChannels []notify.ChannelConfig `json:"notify_channels"` // 任务结束时的通知渠道
 }
 
 // Search 开始数据源发现
@@ -72,21 +137,51 @@ func (h *DiscoveryHandler) Search(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := validateChannels(req.NotifyChannels); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	seeds, err := normalizeSeeds(req.Seeds)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Seeds = seeds
+	req.Topic = strings.TrimSpace(req.Topic)
+	if req.Topic == "" && len(req.Seeds) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "topic 和 seeds 至少提供一个"})
+		return
+	}
+	topic := req.Topic
+	if topic == "" {
+		topic = seedTopic(req.Seeds)
+	}
 
 	// 设置默认值
 	if req.Depth == "" {
-		req.Depth = "standard"
+		req.Depth = discovery.DepthStandard
 	}
 	if req.CompetitorCount == 0 {
 		req.CompetitorCount = 5
 	}
+	plan, err := discovery.PlanForDepth(req.Depth)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	searchPlan, err := jobs.EncodePayload(plan)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	// 创建任务
 	task := &models.DiscoveryTask{
-		Topic:       req.Topic,
+		Topic:       topic,
 		Market:      req.Market,
 		TargetCount: req.CompetitorCount,
 		SearchDepth: req.Depth,
+		SearchPlan:  searchPlan,
 		Status:      "processing",
 		Progress:    0,
 		CreatedAt:   time.Now(),
@@ -97,11 +192,17 @@ func (h *DiscoveryHandler) Search(c *gin.Context) {
 		return
 	}
 
-	// 异步执行搜索
-	go h.executeSearch(task, req)
+	// 加入任务队列异步执行
+	job, err := jobQueue.Enqueue(jobDiscoverySearch, searchJobPayload{TaskID: task.ID, Request: req}, &task.ID)
+	if err != nil {
+		failTask(task, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建任务失败"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"task_id":        task.ID,
+		"job_id":         job.ID,
 		"status":         "processing",
 		"progress":       0,
 		"estimated_time": 60,
@@ -109,58 +210,78 @@ func (h *DiscoveryHandler) Search(c *gin.Context) {
 }
 
 // executeSearch 执行搜索（后台任务）
-func (h *DiscoveryHandler) executeSearch(task *models.DiscoveryTask, req SearchRequest) {
-	db := database.DB
+func (h *DiscoveryHandler) executeSearch(ctx context.Context, task *models.DiscoveryTask, req SearchRequest) error {
+	cfg := config.AppConfig
+
+	ctx, cancel := stageContext(ctx, cfg.DiscoveryTimeout)
+	defer cancel()
+	if req.ForceRefresh {
+		ctx = discovery.WithForceRefresh(ctx)
+	}
+	// 按目标市场使用对应语言的查询模板和搜索地区
+	ctx = h.searchManager.WithMarket(ctx, req.Market)
 
 	// 更新进度：10% - 开始搜索竞品
 	task.Progress = 10
-	db.Save(task)
-
-	// 1. 搜索竞品
-	maxResults := 10
-	if req.Depth == "deep" {
-		maxResults = 20
-	} else if req.Depth == "quick" {
-		maxResults = 5
-	}
+	saveTask(task)
 
-	searchResults, err := h.searchManager.SearchCompetitors(req.Topic, maxResults)
+	// 1. 按搜索深度策略搜索竞品
+	plan, err := discovery.PlanForDepth(req.Depth)
 	if err != nil {
-		task.Status = "failed"
-		task.ResultData = models.JSONB{"error": err.Error()}
-		db.Save(task)
-		return
+		return err
 	}
 
-	// 更新进度：40% - 提取竞品名称
-	task.Progress = 40
-	db.Save(task)
+	var extraction *competitorExtraction
+	if len(req.Seeds) > 0 {
+		// 提供了种子产品：查找种子的替代品
+		extraction, err = h.discoverAlternatives(ctx, req.Seeds, req.Topic, plan, req.CompetitorCount)
+		if err != nil {
+			return fmt.Errorf("查找替代品失败: %w", err)
+		}
+	} else {
+		searchResults, err := h.searchManager.SearchCompetitors(ctx, req.Topic, plan)
+		if err != nil {
+			return fmt.Errorf("搜索竞品失败: %w", err)
+		}
 
-	// 2. 从搜索结果中提取竞品（这里简化处理，实际应该访问文章用LLM提取）
-	// 为了演示，我们直接从标题中提取可能的竞品名称
-	competitorNames := h.extractCompetitorNames(searchResults, req.Topic)
+		// 更新进度：40% - 提取竞品名称
+		task.Progress = 40
+		saveTask(task)
 
-	if len(competitorNames) > req.CompetitorCount {
-		competitorNames = competitorNames[:req.CompetitorCount]
+		// 2. 抓取对比/盘点类文章，用LLM提取竞品并投票汇总
+		extraction, err = h.extractCompetitors(ctx, searchResults, req.Topic, plan, req.CompetitorCount)
+		if err != nil {
+			return fmt.Errorf("提取竞品失败: %w", err)
+		}
 	}
+	competitorNames := extraction.Names()
 
 	// 更新进度：60% - 搜索数据源
 	task.Progress = 60
 	task.CompetitorsFound = len(competitorNames)
-	db.Save(task)
+	saveTask(task)
 
-	// 3. 为每个竞品搜索数据源
+	// 3. 为每个竞品识别官网并搜索数据源，官网验证通过时数据源查询限定在官网内
 	allDataSources := make(map[string][]*discovery.DataSourceInfo)
+	websites := make(map[string]*discovery.OfficialSite)
 
 	for _, competitorName := range competitorNames {
-		sources, err := h.searchManager.SearchDataSources(competitorName, req.SourceTypes, 5)
+		website := h.findOfficialSite(ctx, competitorName)
+		if website != nil {
+			websites[competitorName] = website
+		}
+
+		sources, err := h.searchManager.SearchDataSources(ctx, competitorName, verifiedDomain(website), req.SourceTypes, plan.SourcesPerType)
+		if ctx.Err() != nil {
+			return fmt.Errorf("搜索数据源失败: %w", ctx.Err())
+		}
 		if err != nil {
 			continue
 		}
 
 		// 处理和评分
 		for sourceType, results := range sources {
-			processed := discovery.ProcessSearchResults(results)
+			processed := h.processSearchResults(results)
 			key := fmt.Sprintf("%s_%s", competitorName, sourceType)
 			allDataSources[key] = processed
 		}
@@ -182,35 +303,20 @@ func (h *DiscoveryHandler) executeSearch(task *models.DiscoveryTask, req SearchR
 
 	// 保存结果
 	task.ResultData = models.JSONB{
-		"competitors":  competitorNames,
-		"data_sources": allDataSources,
-	}
-
-	db.Save(task)
-}
-
-// extractCompetitorNames 从搜索结果中提取竞品名称（简化版）
-func (h *DiscoveryHandler) extractCompetitorNames(results []discovery.SearchResult, topic string) []string {
-	// 这里应该用LLM提取，为了简化，我们从标题中提取
-	// 实际应该访问对比类文章，用LLM提取竞品列表
-	names := make(map[string]bool)
-
-	for _, result := range results {
-		// 简单的关键词提取逻辑
-		// 实际项目中应该用LLM
-		if len(names) >= 10 {
-			break
-		}
-		// 这里只是占位符，实际需要更智能的提取
-		names[result.Title] = true
-	}
-
-	nameList := []string{}
-	for name := range names {
-		nameList = append(nameList, name)
-	}
-
-	return nameList
+		"competitors":        competitorNames,
+		"competitor_details": extraction.Competitors,
+		"extraction_method":  extraction.Method,
+		"articles":           extraction.Articles,
+		"snowball_articles":  extraction.SnowballArticles,
+		"seeds":              extraction.Seeds,
+		"category":           extraction.Category,
+		"websites":           websites,
+		"data_sources":       allDataSources,
+		"search_plan":        plan,
+	}
+
+	saveTask(task)
+	return nil
 }
 
 // GetStatus 获取任务状态
@@ -228,6 +334,8 @@ func (h *DiscoveryHandler) GetStatus(c *gin.Context) {
 		"progress":           task.Progress,
 		"competitors_found":  task.CompetitorsFound,
 		"data_sources_found": task.SourcesFound,
+		"search_depth":       task.SearchDepth,
+		"search_plan":        task.SearchPlan,
 		"result":             task.ResultData,
 		"created_at":         task.CreatedAt,
 		"completed_at":       task.CompletedAt,
@@ -259,34 +367,47 @@ func (h *DiscoveryHandler) Confirm(c *gin.Context) {
 		return
 	}
 
+	websites := taskWebsites(&task)
+	discovered := taskSources(&task)
+
 	// 保存竞品和数据源到数据库
 	for _, competitorName := range req.SelectedCompetitors {
-		// 创建竞品记录
-		competitor := &models.Competitor{
-			Name:            competitorName,
-			DiscoveryTaskID: &task.ID,
-			Confidence:      0.9,
-			Status:          "active",
-			CreatedAt:       time.Now(),
-			UpdatedAt:       time.Now(),
-		}
-
-		if err := db.Create(competitor).Error; err != nil {
+		// 查找或创建竞品记录，已有的同一竞品（包括不同写法）不会重复创建
+		competitor, created, err := competitorResolver().Resolve(competitorName)
+		if err != nil {
 			continue
 		}
+		if created {
+			competitor.DiscoveryTaskID = &task.ID
+			competitor.Confidence = 0.9
+			db.Save(competitor)
+		}
+		saveCompetitorWebsite(competitor, websites[competitorName])
 
 		// 保存数据源
 		if sources, ok := req.SelectedSources[competitorName]; ok {
 			for _, sourceURL := range sources {
-				dataSource := &models.DataSource{
-					CompetitorID:   competitor.ID,
-					URL:            sourceURL,
-					Priority:       1,
-					QualityScore:   0.8,
-					AutoDiscovered: true,
-					Status:         "active",
+				// 初始质量分使用发现时的评分，之后随爬取和分析结果更新
+				qualityScore := 0.8
+				var publishedAt *time.Time
+				if source, ok := discovered[sourceURL]; ok {
+					qualityScore = source.QualityScore
+					publishedAt = source.PublishedAt
+				}
+
+				var dataSource models.DataSource
+				err := db.Where(models.DataSource{CompetitorID: competitor.ID, URL: sourceURL}).
+					Attrs(models.DataSource{
+						Priority:       1,
+						QualityScore:   qualityScore,
+						AutoDiscovered: true,
+						Status:         "active",
+						PublishedAt:    publishedAt,
+					}).
+					FirstOrCreate(&dataSource).Error
+				if err != nil {
+					log.Printf("保存数据源失败 %s: %v", sourceURL, err)
 				}
-				db.Create(dataSource)
 			}
 		}
 	}
@@ -307,8 +428,8 @@ type CrawlHandler struct {
 func NewCrawlHandler() *CrawlHandler {
 	cfg := config.AppConfig
 	return &CrawlHandler{
-		crawler: crawler.NewThreeLayerCrawler(cfg.FirecrawlAPIKey),
-		saver:   crawler.NewContentSaver(cfg.StoragePath),
+		crawler: newThreeLayerCrawler(cfg),
+		saver:   crawler.NewContentSaver(cfg.StoragePath, platformRegistry()),
 	}
 }
 
@@ -327,56 +448,42 @@ func (h *CrawlHandler) CrawlSingle(c *gin.Context) {
 		return
 	}
 
+	ctx, cancel := stageContext(c.Request.Context(), config.AppConfig.CrawlTimeout)
+	defer cancel()
+
 	// 爬取
-	result, err := h.crawler.Crawl(req.URL)
+	result, err := h.crawler.Crawl(ctx, req.URL)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if ctx.Err() == nil {
+			recordCrawlOutcome(req.URL, nil, err)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      err.Error(),
+			"error_kind": crawler.ErrorKindOf(err),
+		})
 		return
 	}
 
-	// 保存
-	saveResult, err := h.saver.Save(result, req.Competitor)
+	// 保存到本地和数据库
+	rawContent, saveResult, err := h.storeCrawlResult(ctx, URLItem{
+		URL:        req.URL,
+		Competitor: req.Competitor,
+		SourceType: req.SourceType,
+	}, result)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败: " + err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-
-	// 保存到数据库
-	db := database.DB
-
-	// 查找或创建竞品
-	var competitor models.Competitor
-	db.FirstOrCreate(&competitor, models.Competitor{Name: req.Competitor})
-
-	// 查找或创建数据源
-	var dataSource models.DataSource
-	db.FirstOrCreate(&dataSource, models.DataSource{
-		CompetitorID: competitor.ID,
-		URL:          req.URL,
-	})
-	now := time.Now()
-	dataSource.LastCrawlTime = &now
-	db.Save(&dataSource)
-
-	// 保存原始内容
-	rawContent := &models.RawContent{
-		SourceID:    dataSource.ID,
-		ContentPath: saveResult.ContentPath,
-		ContentHash: crawler.CalculateHash(result.Markdown),
-		CrawlTime:   time.Now(),
-		Metadata: models.JSONB{
-			"title":    result.Title,
-			"platform": result.Platform,
-			"method":   result.Method,
-		},
-	}
-	db.Create(rawContent)
+	recordCrawlOutcome(req.URL, result, nil)
 
 	c.JSON(http.StatusOK, gin.H{
-		"success":      true,
-		"content_path": saveResult.ContentPath,
-		"image_count":  len(saveResult.ImagePaths),
-		"title":        saveResult.Title,
+		"success":        true,
+		"content_path":   saveResult.ContentPath,
+		"image_count":    len(saveResult.ImagePaths),
+		"title":          saveResult.Title,
+		"raw_content_id": rawContent.ID,
+		"crawler_layer":  result.Method,
+		"attempts":       crawlAttempts(result),
 	})
 }
 
@@ -391,7 +498,7 @@ func GetCompetitors(c *gin.Context) {
 	var total int64
 
 	db.Model(&models.Competitor{}).Count(&total)
-	db.Offset((page - 1) * pageSize).Limit(pageSize).Find(&competitors)
+	db.Preload("Aliases").Offset((page - 1) * pageSize).Limit(pageSize).Find(&competitors)
 
 	c.JSON(http.StatusOK, gin.H{
 		"total":       total,
@@ -442,109 +549,37 @@ func (h *CrawlHandler) CrawlBatch(c *gin.Context) {
 		return
 	}
 
-	// 设置默认并发数（降低以避免403错误）
+	// 并发数只决定同时爬取的主机数，同一主机的请求由主机调度器限速排队
 	concurrent := req.Concurrent
 	if concurrent <= 0 {
-		concurrent = 1 // 改为1，避免并发请求被封
+		concurrent = defaultCrawlConcurrency()
 	}
-	if concurrent > 3 {
-		concurrent = 3 // 降低最大并发，避免触发反爬虫
+	if concurrent > maxCrawlConcurrency {
+		concurrent = maxCrawlConcurrency
 	}
 
-	// 异步执行批量爬取
-	go h.executeBatchCrawl(req.URLs, concurrent)
-
-	c.JSON(http.StatusOK, gin.H{
-		"success":    true,
-		"total_urls": len(req.URLs),
-		"concurrent": concurrent,
-		"message":    "批量爬取任务已启动",
-	})
-}
-
-// executeBatchCrawl 执行批量爬取
-func (h *CrawlHandler) executeBatchCrawl(urls []URLItem, concurrent int) {
-	db := database.DB
-
-	// 使用信号量控制并发
-	sem := make(chan struct{}, concurrent)
-	var wg sync.WaitGroup
-
-	for i, urlItem := range urls {
-		wg.Add(1)
-		go func(index int, item URLItem) {
-			defer wg.Done()
-			sem <- struct{}{}        // 获取信号量
-			defer func() { <-sem }() // 释放信号量
-
-			// 添加渐进式延迟，避免同时发起过多请求
-			time.Sleep(time.Duration(index) * 2 * time.Second)
-
-			// 爬取（带重试）
-			var result *crawler.CrawlResult
-			var err error
-
-			// 最多重试3次
-			for retry := 0; retry < 3; retry++ {
-				result, err = h.crawler.Crawl(item.URL)
-				if err == nil {
-					break // 成功，退出重试
-				}
-
-				if retry < 2 {
-					// 失败，等待后重试
-					waitTime := time.Duration(retry+1) * 5 * time.Second
-					log.Printf("爬取失败 %s (重试 %d/2): %v，等待%v后重试...", item.URL, retry+1, err, waitTime)
-					time.Sleep(waitTime)
-				}
-			}
-
-			if err != nil {
-				log.Printf("爬取最终失败 %s: %v", item.URL, err)
-				return
-			}
-
-			// 保存
-			saveResult, err := h.saver.Save(result, item.Competitor)
-			if err != nil {
-				log.Printf("保存失败 %s: %v", item.URL, err)
-				return
-			}
-
-			// 保存到数据库
-			var competitor models.Competitor
-			db.FirstOrCreate(&competitor, models.Competitor{Name: item.Competitor})
-
-			var dataSource models.DataSource
-			db.FirstOrCreate(&dataSource, models.DataSource{
-				CompetitorID: competitor.ID,
-				URL:          item.URL,
-				SourceType:   item.SourceType,
-			})
-			now := time.Now()
-			dataSource.LastCrawlTime = &now
-			db.Save(&dataSource)
-
-			rawContent := &models.RawContent{
-				SourceID:    dataSource.ID,
-				ContentPath: saveResult.ContentPath,
-				ContentHash: crawler.CalculateHash(result.Markdown),
-				CrawlTime:   time.Now(),
-				Metadata: models.JSONB{
-					"title":    result.Title,
-					"platform": result.Platform,
-					"method":   result.Method,
-					"url":      item.URL,
-				},
-			}
-			db.Create(rawContent)
+	// 记录每个URL的爬取状态
+	crawlJob, err := createCrawlJob(req.URLs, concurrent, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-			log.Printf("爬取成功: %s", item.URL)
-		}(i, urlItem)
+	// 加入任务队列异步执行
+	job, err := jobQueue.Enqueue(jobCrawlBatch, crawlBatchPayload{CrawlJobID: crawlJob.ID}, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建任务失败: " + err.Error()})
+		return
 	}
 
-	wg.Wait()
-	log.Println("批量爬取任务完成")
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"crawl_job_id": crawlJob.ID,
+		"job_id":       job.ID,
+		"total_urls":   len(req.URLs),
+		"concurrent":   concurrent,
+		"message":      "批量爬取任务已启动",
+	})
 }
 
 // AnalysisHandler AI分析处理器
@@ -557,7 +592,7 @@ type AnalysisHandler struct {
 // NewAnalysisHandler 创建AI分析处理器
 func NewAnalysisHandler() *AnalysisHandler {
 	cfg := config.AppConfig
-	llmClient := ai.NewLLMClient(cfg.OpenAIAPIKey, cfg.LLMModel, cfg.LLMTemperature, cfg.LLMMaxTokens, cfg.LLMBaseURL)
+	llmClient := newLLMClient(cfg)
 
 	return &AnalysisHandler{
 		llmClient:            llmClient,
@@ -630,35 +665,29 @@ func (h *AnalysisHandler) AnalyzeCompetitor(c *gin.Context) {
 		content = content[:50000]
 	}
 
+	ctx, cancel := stageContext(c.Request.Context(), config.AppConfig.AnalysisTimeout)
+	defer cancel()
+
 	// 提取产品信息
-	productInfo, err := h.productInfoExtractor.Extract(content)
+	productInfo, err := h.productInfoExtractor.Extract(ctx, content)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "产品信息提取失败: " + err.Error()})
 		return
 	}
 
 	// SWOT分析
-	swotAnalysis, err := h.swotAnalyzer.Analyze(competitor.Name, productInfo, req.MarketContext)
+	swotAnalysis, err := h.swotAnalyzer.Analyze(ctx, competitor.Name, productInfo, req.MarketContext)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "SWOT分析失败: " + err.Error()})
 		return
 	}
 
-	// 保存分析结果
-	productInfoJSON, _ := json.Marshal(productInfo)
-	swotJSON, _ := json.Marshal(swotAnalysis)
-
-	parsedData := &models.ParsedData{
-		RawContentID: rawContents[0].ID,
-		DataType:     "product_info",
-		ExtractedData: models.JSONB{
-			"product_info":  string(productInfoJSON),
-			"swot_analysis": string(swotJSON),
-		},
-		Confidence: 0.8,
-		ParsedAt:   time.Now(),
+	// 保存分析结果，并记录与上次分析相比的变化
+	recordAnalysisOutcome(dataSources, rawContents, productInfo)
+	if _, err := storeProductAnalysis(competitor.ID, rawContents[0].ID, productInfo, swotAnalysis); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
-	db.Create(parsedData)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success":       true,
@@ -675,8 +704,7 @@ type ReportHandler struct {
 
 // NewReportHandler 创建报告处理器
 func NewReportHandler() *ReportHandler {
-	cfg := config.AppConfig
-	llmClient := ai.NewLLMClient(cfg.OpenAIAPIKey, cfg.LLMModel, cfg.LLMTemperature, cfg.LLMMaxTokens, cfg.LLMBaseURL)
+	llmClient := newLLMClient(config.AppConfig)
 
 	return &ReportHandler{
 		reportGenerator: report.NewReportGenerator(llmClient),
@@ -774,13 +802,10 @@ func (h *ReportHandler) GenerateReport(c *gin.Context) {
 	}
 
 	// 保存到数据库
-	competitorIDs := req.CompetitorIDs
-	competitorIDsJSON, _ := json.Marshal(competitorIDs)
-
 	analysisReport := &models.AnalysisReport{
 		ReportName:  reportName,
 		ReportType:  "competitive_analysis",
-		Competitors: models.JSONB{"ids": competitorIDsJSON},
+		Competitors: models.JSONB{"ids": req.CompetitorIDs},
 		ReportPath:  reportPath,
 		CreatedAt:   time.Now(),
 	}
@@ -815,13 +840,17 @@ func NewAutomationHandler() *AutomationHandler {
 
 // AutoAnalysisRequest 自动分析请求
 type AutoAnalysisRequest struct {
-	Topic           string `json:"topic" binding:"required"`
-	Market          string `json:"market"`
+	Topic           string   `json:"topic"`
+	Seeds           []string `json:"seeds"` // 种子产品名称或网址，提供时查找它们的替代品
+	Market          string   `json:"market"`
 	CompetitorCount int    `json:"competitor_count"`
 	Depth           string `json:"depth"`
 	AutoCrawl       bool   `json:"auto_crawl"`      // 是否自动爬取，默认true
 	AutoAnalyze     bool   `json:"auto_analyze"`    // 是否自动分析，默认true
 	GenerateReport  bool   `json:"generate_report"` // 是否生成报告，默认true
+	ForceRefresh    bool   `json:"force_refresh"`   // 忽略搜索缓存，重新搜索
+
+	NotifyChannels []notify.ChannelConfig `json:"notify_channels"` // 任务结束时的通知渠道
 }
 
 // AutoAnalysis 全流程自动化分析
@@ -831,14 +860,43 @@ func (h *AutomationHandler) AutoAnalysis(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := validateChannels(req.NotifyChannels); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	seeds, err := normalizeSeeds(req.Seeds)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Seeds = seeds
+	req.Topic = strings.TrimSpace(req.Topic)
+	if req.Topic == "" && len(req.Seeds) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "topic 和 seeds 至少提供一个"})
+		return
+	}
+	topic := req.Topic
+	if topic == "" {
+		topic = seedTopic(req.Seeds)
+	}
 
 	// 设置默认值
 	if req.Depth == "" {
-		req.Depth = "standard"
+		req.Depth = discovery.DepthStandard
 	}
 	if req.CompetitorCount == 0 {
 		req.CompetitorCount = 5
 	}
+	plan, err := discovery.PlanForDepth(req.Depth)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	searchPlan, err := jobs.EncodePayload(plan)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	// 修复：如果用户没有显式设置bool参数，默认启用所有功能
 	// Go的JSON unmarshal会将未设置的bool字段设为false
@@ -853,139 +911,289 @@ func (h *AutomationHandler) AutoAnalysis(c *gin.Context) {
 	// 创建自动化任务记录
 	db := database.DB
 	task := &models.DiscoveryTask{
-		Topic:       req.Topic,
+		Topic:       topic,
 		Market:      req.Market,
 		TargetCount: req.CompetitorCount,
 		SearchDepth: req.Depth,
+		SearchPlan:  searchPlan,
 		Status:      "processing",
 		Progress:    0,
 		CreatedAt:   time.Now(),
 	}
-	db.Create(task)
+	if err := db.Create(task).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建任务失败"})
+		return
+	}
 
-	// 异步执行全流程
-	go h.executeAutoWorkflow(task.ID, req)
+	// 加入任务队列异步执行，服务重启后从最后完成的阶段继续
+	job, err := jobQueue.Enqueue(jobAutoAnalysis, autoWorkflowState{TaskID: task.ID, Request: req}, &task.ID)
+	if err != nil {
+		failTask(task, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建任务失败"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success":        true,
 		"task_id":        task.ID,
+		"job_id":         job.ID,
 		"status":         "processing",
 		"workflow":       "discovery -> crawl -> analysis -> report",
 		"estimated_time": 12000, // 预计10分钟
 	})
 }
 
-// executeAutoWorkflow 执行自动化工作流
-func (h *AutomationHandler) executeAutoWorkflow(taskID uint, req AutoAnalysisRequest) {
-	db := database.DB
-
-	var task models.DiscoveryTask
-	db.First(&task, taskID)
+// 自动化工作流的阶段（按执行顺序）
+const (
+	stageDiscovered   = "discovered"
+	stageSourcesFound = "sources_found"
+	stageCrawled      = "crawled"
+	stageAnalyzed     = "analyzed"
+)
 
-	log.Printf("[自动化] 开始执行任务 #%d: %s", taskID, req.Topic)
+var workflowStages = []string{stageDiscovered, stageSourcesFound, stageCrawled, stageAnalyzed}
+
+// autoWorkflowState 自动化工作流的断点状态，保存在任务队列的Payload中
+type autoWorkflowState struct {
+	TaskID      uint                               `json:"task_id"`
+	Request     AutoAnalysisRequest                `json:"request"`
+	Stage       string                             `json:"stage"` // 最后完成的阶段
+	Competitors []string                           `json:"competitors"`
+	Extraction  *competitorExtraction              `json:"extraction"`
+	URLs        []URLItem                          `json:"urls"`
+	Websites    map[string]*discovery.OfficialSite `json:"websites"` // 官网识别结果
+	CrawlJobID  uint                               `json:"crawl_job_id"`
+	AnalyzedIDs []uint                             `json:"analyzed_ids"`
+}
 
-	// 步骤1: 发现竞品
-	task.Status = "discovering"
-	task.Progress = 10
-	db.Save(&task)
+// done 判断阶段是否已经完成
+func (s *autoWorkflowState) done(stage string) bool {
+	if s.Stage == "" {
+		return false
+	}
+	for _, st := range workflowStages {
+		if st == stage {
+			return true
+		}
+		if st == s.Stage {
+			return false
+		}
+	}
+	return false
+}
 
-	searchResults, err := h.discoveryHandler.searchManager.SearchCompetitors(req.Topic, 10)
+// executeAutoWorkflow 执行自动化工作流，已完成的阶段直接跳过
+func (h *AutomationHandler) executeAutoWorkflow(ctx context.Context, task *models.DiscoveryTask, state *autoWorkflowState, checkpoint func(stage string)) error {
+	cfg := config.AppConfig
+	req := state.Request
+	if req.ForceRefresh {
+		ctx = discovery.WithForceRefresh(ctx)
+	}
+	ctx = h.discoveryHandler.searchManager.WithMarket(ctx, req.Market)
+	plan, err := discovery.PlanForDepth(req.Depth)
 	if err != nil {
-		task.Status = "failed"
-		task.ResultData = models.JSONB{"error": "发现失败: " + err.Error()}
-		db.Save(&task)
-		return
+		return err
 	}
 
-	// 提取竞品名称（简化处理）
-	competitorNames := extractCompetitorNamesFromResults(searchResults, req.CompetitorCount)
+	log.Printf("[自动化] 开始执行任务 #%d: %s（搜索深度 %s）", task.ID, req.Topic, plan.Depth)
 
-	task.Progress = 30
-	task.CompetitorsFound = len(competitorNames)
-	db.Save(&task)
+	// 步骤1: 发现竞品
+	if !state.done(stageDiscovered) {
+		task.Status = "discovering"
+		task.Progress = 10
+		saveTask(task)
+
+		discoverCtx, cancelDiscover := stageContext(ctx, cfg.DiscoveryTimeout)
+		var extraction *competitorExtraction
+		if len(req.Seeds) > 0 {
+			// 提供了种子产品：查找种子的替代品
+			extraction, err = h.discoveryHandler.discoverAlternatives(discoverCtx, req.Seeds, req.Topic, plan, req.CompetitorCount)
+			cancelDiscover()
+			if err != nil {
+				return fmt.Errorf("查找替代品失败: %w", err)
+			}
+		} else {
+			searchResults, err := h.discoveryHandler.searchManager.SearchCompetitors(discoverCtx, req.Topic, plan)
+			if err != nil {
+				cancelDiscover()
+				return fmt.Errorf("发现失败: %w", err)
+			}
+
+			// 抓取对比/盘点类文章，用LLM提取竞品
+			extraction, err = h.discoveryHandler.extractCompetitors(discoverCtx, searchResults, req.Topic, plan, req.CompetitorCount)
+			cancelDiscover()
+			if err != nil {
+				return fmt.Errorf("提取竞品失败: %w", err)
+			}
+		}
+		state.Competitors = extraction.Names()
+		state.Extraction = extraction
+
+		// 只提供种子时，后续报告使用推断出的品类作为主题
+		if state.Request.Topic == "" {
+			state.Request.Topic = extraction.Category
+			if state.Request.Topic == "" {
+				state.Request.Topic = seedTopic(req.Seeds)
+			}
+			req.Topic = state.Request.Topic
+		}
+		checkpoint(stageDiscovered)
+
+		task.Progress = 30
+		task.CompetitorsFound = len(state.Competitors)
+		saveTask(task)
 
-	log.Printf("[自动化] 发现竞品: %v", competitorNames)
+		log.Printf("[自动化] 发现竞品: %v", state.Competitors)
+	}
 
 	// 步骤2: 搜索数据源
-	task.Status = "searching_sources"
-	task.Progress = 40
-	db.Save(&task)
+	if !state.done(stageSourcesFound) {
+		task.Status = "searching_sources"
+		task.Progress = 40
+		saveTask(task)
+
+		discoverCtx, cancelDiscover := stageContext(ctx, cfg.DiscoveryTimeout)
+		defer cancelDiscover()
+
+		allURLs := []URLItem{}
+		state.Websites = make(map[string]*discovery.OfficialSite)
+		for _, competitorName := range state.Competitors {
+			website := h.discoveryHandler.findOfficialSite(discoverCtx, competitorName)
+			if website != nil {
+				state.Websites[competitorName] = website
+			}
 
-	allURLs := []URLItem{}
-	for _, competitorName := range competitorNames {
-		sources, _ := h.discoveryHandler.searchManager.SearchDataSources(competitorName, []string{"官网", "产品功能"}, 3)
-
-		for _, results := range sources {
-			processed := discovery.ProcessSearchResults(results)
-			for _, source := range processed {
-				if len(allURLs) < req.CompetitorCount*3 { // 每个竞品最多3个URL
-					allURLs = append(allURLs, URLItem{
-						URL:        source.URL,
-						Competitor: competitorName,
-						SourceType: source.Type,
-					})
+			sources, _ := h.discoveryHandler.searchManager.SearchDataSources(discoverCtx, competitorName, verifiedDomain(website), []string{"官网", "产品功能"}, plan.SourcesPerType)
+			if discoverCtx.Err() != nil {
+				return fmt.Errorf("搜索数据源失败: %w", discoverCtx.Err())
+			}
+
+			// 已验证的官网首页优先爬取，每个竞品最多爬取plan.CrawlBudget个URL
+			added := make(map[string]bool)
+			if website != nil && website.Verified && len(added) < plan.CrawlBudget {
+				allURLs = append(allURLs, URLItem{URL: website.URL, Competitor: competitorName, SourceType: "官网首页"})
+				added[website.URL] = true
+			}
+
+			for _, results := range sources {
+				processed := h.discoveryHandler.processSearchResults(results)
+				for _, source := range processed {
+					if added[source.URL] {
+						continue
+					}
+					if len(added) < plan.CrawlBudget {
+						added[source.URL] = true
+						allURLs = append(allURLs, URLItem{
+							URL:        source.URL,
+							Competitor: competitorName,
+							SourceType: source.Type,
+						})
+					}
 				}
 			}
 		}
+		cancelDiscover()
+
+		state.URLs = allURLs
+		checkpoint(stageSourcesFound)
+
+		task.Progress = 50
+		task.SourcesFound = len(allURLs)
+		saveTask(task)
+
+		log.Printf("[自动化] 找到数据源: %d 个", len(allURLs))
 	}
 
-	task.Progress = 50
-	task.SourcesFound = len(allURLs)
-	db.Save(&task)
+	// 步骤3: 批量爬取（如果启用），阶段超时后使用已爬取的内容继续
+	if !state.done(stageCrawled) {
+		if req.AutoCrawl && len(state.URLs) > 0 {
+			task.Status = "crawling"
+			task.Progress = 60
+			saveTask(task)
 
-	log.Printf("[自动化] 找到数据源: %d 个", len(allURLs))
+			// 断点续跑时复用同一个爬取任务，只爬取未成功的URL
+			if state.CrawlJobID == 0 {
+				crawlJob, err := createCrawlJob(state.URLs, defaultCrawlConcurrency(), &task.ID)
+				if err != nil {
+					return err
+				}
+				state.CrawlJobID = crawlJob.ID
+				checkpoint(stageSourcesFound)
+			}
 
-	// 步骤3: 批量爬取（如果启用）
-	if req.AutoCrawl && len(allURLs) > 0 {
-		task.Status = "crawling"
-		task.Progress = 60
-		db.Save(&task)
+			log.Printf("[自动化] 开始爬取 %d 个URL（爬取任务 #%d）", len(state.URLs), state.CrawlJobID)
+			crawlCtx, cancelCrawl := stageContext(ctx, cfg.CrawlTimeout)
+			h.crawlHandler.executeBatchCrawl(crawlCtx, state.CrawlJobID)
+			cancelCrawl()
 
-		log.Printf("[自动化] 开始爬取 %d 个URL", len(allURLs))
-		h.crawlHandler.executeBatchCrawl(allURLs, 1) // 使用并发1，避免403错误
+			if ctx.Err() != nil {
+				return fmt.Errorf("爬取失败: %w", ctx.Err())
+			}
 
-		task.Progress = 75
-		db.Save(&task)
+			task.Progress = 75
+			saveTask(task)
+		}
+		checkpoint(stageCrawled)
+	}
+
+	// 爬取时才会创建竞品记录，此时保存已验证的官网
+	for name, website := range state.Websites {
+		if competitor, err := competitorResolver().Find(name); err == nil {
+			saveCompetitorWebsite(competitor, website)
+		}
 	}
 
 	// 步骤4: AI分析（如果启用）
-	var analyzedCompetitorIDs []uint
-	if req.AutoAnalyze {
-		task.Status = "analyzing"
-		task.Progress = 80
-		db.Save(&task)
-
-		log.Println("[自动化] 开始AI分析")
-
-		for _, name := range competitorNames {
-			var competitor models.Competitor
-			if err := db.Where("name = ?", name).First(&competitor).Error; err != nil {
-				continue
+	if !state.done(stageAnalyzed) {
+		if req.AutoAnalyze {
+			task.Status = "analyzing"
+			task.Progress = 80
+			saveTask(task)
+
+			log.Println("[自动化] 开始AI分析")
+
+			analyzeCtx, cancelAnalyze := stageContext(ctx, cfg.AnalysisTimeout)
+			for _, name := range state.Competitors {
+				if analyzeCtx.Err() != nil {
+					break
+				}
+
+				competitor, err := competitorResolver().Find(name)
+				if err != nil || competitor == nil {
+					continue
+				}
+
+				// 执行分析
+				if err := h.analyzeCompetitorByID(analyzeCtx, competitor.ID, req.Market); err != nil {
+					log.Printf("[自动化] 分析失败 %s: %v", name, err)
+					continue
+				}
+
+				state.AnalyzedIDs = append(state.AnalyzedIDs, competitor.ID)
 			}
+			cancelAnalyze()
 
-			// 执行分析
-			if err := h.analyzeCompetitorByID(competitor.ID, req.Market); err != nil {
-				log.Printf("[自动化] 分析失败 %s: %v", name, err)
-				continue
+			if ctx.Err() != nil {
+				return fmt.Errorf("分析失败: %w", ctx.Err())
 			}
 
-			analyzedCompetitorIDs = append(analyzedCompetitorIDs, competitor.ID)
+			task.Progress = 90
+			saveTask(task)
 		}
-
-		task.Progress = 90
-		db.Save(&task)
+		checkpoint(stageAnalyzed)
 	}
 
 	// 步骤5: 生成报告（如果启用）
 	var reportPath string
-	if req.GenerateReport && len(analyzedCompetitorIDs) > 0 {
+	if req.GenerateReport && len(state.AnalyzedIDs) > 0 {
 		task.Status = "generating_report"
 		task.Progress = 95
-		db.Save(&task)
+		saveTask(task)
 
 		log.Println("[自动化] 生成报告")
 
-		reportPath, err = h.generateReportForCompetitors(analyzedCompetitorIDs, req.Topic)
+		var err error
+		reportPath, err = h.generateReportForCompetitors(state.AnalyzedIDs, req.Topic)
 		if err != nil {
 			log.Printf("[自动化] 报告生成失败: %v", err)
 		}
@@ -997,14 +1205,24 @@ func (h *AutomationHandler) executeAutoWorkflow(taskID uint, req AutoAnalysisReq
 	now := time.Now()
 	task.CompletedAt = &now
 	task.ResultData = models.JSONB{
-		"competitors":    competitorNames,
-		"urls_crawled":   len(allURLs),
-		"analyzed_count": len(analyzedCompetitorIDs),
+		"competitors":    state.Competitors,
+		"urls_crawled":   len(state.URLs),
+		"analyzed_count": len(state.AnalyzedIDs),
 		"report_path":    reportPath,
 	}
-	db.Save(&task)
+	if state.Extraction != nil {
+		task.ResultData["competitor_details"] = state.Extraction.Competitors
+		task.ResultData["extraction_method"] = state.Extraction.Method
+		task.ResultData["articles"] = state.Extraction.Articles
+		task.ResultData["snowball_articles"] = state.Extraction.SnowballArticles
+		task.ResultData["seeds"] = state.Extraction.Seeds
+		task.ResultData["category"] = state.Extraction.Category
+	}
+	task.ResultData["search_plan"] = plan
+	saveTask(task)
 
-	log.Printf("[自动化] 任务完成 #%d", taskID)
+	log.Printf("[自动化] 任务完成 #%d", task.ID)
+	return nil
 }
 
 // extractCompetitorNamesFromResults 从搜索结果提取竞品名称
@@ -1060,7 +1278,7 @@ func extractBrandFromURL(urlStr string) string {
 }
 
 // analyzeCompetitorByID 分析竞品（内部方法）
-func (h *AutomationHandler) analyzeCompetitorByID(competitorID uint, marketContext string) error {
+func (h *AutomationHandler) analyzeCompetitorByID(ctx context.Context, competitorID uint, marketContext string) error {
 	db := database.DB
 
 	var competitor models.Competitor
@@ -1099,34 +1317,21 @@ func (h *AutomationHandler) analyzeCompetitorByID(competitorID uint, marketConte
 	}
 
 	// 提取产品信息
-	productInfo, err := h.analysisHandler.productInfoExtractor.Extract(content)
+	productInfo, err := h.analysisHandler.productInfoExtractor.Extract(ctx, content)
 	if err != nil {
 		return err
 	}
 
 	// SWOT分析
-	swotAnalysis, err := h.analysisHandler.swotAnalyzer.Analyze(competitor.Name, productInfo, marketContext)
+	swotAnalysis, err := h.analysisHandler.swotAnalyzer.Analyze(ctx, competitor.Name, productInfo, marketContext)
 	if err != nil {
 		return err
 	}
 
-	// 保存分析结果
-	productInfoJSON, _ := json.Marshal(productInfo)
-	swotJSON, _ := json.Marshal(swotAnalysis)
-
-	parsedData := &models.ParsedData{
-		RawContentID: rawContents[0].ID,
-		DataType:     "product_info",
-		ExtractedData: models.JSONB{
-			"product_info":  string(productInfoJSON),
-			"swot_analysis": string(swotJSON),
-		},
-		Confidence: 0.8,
-		ParsedAt:   time.Now(),
-	}
-	db.Create(parsedData)
-
-	return nil
+	// 保存分析结果，并记录与上次分析相比的变化
+	recordAnalysisOutcome(dataSources, rawContents, productInfo)
+	_, err = storeProductAnalysis(competitor.ID, rawContents[0].ID, productInfo, swotAnalysis)
+	return err
 }
 
 // generateReportForCompetitors 为竞品生成报告（内部方法）
@@ -1186,11 +1391,10 @@ func (h *AutomationHandler) generateReportForCompetitors(competitorIDs []uint, t
 	}
 
 	// 保存到数据库
-	competitorIDsJSON, _ := json.Marshal(competitorIDs)
 	analysisReport := &models.AnalysisReport{
 		ReportName:  reportName,
 		ReportType:  "auto_competitive_analysis",
-		Competitors: models.JSONB{"ids": competitorIDsJSON},
+		Competitors: models.JSONB{"ids": competitorIDs},
 		ReportPath:  reportPath,
 		CreatedAt:   time.Now(),
 	}
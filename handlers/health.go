@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"competitive-analyzer/breaker"
+	"competitive-analyzer/config"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	providerBreakersOnce sync.Once
+	providerBreakerSet   *breaker.Registry
+)
+
+// providerBreakers 外部服务（爬虫API、搜索引擎、LLM）的熔断器，所有处理器共享
+func providerBreakers() *breaker.Registry {
+	providerBreakersOnce.Do(func() {
+		cfg := config.AppConfig
+		providerBreakerSet = breaker.NewRegistry(cfg.CircuitFailureThreshold, time.Duration(cfg.CircuitCooldown)*time.Second)
+	})
+	return providerBreakerSet
+}
+
+// Health 健康检查，列出各外部服务熔断器的状态；有服务熔断时status为degraded
+func Health(c *gin.Context) {
+	providers := providerBreakers().Statuses()
+
+	status := "ok"
+	for _, provider := range providers {
+		if provider.State != breaker.StateClosed {
+			status = "degraded"
+			break
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    status,
+		"version":   "1.0.0",
+		"providers": providers,
+	})
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"competitive-analyzer/config"
+	"competitive-analyzer/database"
+	"competitive-analyzer/jobs"
+	"competitive-analyzer/models"
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// 任务类型
+const (
+	jobDiscoverySearch = "discovery_search"
+	jobCrawlBatch      = "crawl_batch"
+	jobAutoAnalysis    = "auto_analysis"
+	jobNotify          = "notify"
+)
+
+// jobQueue 后台任务队列，由RegisterJobHandlers设置
+var jobQueue *jobs.Queue
+
+// searchJobPayload 发现任务参数
+type searchJobPayload struct {
+	TaskID  uint          `json:"task_id"`
+	Request SearchRequest `json:"request"`
+}
+
+// crawlBatchPayload 批量爬取任务参数
+type crawlBatchPayload struct {
+	CrawlJobID uint `json:"crawl_job_id"`
+}
+
+// RegisterJobHandlers 注册各类后台任务的处理函数
+func RegisterJobHandlers(queue *jobs.Queue) {
+	jobQueue = queue
+
+	discoveryHandler := NewDiscoveryHandler()
+	crawlHandler := NewCrawlHandler()
+	automationHandler := NewAutomationHandler()
+
+	queue.Register(jobDiscoverySearch, discoveryHandler.runSearchJob)
+	queue.Register(jobCrawlBatch, crawlHandler.runBatchCrawlJob)
+	queue.Register(jobAutoAnalysis, automationHandler.runAutoJob)
+	queue.Register(jobNotify, runNotifyJob)
+}
+
+// RecoverTasks 将没有待执行任务的未完成发现任务标记为失败（服务启动时调用）
+func RecoverTasks() {
+	db := database.DB
+
+	activeTaskIDs := db.Model(&models.Job{}).
+		Select("task_id").
+		Where("task_id IS NOT NULL AND status IN ?", []string{"pending", "running"})
+
+	result := db.Model(&models.DiscoveryTask{}).
+		Where("status NOT IN ?", []string{"completed", "failed", "cancelled"}).
+		Where("id NOT IN (?)", activeTaskIDs).
+		Updates(map[string]interface{}{
+			"status":       "failed",
+			"result_data":  models.JSONB{"error": "服务重启，任务中断"},
+			"completed_at": time.Now(),
+		})
+
+	if result.Error != nil {
+		log.Printf("[任务队列] 恢复中断任务失败: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("[任务队列] %d 个中断的任务已标记为失败", result.RowsAffected)
+	}
+}
+
+// finishJob 处理任务执行结果：最后一次尝试失败时标记发现任务失败，否则等待重试
+func finishJob(job *models.Job, task *models.DiscoveryTask, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if jobs.IsFinalAttempt(job, err) || errors.Is(err, context.Canceled) {
+		failTask(task, err)
+		return err
+	}
+
+	log.Printf("[任务队列] 任务 #%d 第%d次执行失败，稍后重试: %v", task.ID, job.Attempts, err)
+	task.ResultData = models.JSONB{
+		"error":    err.Error(),
+		"attempts": job.Attempts,
+		"retrying": true,
+	}
+	saveTask(task)
+	return err
+}
+
+// runSearchJob 执行发现任务
+func (h *DiscoveryHandler) runSearchJob(ctx context.Context, job *models.Job) error {
+	var payload searchJobPayload
+	if err := jobs.DecodePayload(job, &payload); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	var task models.DiscoveryTask
+	if err := database.DB.First(&task, payload.TaskID).Error; err != nil {
+		return jobs.Permanent(err)
+	}
+	if isTaskFinished(task.Status) {
+		return nil
+	}
+
+	ctx, done := runningTasks.attach(ctx, task.ID)
+	defer done()
+
+	err := finishJob(job, &task, h.executeSearch(ctx, &task, payload.Request))
+	notifyTaskFinished(task.ID, payload.Request.NotifyChannels)
+	return err
+}
+
+// runBatchCrawlJob 执行批量爬取任务
+func (h *CrawlHandler) runBatchCrawlJob(ctx context.Context, job *models.Job) error {
+	var payload crawlBatchPayload
+	if err := jobs.DecodePayload(job, &payload); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	ctx, cancel := stageContext(ctx, config.AppConfig.CrawlTimeout)
+	defer cancel()
+
+	err := h.executeBatchCrawl(ctx, payload.CrawlJobID)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil // 超时未完成的URL可通过重试接口继续
+	}
+	return err
+}
+
+// runAutoJob 执行全流程自动化任务，从上次完成的阶段继续
+func (h *AutomationHandler) runAutoJob(ctx context.Context, job *models.Job) error {
+	var state autoWorkflowState
+	if err := jobs.DecodePayload(job, &state); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	var task models.DiscoveryTask
+	if err := database.DB.First(&task, state.TaskID).Error; err != nil {
+		return jobs.Permanent(err)
+	}
+	if isTaskFinished(task.Status) {
+		return nil
+	}
+
+	if state.Stage != "" {
+		log.Printf("[自动化] 任务 #%d 从阶段 %s 之后继续执行", task.ID, state.Stage)
+	}
+
+	ctx, done := runningTasks.attach(ctx, task.ID)
+	defer done()
+
+	// 每完成一个阶段保存一次断点
+	checkpoint := func(stage string) {
+		state.Stage = stage
+		payload, err := jobs.EncodePayload(state)
+		if err != nil {
+			return
+		}
+		job.Payload = payload
+		if err := jobQueue.SaveProgress(job); err != nil {
+			log.Printf("[自动化] 保存断点失败 #%d: %v", task.ID, err)
+		}
+	}
+
+	err := finishJob(job, &task, h.executeAutoWorkflow(ctx, &task, &state, checkpoint))
+	notifyTaskFinished(task.ID, state.Request.NotifyChannels)
+	return err
+}
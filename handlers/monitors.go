@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"competitive-analyzer/alerts"
+	"competitive-analyzer/database"
+	"competitive-analyzer/jobs"
+	"competitive-analyzer/models"
+	"competitive-analyzer/monitor"
+	"competitive-analyzer/notify"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// monitorScheduler 监控任务调度器，由RegisterMonitorScheduler设置
+var monitorScheduler *monitor.Scheduler
+
+// RegisterMonitorScheduler 设置监控任务的执行函数
+func RegisterMonitorScheduler(scheduler *monitor.Scheduler) {
+	monitorScheduler = scheduler
+	scheduler.SetRunFunc(NewCrawlHandler().runMonitorTask)
+}
+
+// runMonitorTask 为监控的竞品创建批量爬取任务，重新爬取所有有效数据源
+func (h *CrawlHandler) runMonitorTask(ctx context.Context, task *models.MonitorTask) error {
+	competitorIDs := monitorCompetitorIDs(task)
+	if len(competitorIDs) == 0 {
+		return fmt.Errorf("监控任务没有关联竞品")
+	}
+
+	var dataSources []models.DataSource
+	database.DB.Preload("Competitor").
+		Where("competitor_id IN ? AND status = ?", competitorIDs, "active").
+		Order("competitor_id, priority DESC, id").
+		Find(&dataSources)
+	if len(dataSources) == 0 {
+		return fmt.Errorf("监控的竞品没有有效的数据源")
+	}
+
+	urls := make([]URLItem, 0, len(dataSources))
+	for _, ds := range dataSources {
+		urls = append(urls, URLItem{
+			URL:        ds.URL,
+			Competitor: ds.Competitor.Name,
+			SourceType: ds.SourceType,
+		})
+	}
+
+	crawlJob, err := createCrawlJob(urls, 1, nil)
+	if err != nil {
+		return err
+	}
+	database.DB.Model(crawlJob).Update("monitor_task_id", task.ID)
+	task.LastCrawlJobID = &crawlJob.ID
+
+	if _, err := jobQueue.Enqueue(jobCrawlBatch, crawlBatchPayload{CrawlJobID: crawlJob.ID}, nil); err != nil {
+		return fmt.Errorf("创建任务失败: %w", err)
+	}
+	return nil
+}
+
+// monitorCompetitorIDs 解析监控任务关联的竞品ID（存储格式 {"ids": [1, 2]}）
+func monitorCompetitorIDs(task *models.MonitorTask) []uint {
+	raw, _ := task.CompetitorIDs["ids"].([]interface{})
+
+	ids := make([]uint, 0, len(raw))
+	for _, v := range raw {
+		switch id := v.(type) {
+		case float64: // 从数据库读取
+			if id > 0 {
+				ids = append(ids, uint(id))
+			}
+		case uint: // 请求中刚设置
+			if id > 0 {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// MonitorHandler 监控任务处理器
+type MonitorHandler struct{}
+
+// NewMonitorHandler 创建监控任务处理器
+func NewMonitorHandler() *MonitorHandler {
+	return &MonitorHandler{}
+}
+
+// MonitorRequest 创建/更新监控任务请求
+type MonitorRequest struct {
+	Name          *string      `json:"name"`
+	CompetitorIDs []uint       `json:"competitor_ids"`
+	Frequency     *string      `json:"frequency"`   // daily/weekly/monthly 或 cron表达式（如 "0 9 * * 1"）
+	AlertRules    models.JSONB `json:"alert_rules"` // {"rules": [...]}，见alerts.Rule
+	Status        *string      `json:"status"`      // active/paused
+
+	NotifyChannels []notify.ChannelConfig `json:"notify_channels"` // 告警通知渠道，见notify.ChannelConfig
+}
+
+// apply 将请求中提供的字段写入监控任务，频率或状态变化时重新计算下一次执行时间
+func (req *MonitorRequest) apply(task *models.MonitorTask) error {
+	reschedule := false
+
+	if req.Name != nil {
+		task.Name = *req.Name
+	}
+	if req.CompetitorIDs != nil {
+		ids := make([]interface{}, len(req.CompetitorIDs))
+		for i, id := range req.CompetitorIDs {
+			ids[i] = id
+		}
+		task.CompetitorIDs = models.JSONB{"ids": ids}
+	}
+	if req.Frequency != nil {
+		task.Frequency = *req.Frequency
+		reschedule = true
+	}
+	if req.AlertRules != nil {
+		rules, err := alerts.ParseRules(req.AlertRules)
+		if err != nil {
+			return err
+		}
+		task.AlertRules = alerts.EncodeRules(rules)
+	}
+	if req.NotifyChannels != nil {
+		notify.RestoreRedacted(req.NotifyChannels, monitorChannels(task))
+		if err := validateChannels(req.NotifyChannels); err != nil {
+			return err
+		}
+		channels, err := jobs.EncodePayload(map[string]interface{}{"channels": req.NotifyChannels})
+		if err != nil {
+			return err
+		}
+		task.NotifyChannels = channels
+	}
+	if req.Status != nil {
+		if *req.Status != "active" && *req.Status != "paused" {
+			return fmt.Errorf("无效的状态: %s（可选 active/paused）", *req.Status)
+		}
+		reschedule = reschedule || (*req.Status == "active" && task.Status != "active")
+		task.Status = *req.Status
+	}
+
+	if task.Name == "" {
+		return fmt.Errorf("监控任务名称不能为空")
+	}
+	if len(monitorCompetitorIDs(task)) == 0 {
+		return fmt.Errorf("至少需要关联一个竞品")
+	}
+
+	if reschedule || task.NextRunTime.IsZero() {
+		next, err := monitor.NextRunTime(task.Frequency, time.Now())
+		if err != nil {
+			return err
+		}
+		task.NextRunTime = next
+	}
+	return nil
+}
+
+// redactMonitor 返回给客户端的监控任务：通知渠道的签名密钥、请求头值和地址中的令牌已隐去
+func redactMonitor(task models.MonitorTask) models.MonitorTask {
+	if task.NotifyChannels == nil {
+		return task
+	}
+	channels := monitorChannels(&task)
+	redacted := make([]notify.ChannelConfig, len(channels))
+	for i, channel := range channels {
+		redacted[i] = channel.Redacted()
+	}
+	task.NotifyChannels = models.JSONB{"channels": redacted}
+	return task
+}
+
+// CreateMonitor 创建监控任务
+func (h *MonitorHandler) CreateMonitor(c *gin.Context) {
+	var req MonitorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task := &models.MonitorTask{Status: "active", Frequency: "daily"}
+	if err := req.apply(task); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.DB.Create(task).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建监控任务失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"monitor": redactMonitor(*task),
+	})
+}
+
+// ListMonitors 获取监控任务列表
+func (h *MonitorHandler) ListMonitors(c *gin.Context) {
+	var tasks []models.MonitorTask
+
+	query := database.DB.Order("id")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Find(&tasks)
+
+	for i := range tasks {
+		tasks[i] = redactMonitor(tasks[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"monitors": tasks,
+	})
+}
+
+// GetMonitor 获取监控任务详情
+func (h *MonitorHandler) GetMonitor(c *gin.Context) {
+	var task models.MonitorTask
+	if err := database.DB.First(&task, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "监控任务不存在"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"monitor": redactMonitor(task),
+	})
+}
+
+// UpdateMonitor 更新监控任务（只修改请求中提供的字段）
+func (h *MonitorHandler) UpdateMonitor(c *gin.Context) {
+	db := database.DB
+
+	var task models.MonitorTask
+	if err := db.First(&task, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "监控任务不存在"})
+		return
+	}
+
+	var req MonitorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.apply(&task); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := db.Save(&task).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新监控任务失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"monitor": redactMonitor(task),
+	})
+}
+
+// DeleteMonitor 删除监控任务
+func (h *MonitorHandler) DeleteMonitor(c *gin.Context) {
+	result := database.DB.Delete(&models.MonitorTask{}, c.Param("id"))
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "监控任务不存在"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// RunMonitor 立即执行一次监控任务
+func (h *MonitorHandler) RunMonitor(c *gin.Context) {
+	var task models.MonitorTask
+	if err := database.DB.First(&task, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "监控任务不存在"})
+		return
+	}
+
+	if err := monitorScheduler.Execute(c.Request.Context(), &task); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"monitor_id":   task.ID,
+		"crawl_job_id": task.LastCrawlJobID,
+	})
+}
@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"competitive-analyzer/config"
+	"competitive-analyzer/database"
+	"competitive-analyzer/jobs"
+	"competitive-analyzer/models"
+	"competitive-analyzer/notify"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// notifyJobPayload 通知发送任务参数
+type notifyJobPayload struct {
+	DeliveryID uint `json:"delivery_id"`
+}
+
+// smtpConfig 邮件服务器配置
+func smtpConfig() notify.SMTPConfig {
+	cfg := config.AppConfig
+	return notify.SMTPConfig{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.SMTPFrom,
+	}
+}
+
+// validateChannels 校验请求中的通知渠道配置
+func validateChannels(channels []notify.ChannelConfig) error {
+	for i := range channels {
+		if err := channels[i].Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// monitorChannels 读取监控任务的通知渠道
+func monitorChannels(task *models.MonitorTask) []notify.ChannelConfig {
+	channels, err := notify.ParseChannels(task.NotifyChannels["channels"])
+	if err != nil {
+		log.Printf("[通知] 监控任务 #%d 的通知渠道无效: %v", task.ID, err)
+		return nil
+	}
+	return channels
+}
+
+// queueNotification 为每个渠道渲染消息、记录发送日志并加入任务队列异步发送（失败按队列策略重试）
+func queueNotification(channels []notify.ChannelConfig, event string, data interface{}, delivery models.NotificationDelivery) {
+	dataJSONB, err := jobs.EncodePayload(data)
+	if err != nil {
+		log.Printf("[通知] 序列化通知数据失败: %v", err)
+		return
+	}
+
+	for _, ch := range channels {
+		msg, err := notify.Render(event, ch, data)
+		if err != nil {
+			log.Printf("[通知] %s 渠道渲染消息失败: %v", ch.DisplayName(), err)
+			continue
+		}
+
+		channelJSONB, err := jobs.EncodePayload(ch)
+		if err != nil {
+			continue
+		}
+
+		d := delivery
+		d.Event = event
+		d.ChannelType = ch.Type
+		d.ChannelName = ch.DisplayName()
+		d.Channel = channelJSONB
+		d.Title = msg.Title
+		d.Body = msg.Body
+		d.Data = dataJSONB
+		d.Status = "pending"
+		if err := database.DB.Create(&d).Error; err != nil {
+			log.Printf("[通知] 保存发送记录失败: %v", err)
+			continue
+		}
+
+		// 不关联发现任务，任务被取消时通知仍然发送
+		if _, err := jobQueue.Enqueue(jobNotify, notifyJobPayload{DeliveryID: d.ID}, nil); err != nil {
+			log.Printf("[通知] 创建发送任务失败: %v", err)
+		}
+	}
+}
+
+// notifyAlerts 按监控任务汇总告警，发送到监控任务配置的通知渠道
+func notifyAlerts(events []models.AlertEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	db := database.DB
+
+	byMonitor := make(map[uint][]models.AlertEvent)
+	var monitorIDs []uint
+	for _, event := range events {
+		if _, ok := byMonitor[event.MonitorTaskID]; !ok {
+			monitorIDs = append(monitorIDs, event.MonitorTaskID)
+		}
+		byMonitor[event.MonitorTaskID] = append(byMonitor[event.MonitorTaskID], event)
+	}
+
+	for _, monitorID := range monitorIDs {
+		var task models.MonitorTask
+		if err := db.First(&task, monitorID).Error; err != nil {
+			continue
+		}
+		channels := monitorChannels(&task)
+		if len(channels) == 0 {
+			continue
+		}
+
+		data := notify.AlertData{MonitorID: task.ID, Monitor: task.Name, Time: time.Now()}
+		var changeLogIDs []interface{}
+		seen := make(map[uint]bool)
+
+		for _, event := range byMonitor[monitorID] {
+			var changeLog models.ChangeLog
+			db.Preload("Competitor").First(&changeLog, event.ChangeLogID)
+
+			data.Alerts = append(data.Alerts, notify.AlertItem{
+				Competitor:  changeLog.Competitor.Name,
+				ChangeType:  changeLog.ChangeType,
+				FieldName:   changeLog.FieldName,
+				OldValue:    changeLog.OldValue,
+				NewValue:    changeLog.NewValue,
+				ImpactLevel: changeLog.ImpactLevel,
+				RuleName:    event.RuleName,
+				Message:     event.Message,
+				DetectedAt:  changeLog.DetectedAt,
+			})
+			if !seen[changeLog.ID] {
+				seen[changeLog.ID] = true
+				changeLogIDs = append(changeLogIDs, changeLog.ID)
+			}
+		}
+
+		queueNotification(channels, notify.EventAlert, data, models.NotificationDelivery{
+			MonitorTaskID: &task.ID,
+			ChangeLogIDs:  models.JSONB{"ids": changeLogIDs},
+		})
+	}
+}
+
+// notifyTaskFinished 发现/自动化任务结束（完成、失败或取消）时发送通知
+func notifyTaskFinished(taskID uint, channels []notify.ChannelConfig) {
+	if len(channels) == 0 {
+		return
+	}
+
+	var task models.DiscoveryTask
+	if err := database.DB.First(&task, taskID).Error; err != nil || !isTaskFinished(task.Status) {
+		return
+	}
+
+	data := notify.TaskData{
+		TaskID:           task.ID,
+		Topic:            task.Topic,
+		Status:           task.Status,
+		CompetitorsFound: task.CompetitorsFound,
+		SourcesFound:     task.SourcesFound,
+		Time:             time.Now(),
+	}
+	if names, ok := task.ResultData["competitors"].([]interface{}); ok {
+		for _, name := range names {
+			if s, ok := name.(string); ok {
+				data.Competitors = append(data.Competitors, s)
+			}
+		}
+	}
+	if data.CompetitorsFound == 0 {
+		data.CompetitorsFound = len(data.Competitors)
+	}
+	data.ReportPath, _ = task.ResultData["report_path"].(string)
+	data.Error, _ = task.ResultData["error"].(string)
+
+	queueNotification(channels, notify.EventTaskFinished, data, models.NotificationDelivery{TaskID: &task.ID})
+}
+
+// runNotifyJob 发送一条通知
+func runNotifyJob(ctx context.Context, job *models.Job) error {
+	db := database.DB
+
+	var payload notifyJobPayload
+	if err := jobs.DecodePayload(job, &payload); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	var delivery models.NotificationDelivery
+	if err := db.First(&delivery, payload.DeliveryID).Error; err != nil {
+		return jobs.Permanent(err)
+	}
+	if delivery.Status == "sent" {
+		return nil
+	}
+
+	var cfg notify.ChannelConfig
+	if err := decodeJSONB(delivery.Channel, &cfg); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	channel, err := notify.NewChannel(cfg, smtpConfig())
+	if err == nil {
+		err = channel.Send(ctx, &notify.Message{
+			Event: delivery.Event,
+			Title: delivery.Title,
+			Body:  delivery.Body,
+			Data:  delivery.Data,
+		})
+	} else {
+		err = jobs.Permanent(err)
+	}
+
+	delivery.Attempts++
+	updates := map[string]interface{}{"attempts": delivery.Attempts}
+
+	if err != nil {
+		updates["last_error"] = err.Error()
+		updates["status"] = "retrying"
+		if jobs.IsFinalAttempt(job, err) {
+			updates["status"] = "failed"
+		}
+		db.Model(&delivery).Updates(updates)
+		log.Printf("[通知] %s 发送失败（第%d次）: %v", delivery.ChannelName, delivery.Attempts, err)
+		return err
+	}
+
+	now := time.Now()
+	updates["status"] = "sent"
+	updates["last_error"] = ""
+	updates["sent_at"] = &now
+	db.Model(&delivery).Updates(updates)
+
+	// 告警通知发送成功后标记相关变化已通知
+	if ids, ok := delivery.ChangeLogIDs["ids"].([]interface{}); ok && len(ids) > 0 {
+		db.Model(&models.ChangeLog{}).Where("id IN ?", ids).Update("notified", true)
+	}
+
+	log.Printf("[通知] %s 发送成功: %s", delivery.ChannelName, delivery.Title)
+	return nil
+}
+
+// decodeJSONB 将JSONB解析到结构体
+func decodeJSONB(data models.JSONB, v interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(encoded, v); err != nil {
+		return fmt.Errorf("解析数据失败: %w", err)
+	}
+	return nil
+}
+
+// ListNotifications 获取通知发送记录
+func ListNotifications(c *gin.Context) {
+	db := database.DB
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	query := db.Model(&models.NotificationDelivery{})
+	if monitorID := c.Query("monitor_id"); monitorID != "" {
+		query = query.Where("monitor_task_id = ?", monitorID)
+	}
+	if taskID := c.Query("task_id"); taskID != "" {
+		query = query.Where("task_id = ?", taskID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if event := c.Query("event"); event != "" {
+		query = query.Where("event = ?", event)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var deliveries []models.NotificationDelivery
+	query.Order("id DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&deliveries)
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":         total,
+		"page":          page,
+		"page_size":     pageSize,
+		"notifications": deliveries,
+	})
+}
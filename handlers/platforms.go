@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"competitive-analyzer/config"
+	"competitive-analyzer/crawler"
+	"competitive-analyzer/platforms"
+	"sync"
+)
+
+var (
+	platformRegistryOnce sync.Once
+	sharedPlatforms      *platforms.Registry
+	platformsErr         error
+)
+
+// LoadPlatforms 加载平台配置，启动时调用：PLATFORMS_PATH指定的文件有误时返回错误（不回退到内置配置，以免悄悄忽略配置），
+// 未指定时使用内置配置
+func LoadPlatforms() error {
+	platformRegistryOnce.Do(loadPlatforms)
+	return platformsErr
+}
+
+func loadPlatforms() {
+	if config.AppConfig.Platforms == "" {
+		sharedPlatforms = platforms.Builtin()
+		return
+	}
+	sharedPlatforms, platformsErr = platforms.Load(config.AppConfig.Platforms)
+}
+
+// platformRegistry 平台配置（爬虫、图片下载和链接分类共享）；加载失败时为nil，各方法按内置配置处理
+func platformRegistry() *platforms.Registry {
+	platformRegistryOnce.Do(loadPlatforms)
+	return sharedPlatforms
+}
+
+// newThreeLayerCrawler 创建爬虫：共享主机调度、熔断器和平台配置，配置了会话加密密钥时启用登录会话
+func newThreeLayerCrawler(cfg *config.Config) *crawler.ThreeLayerCrawler {
+	threeLayer := crawler.NewThreeLayerCrawler(cfg.FirecrawlAPIKey, hostScheduler(), providerBreakers(), platformRegistry())
+	if store := sessionStore(); store != nil {
+		threeLayer.UseSessions(store)
+	}
+	return threeLayer
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"competitive-analyzer/config"
+	"competitive-analyzer/database"
+	"competitive-analyzer/discovery"
+	"competitive-analyzer/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// engineCacheStats 单个搜索引擎的缓存统计
+type engineCacheStats struct {
+	SearchEngine string `json:"search_engine"`
+	Entries      int64  `json:"entries"`
+	Hits         int64  `json:"hits"`
+}
+
+// GetSearchCacheStats 获取搜索缓存统计
+func GetSearchCacheStats(c *gin.Context) {
+	db := database.DB
+	now := time.Now()
+
+	var total, active, totalHits int64
+	db.Model(&models.SearchCache{}).Count(&total)
+	db.Model(&models.SearchCache{}).Where("expires_at > ?", now).Count(&active)
+	db.Model(&models.SearchCache{}).Select("COALESCE(SUM(hit_count), 0)").Scan(&totalHits)
+
+	byEngine := []engineCacheStats{}
+	db.Model(&models.SearchCache{}).
+		Select("search_engine, COUNT(*) AS entries, COALESCE(SUM(hit_count), 0) AS hits").
+		Group("search_engine").
+		Order("entries DESC").
+		Scan(&byEngine)
+
+	runtime := discovery.GetCacheStats()
+	hitRate := 0.0
+	if lookups := runtime.Hits + runtime.Misses; lookups > 0 {
+		hitRate = float64(runtime.Hits) / float64(lookups)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":         config.AppConfig.SearchCacheDays > 0,
+		"cache_days":      config.AppConfig.SearchCacheDays,
+		"total_entries":   total,
+		"active_entries":  active,
+		"expired_entries": total - active,
+		"total_hits":      totalHits,
+		"by_engine":       byEngine,
+		"runtime":         runtime, // 本次运行以来
+		"hit_rate":        hitRate,
+	})
+}
+
+// PurgeSearchCache 清除搜索缓存，可按过期状态、搜索引擎和查询过滤
+func PurgeSearchCache(c *gin.Context) {
+	query := database.DB.Where("1 = 1")
+
+	if c.Query("expired") == "true" {
+		query = query.Where("expires_at <= ?", time.Now())
+	}
+	if engine := c.Query("engine"); engine != "" {
+		query = query.Where("search_engine = ?", engine)
+	}
+	if q := c.Query("query"); q != "" {
+		query = query.Where("query = ?", q)
+	}
+
+	result := query.Delete(&models.SearchCache{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"deleted": result.RowsAffected,
+	})
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"competitive-analyzer/ai"
+	"competitive-analyzer/discovery"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// 每次最多提供的种子产品数
+const maxSeeds = 5
+
+// seedProduct 种子产品的识别结果
+type seedProduct struct {
+	Input    string   `json:"input"` // 用户提供的名称或网址
+	Name     string   `json:"name"`
+	URL      string   `json:"url,omitempty"`      // 抓取的官网
+	Category string   `json:"category,omitempty"` // 从官网内容推断的品类
+	Keywords []string `json:"keywords,omitempty"`
+	Summary  string   `json:"summary,omitempty"`
+}
+
+// describe 发送给LLM的种子产品描述
+func (s seedProduct) describe() string {
+	parts := []string{s.Name}
+	if s.Category != "" {
+		parts = append(parts, "品类："+s.Category)
+	}
+	if len(s.Keywords) > 0 {
+		parts = append(parts, "关键词："+strings.Join(s.Keywords, "、"))
+	}
+	if s.Summary != "" {
+		parts = append(parts, "简介："+s.Summary)
+	}
+	return strings.Join(parts, "；")
+}
+
+// normalizeSeeds 去掉空白和重复的种子
+func normalizeSeeds(seeds []string) ([]string, error) {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, seed := range seeds {
+		seed = strings.TrimSpace(seed)
+		if seed == "" || seen[strings.ToLower(seed)] {
+			continue
+		}
+		seen[strings.ToLower(seed)] = true
+		normalized = append(normalized, seed)
+	}
+	if len(normalized) > maxSeeds {
+		return nil, fmt.Errorf("seeds 最多 %d 个", maxSeeds)
+	}
+	return normalized, nil
+}
+
+// seedTopic 只提供种子时任务的主题
+func seedTopic(seeds []string) string {
+	return "类似" + strings.Join(seeds, "、") + "的产品"
+}
+
+// profileSeed 识别种子产品：网址直接抓取，名称先识别官网再抓取，用LLM从官网内容推断品类
+func (h *DiscoveryHandler) profileSeed(ctx context.Context, input string) seedProduct {
+	seed := seedProduct{Input: input, Name: input}
+
+	pageURL := ""
+	if discovery.IsSeedURL(input) {
+		pageURL = discovery.SeedURL(input)
+		seed.Name = discovery.SeedName("", "", pageURL)
+	} else if site := h.findOfficialSite(ctx, input); site != nil && site.Verified {
+		pageURL = site.URL
+	}
+	if pageURL == "" {
+		return seed
+	}
+
+	result, err := h.crawler.Crawl(ctx, pageURL)
+	if err != nil {
+		log.Printf("[种子产品] 抓取官网失败 %s: %v", pageURL, err)
+		return seed
+	}
+	seed.URL = pageURL
+	if discovery.IsSeedURL(input) {
+		seed.Name = discovery.SeedName(result.Title, result.Metadata["og:site_name"], pageURL)
+	}
+
+	content := result.Markdown
+	if runes := []rune(content); len(runes) > maxArticleRunes {
+		content = string(runes[:maxArticleRunes])
+	}
+
+	profile, err := h.extractor.ProfileProduct(ctx, seed.Name, content)
+	if err != nil {
+		log.Printf("[种子产品] 推断品类失败 %s: %v", seed.Name, err)
+		seed.Summary = result.Metadata["description"]
+		return seed
+	}
+	seed.Category = profile.Category
+	seed.Keywords = profile.Keywords
+	seed.Summary = profile.Summary
+	log.Printf("[种子产品] %s → %s", seed.Name, seed.Category)
+	return seed
+}
+
+// discoverAlternatives 基于种子产品发现竞品：识别种子的官网和品类，搜索"X 替代品""X vs""类似X的"
+// 及品类查询，用LLM从文章中提取与种子相似的产品并投票排序，reasons说明相似之处。
+// topic为空时使用第一个推断出的品类
+func (h *DiscoveryHandler) discoverAlternatives(ctx context.Context, seeds []string, topic string, plan discovery.DepthPlan, limit int) (*competitorExtraction, error) {
+	profiles := make([]seedProduct, 0, len(seeds))
+	names := make([]string, 0, len(seeds))
+	descriptions := make([]string, 0, len(seeds))
+	for _, input := range seeds {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		profile := h.profileSeed(ctx, input)
+		profiles = append(profiles, profile)
+		names = append(names, profile.Name)
+		descriptions = append(descriptions, "- "+profile.describe())
+	}
+
+	category := topic
+	for _, profile := range profiles {
+		if category != "" {
+			break
+		}
+		category = profile.Category
+	}
+
+	results, err := h.searchManager.SearchAlternatives(ctx, names, category, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	seedDescription := strings.Join(descriptions, "\n")
+	extract := func(ctx context.Context, content string) ([]ai.CompetitorInfo, error) {
+		return h.extractor.ExtractAlternatives(ctx, seedDescription, content)
+	}
+	mentions, extracted := h.collectMentions(ctx, discovery.SelectArticles(results, plan.Articles, staleArticleAge()), extract)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// 种子本身不作为结果
+	excluded := append(append([]string{}, seeds...), names...)
+
+	extraction := &competitorExtraction{Articles: extracted, Method: "llm", Seeds: profiles, Category: category}
+	extraction.Competitors = discovery.ExcludeCompetitors(discovery.VoteCompetitors(mentions, len(extracted), category), excluded)
+
+	if len(extraction.Competitors) == 0 {
+		log.Printf("[竞品提取] 未能从文章中提取替代品，改为从搜索结果推测: %s", strings.Join(names, "、"))
+		extraction.Method = "heuristic"
+		var votes []discovery.CompetitorVote
+		for _, name := range extractCompetitorNamesFromResults(results, limit+len(excluded)) {
+			votes = append(votes, discovery.CompetitorVote{Name: name})
+		}
+		extraction.Competitors = discovery.ExcludeCompetitors(votes, excluded)
+	}
+
+	if len(extraction.Competitors) > limit {
+		extraction.Competitors = extraction.Competitors[:limit]
+	}
+	return extraction, nil
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"competitive-analyzer/config"
+	"competitive-analyzer/database"
+	"competitive-analyzer/models"
+	"competitive-analyzer/platforms"
+	"competitive-analyzer/sessions"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	sessionStoreOnce sync.Once
+	sharedSessions   *sessions.Store
+)
+
+// sessionStore 平台登录会话存储，未配置SESSION_ENCRYPTION_KEY时为nil（不使用登录会话）
+func sessionStore() *sessions.Store {
+	sessionStoreOnce.Do(func() {
+		store, err := sessions.NewStore(database.DB, config.AppConfig.SessionEncryptionKey)
+		if err != nil {
+			log.Printf("登录会话不可用: %v", err)
+			return
+		}
+		sharedSessions = store
+	})
+	return sharedSessions
+}
+
+// SaveSessionRequest 上传平台登录会话
+type SaveSessionRequest struct {
+	Cookie  string            `json:"cookie"`  // 从浏览器复制的Cookie请求头，如 "a=1; b=2"
+	Cookies []sessions.Cookie `json:"cookies"` // 逐个指定的Cookie（可指定域名、路径、过期时间）
+	Headers map[string]string `json:"headers"` // 额外的请求头，如 Authorization
+	Note    string            `json:"note"`
+}
+
+// SessionHealth 一个平台的登录会话状态
+type SessionHealth struct {
+	Platform   string `json:"platform"`
+	NeedsLogin bool   `json:"needs_login"`
+	// missing：需要登录但没有上传会话；active：可用；expired：已停用（跳转到登录页面、连续遇到登录提示或Cookie已过期）；cookies_expired：Cookie已过期，下次使用时停用
+	Status  string                  `json:"status"`
+	Session *models.PlatformSession `json:"session,omitempty"`
+}
+
+// resolvePlatform 按平台名称或域名（如 小红书、xiaohongshu.com）查找平台
+func resolvePlatform(nameOrHost string) *platforms.Platform {
+	registry := platformRegistry()
+	if platform := registry.ByName(nameOrHost); platform != nil {
+		return platform
+	}
+	return registry.Lookup(nameOrHost)
+}
+
+// SaveSession 上传（覆盖）平台的登录会话，Cookie和请求头加密保存，之后爬取该平台时使用
+func SaveSession(c *gin.Context) {
+	store := sessionStore()
+	if store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": sessions.ErrNoKey.Error()})
+		return
+	}
+
+	platform := resolvePlatform(c.Param("platform"))
+	if platform == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "平台不存在: " + c.Param("platform")})
+		return
+	}
+
+	var req SaveSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cookies, err := sessions.ParseCookieHeader(req.Cookie)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	credentials := sessions.Credentials{
+		Cookies: append(cookies, req.Cookies...),
+		Headers: req.Headers,
+	}
+
+	session, err := store.Save(platform.Name, credentials, req.Note)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"session": session,
+	})
+}
+
+// DeleteSession 删除平台的登录会话
+func DeleteSession(c *gin.Context) {
+	store := sessionStore()
+	if store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": sessions.ErrNoKey.Error()})
+		return
+	}
+
+	platform := resolvePlatform(c.Param("platform"))
+	if platform == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "平台不存在: " + c.Param("platform")})
+		return
+	}
+
+	deleted, err := store.Delete(platform.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": platform.Name + "没有登录会话"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// ListSessions 列出登录会话的健康状态：已上传会话的平台，以及需要登录但没有会话的平台
+func ListSessions(c *gin.Context) {
+	store := sessionStore()
+
+	var stored []models.PlatformSession
+	if store != nil {
+		var err error
+		if stored, err = store.List(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	now := time.Now()
+	seen := make(map[string]bool)
+	health := []SessionHealth{}
+	for i := range stored {
+		session := &stored[i]
+		seen[session.Platform] = true
+
+		status := session.Status
+		if status == sessions.StatusActive && session.CookiesExpireAt != nil && session.CookiesExpireAt.Before(now) {
+			status = "cookies_expired"
+		}
+		platform := platformRegistry().ByName(session.Platform)
+		health = append(health, SessionHealth{
+			Platform:   session.Platform,
+			NeedsLogin: platform != nil && platform.NeedsLogin,
+			Status:     status,
+			Session:    session,
+		})
+	}
+	for _, platform := range platformRegistry().Platforms {
+		if platform.NeedsLogin && !seen[platform.Name] {
+			health = append(health, SessionHealth{Platform: platform.Name, NeedsLogin: true, Status: "missing"})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"encryption_configured": store != nil,
+		"sessions":              health,
+	})
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"competitive-analyzer/database"
+	"competitive-analyzer/models"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// taskRegistry 记录正在执行的后台任务，用于取消
+type taskRegistry struct {
+	mu      sync.Mutex
+	cancels map[uint]context.CancelFunc
+}
+
+var runningTasks = &taskRegistry{cancels: make(map[uint]context.CancelFunc)}
+
+// attach 为任务创建可取消的ctx，任务结束后调用返回的done释放资源
+func (r *taskRegistry) attach(parent context.Context, taskID uint) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+
+	r.mu.Lock()
+	r.cancels[taskID] = cancel
+	r.mu.Unlock()
+
+	return ctx, func() {
+		r.mu.Lock()
+		delete(r.cancels, taskID)
+		r.mu.Unlock()
+		cancel()
+	}
+}
+
+// cancel 取消正在执行的任务，任务不在运行时返回false
+func (r *taskRegistry) cancel(taskID uint) bool {
+	r.mu.Lock()
+	cancel, ok := r.cancels[taskID]
+	r.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// stageContext 为工作流的单个阶段设置超时
+func stageContext(parent context.Context, seconds int) (context.Context, context.CancelFunc) {
+	if seconds <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent, time.Duration(seconds)*time.Second)
+}
+
+// sleepContext 可被取消的等待
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// isTaskFinished 任务是否已处于终态
+func isTaskFinished(status string) bool {
+	return status == "completed" || status == "failed" || status == "cancelled"
+}
+
+// saveTask 保存后台任务进度，已被取消的任务不会被覆盖
+func saveTask(task *models.DiscoveryTask) {
+	database.DB.Model(&models.DiscoveryTask{}).
+		Where("id = ? AND status <> ?", task.ID, "cancelled").
+		Select("*").
+		Updates(task)
+}
+
+// failTask 根据错误类型将任务标记为取消或失败
+func failTask(task *models.DiscoveryTask, err error) {
+	now := time.Now()
+	task.CompletedAt = &now
+
+	if errors.Is(err, context.Canceled) {
+		task.Status = "cancelled"
+		task.ResultData = models.JSONB{"error": "任务已取消"}
+		database.DB.Save(task)
+		return
+	}
+
+	task.Status = "failed"
+	task.ResultData = models.JSONB{
+		"error":   err.Error(),
+		"timeout": errors.Is(err, context.DeadlineExceeded),
+	}
+	saveTask(task)
+}
+
+// TaskHandler 任务管理处理器
+type TaskHandler struct{}
+
+// NewTaskHandler 创建任务管理处理器
+func NewTaskHandler() *TaskHandler {
+	return &TaskHandler{}
+}
+
+// Cancel 取消正在执行的发现/自动化任务
+func (h *TaskHandler) Cancel(c *gin.Context) {
+	db := database.DB
+
+	var task models.DiscoveryTask
+	if err := db.First(&task, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+		return
+	}
+
+	if isTaskFinished(task.Status) {
+		c.JSON(http.StatusConflict, gin.H{"error": "任务已结束，无法取消", "status": task.Status})
+		return
+	}
+
+	// 中止进行中的搜索、爬取和LLM调用，并取消排队中的重试
+	wasRunning := runningTasks.cancel(task.ID)
+	if jobQueue != nil {
+		jobQueue.CancelByTask(task.ID)
+	}
+
+	now := time.Now()
+	task.Status = "cancelled"
+	task.CompletedAt = &now
+	db.Model(&task).Updates(map[string]interface{}{
+		"status":       task.Status,
+		"completed_at": task.CompletedAt,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"task_id":     task.ID,
+		"status":      task.Status,
+		"was_running": wasRunning,
+	})
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"competitive-analyzer/database"
+	"competitive-analyzer/discovery"
+	"competitive-analyzer/models"
+	"context"
+	"log"
+)
+
+// 每个竞品最多验证的候选官网数
+const maxOfficialSiteChecks = 2
+
+// findOfficialSite 识别竞品官网：按评分依次抓取候选官网首页验证，返回第一个通过验证的官网；
+// 都未通过时返回评分最高的候选（Verified为false），没有候选时返回nil
+func (h *DiscoveryHandler) findOfficialSite(ctx context.Context, competitorName string) *discovery.OfficialSite {
+	candidates, err := h.searchManager.FindOfficialSites(ctx, competitorName)
+	if err != nil || len(candidates) == 0 {
+		return nil
+	}
+
+	for i := range candidates {
+		if i >= maxOfficialSiteChecks || ctx.Err() != nil {
+			break
+		}
+		site := &candidates[i]
+
+		result, err := h.crawler.Crawl(ctx, site.URL)
+		if err != nil {
+			log.Printf("[官网识别] 抓取首页失败 %s: %v", site.URL, err)
+			continue
+		}
+
+		verified, reason := discovery.VerifyOfficialSite(competitorName, result.Title, result.Markdown)
+		site.Reasons = append(site.Reasons, reason)
+		if verified {
+			site.Verified = true
+			log.Printf("[官网识别] %s → %s", competitorName, site.URL)
+			return site
+		}
+	}
+	return &candidates[0]
+}
+
+// verifiedDomain 已验证官网的域名，用于限定数据源查询范围
+func verifiedDomain(site *discovery.OfficialSite) string {
+	if site == nil || !site.Verified {
+		return ""
+	}
+	return site.Domain
+}
+
+// saveCompetitorWebsite 竞品还没有官网时，保存已验证的官网
+func saveCompetitorWebsite(competitor *models.Competitor, site *discovery.OfficialSite) {
+	if competitor == nil || competitor.Website != "" || site == nil || !site.Verified {
+		return
+	}
+	competitor.Website = site.URL
+	database.DB.Model(competitor).Update("website", site.URL)
+}
+
+// taskWebsites 发现任务结果中的官网识别结果
+func taskWebsites(task *models.DiscoveryTask) map[string]*discovery.OfficialSite {
+	websites := make(map[string]*discovery.OfficialSite)
+	if raw, ok := task.ResultData["websites"].(map[string]interface{}); ok {
+		if err := decodeJSONB(raw, &websites); err != nil {
+			log.Printf("解析官网识别结果失败: %v", err)
+		}
+	}
+	return websites
+}
@@ -0,0 +1,382 @@
+package jobs
+
+import (
+	"competitive-analyzer/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// HandlerFunc 任务处理函数，返回错误时按重试策略重新排队
+type HandlerFunc func(ctx context.Context, job *models.Job) error
+
+// permanentError 不需要重试的错误
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent 标记错误为不可重试
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent 判断错误是否不可重试
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// Queue 基于数据库的持久化任务队列
+type Queue struct {
+	db            *gorm.DB
+	workers       int
+	maxAttempts   int
+	leaseDuration time.Duration
+	pollInterval  time.Duration
+	owner         string
+
+	mu       sync.RWMutex
+	handlers map[string]HandlerFunc
+	wake     chan struct{}
+}
+
+// NewQueue 创建任务队列
+func NewQueue(db *gorm.DB, workers, maxAttempts int) *Queue {
+	if workers <= 0 {
+		workers = 1
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+
+	hostname, _ := os.Hostname()
+
+	return &Queue{
+		db:            db,
+		workers:       workers,
+		maxAttempts:   maxAttempts,
+		leaseDuration: 2 * time.Minute,
+		pollInterval:  2 * time.Second,
+		owner:         fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), time.Now().Unix()),
+		handlers:      make(map[string]HandlerFunc),
+		wake:          make(chan struct{}, 1),
+	}
+}
+
+// Register 注册任务类型的处理函数
+func (q *Queue) Register(jobType string, handler HandlerFunc) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[jobType] = handler
+}
+
+// Enqueue 添加任务
+func (q *Queue) Enqueue(jobType string, payload interface{}, taskID *uint) (*models.Job, error) {
+	data, err := EncodePayload(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.Job{
+		Type:        jobType,
+		Payload:     data,
+		Status:      "pending",
+		TaskID:      taskID,
+		MaxAttempts: q.maxAttempts,
+		RunAt:       time.Now(),
+	}
+	if err := q.db.Create(job).Error; err != nil {
+		return nil, fmt.Errorf("保存任务失败: %w", err)
+	}
+
+	// 唤醒调度循环，尽快执行
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+
+	return job, nil
+}
+
+// SaveProgress 保存任务的中间状态（Payload），用于重启后从断点继续
+func (q *Queue) SaveProgress(job *models.Job) error {
+	return q.db.Model(&models.Job{}).Where("id = ?", job.ID).Update("payload", job.Payload).Error
+}
+
+// CancelByTask 取消关联发现任务的所有未完成任务
+func (q *Queue) CancelByTask(taskID uint) error {
+	now := time.Now()
+	return q.db.Model(&models.Job{}).
+		Where("task_id = ? AND status IN ?", taskID, []string{"pending", "running"}).
+		Updates(map[string]interface{}{"status": "cancelled", "completed_at": &now}).Error
+}
+
+// Recover 恢复上次运行中断的任务（服务启动时调用）
+func (q *Queue) Recover() (int64, error) {
+	result := q.db.Model(&models.Job{}).
+		Where("status = ?", "running").
+		Updates(map[string]interface{}{
+			"status":       "pending",
+			"lease_owner":  "",
+			"leased_until": nil,
+			"run_at":       time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// Start 启动调度循环和工作协程
+func (q *Queue) Start(ctx context.Context) {
+	slots := make(chan struct{}, q.workers)
+
+	go func() {
+		ticker := time.NewTicker(q.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			q.reclaimExpired()
+			q.dispatch(ctx, slots)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-q.wake:
+			}
+		}
+	}()
+}
+
+// dispatch 在有空闲worker时持续领取并执行任务
+func (q *Queue) dispatch(ctx context.Context, slots chan struct{}) {
+	for {
+		select {
+		case slots <- struct{}{}:
+		default:
+			return // 没有空闲worker
+		}
+
+		job, err := q.claim()
+		if err != nil || job == nil {
+			<-slots
+			if err != nil {
+				log.Printf("[任务队列] 领取任务失败: %v", err)
+			}
+			return
+		}
+
+		go func(job *models.Job) {
+			defer func() {
+				<-slots
+				// worker空闲后立即检查是否有新任务
+				select {
+				case q.wake <- struct{}{}:
+				default:
+				}
+			}()
+			q.run(ctx, job)
+		}(job)
+	}
+}
+
+// claim 领取一个到期的待执行任务
+func (q *Queue) claim() (*models.Job, error) {
+	q.mu.RLock()
+	types := make([]string, 0, len(q.handlers))
+	for jobType := range q.handlers {
+		types = append(types, jobType)
+	}
+	q.mu.RUnlock()
+
+	if len(types) == 0 {
+		return nil, nil
+	}
+
+	var candidates []models.Job
+	if err := q.db.Where("status = ? AND run_at <= ? AND type IN ?", "pending", time.Now(), types).
+		Order("run_at, id").Limit(5).Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	for _, candidate := range candidates {
+		leasedUntil := time.Now().Add(q.leaseDuration)
+		// 条件更新保证同一任务只被一个worker领取
+		result := q.db.Model(&models.Job{}).
+			Where("id = ? AND status = ?", candidate.ID, "pending").
+			Updates(map[string]interface{}{
+				"status":       "running",
+				"lease_owner":  q.owner,
+				"leased_until": &leasedUntil,
+				"attempts":     gorm.Expr("attempts + 1"),
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			job := &models.Job{}
+			if err := q.db.First(job, candidate.ID).Error; err != nil {
+				return nil, err
+			}
+			return job, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// reclaimExpired 回收租约过期的任务（持有者已退出）
+func (q *Queue) reclaimExpired() {
+	err := q.db.Model(&models.Job{}).
+		Where("status = ? AND leased_until < ?", "running", time.Now()).
+		Updates(map[string]interface{}{
+			"status":       "pending",
+			"lease_owner":  "",
+			"leased_until": nil,
+		}).Error
+	if err != nil {
+		log.Printf("[任务队列] 回收过期任务失败: %v", err)
+	}
+}
+
+// run 执行任务并根据结果更新状态
+func (q *Queue) run(ctx context.Context, job *models.Job) {
+	q.mu.RLock()
+	handler := q.handlers[job.Type]
+	q.mu.RUnlock()
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// 定期续租，防止长任务被当作中断任务回收
+	go func() {
+		ticker := time.NewTicker(q.leaseDuration / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-jobCtx.Done():
+				return
+			case <-ticker.C:
+				leasedUntil := time.Now().Add(q.leaseDuration)
+				err := q.db.Model(&models.Job{}).
+					Where("id = ? AND lease_owner = ? AND status = ?", job.ID, q.owner, "running").
+					Update("leased_until", &leasedUntil).Error
+				if err != nil {
+					log.Printf("[任务队列] 任务 #%d 续租失败: %v", job.ID, err)
+				}
+			}
+		}
+	}()
+
+	log.Printf("[任务队列] 开始执行 #%d %s（第%d次）", job.ID, job.Type, job.Attempts)
+
+	err := q.safeRun(jobCtx, handler, job)
+	now := time.Now()
+
+	var updates map[string]interface{}
+	switch {
+	case err == nil:
+		updates = map[string]interface{}{
+			"status":       "completed",
+			"last_error":   "",
+			"completed_at": &now,
+			"leased_until": nil,
+		}
+		log.Printf("[任务队列] 任务完成 #%d %s", job.ID, job.Type)
+
+	case errors.Is(err, context.Canceled) && ctx.Err() == nil:
+		updates = map[string]interface{}{
+			"status":       "cancelled",
+			"last_error":   err.Error(),
+			"completed_at": &now,
+			"leased_until": nil,
+		}
+		log.Printf("[任务队列] 任务已取消 #%d %s", job.ID, job.Type)
+
+	case IsFinalAttempt(job, err):
+		updates = map[string]interface{}{
+			"status":       "failed",
+			"last_error":   err.Error(),
+			"completed_at": &now,
+			"leased_until": nil,
+		}
+		log.Printf("[任务队列] 任务失败 #%d %s: %v", job.ID, job.Type, err)
+
+	default:
+		// 指数退避：30s、60s、120s...
+		backoff := time.Duration(1<<uint(job.Attempts-1)) * 30 * time.Second
+		updates = map[string]interface{}{
+			"status":       "pending",
+			"last_error":   err.Error(),
+			"run_at":       now.Add(backoff),
+			"lease_owner":  "",
+			"leased_until": nil,
+		}
+		log.Printf("[任务队列] 任务 #%d %s 失败，%v后重试: %v", job.ID, job.Type, backoff, err)
+	}
+
+	// 只更新仍由本worker持有的任务，已取消的任务保持取消状态；写入失败时任务仍为running，租约过期后被回收重跑
+	dbErr := q.db.Model(&models.Job{}).
+		Where("id = ? AND lease_owner = ? AND status = ?", job.ID, q.owner, "running").
+		Updates(updates).Error
+	if dbErr != nil {
+		log.Printf("[任务队列] 保存任务 #%d 状态（%v）失败: %v", job.ID, updates["status"], dbErr)
+	}
+}
+
+// safeRun 执行处理函数，panic视为不可重试的失败
+func (q *Queue) safeRun(ctx context.Context, handler HandlerFunc, job *models.Job) (err error) {
+	if handler == nil {
+		return Permanent(fmt.Errorf("未注册的任务类型: %s", job.Type))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = Permanent(fmt.Errorf("任务执行异常: %v", r))
+		}
+	}()
+
+	return handler(ctx, job)
+}
+
+// IsFinalAttempt 当前是否为最后一次尝试
+func IsFinalAttempt(job *models.Job, err error) bool {
+	return IsPermanent(err) || job.Attempts >= job.MaxAttempts
+}
+
+// EncodePayload 将任意结构转换为JSONB
+func EncodePayload(v interface{}) (models.JSONB, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("序列化任务参数失败: %w", err)
+	}
+
+	var payload models.JSONB
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("序列化任务参数失败: %w", err)
+	}
+	return payload, nil
+}
+
+// DecodePayload 将任务的Payload解析到结构体
+func DecodePayload(job *models.Job, v interface{}) error {
+	data, err := json.Marshal(job.Payload)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("解析任务参数失败: %w", err)
+	}
+	return nil
+}
@@ -4,7 +4,11 @@ import (
 	"competitive-analyzer/config"
 	"competitive-analyzer/database"
 	"competitive-analyzer/handlers"
+	"competitive-analyzer/jobs"
+	"competitive-analyzer/monitor"
+	"context"
 	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,6 +22,27 @@ func main() {
 		log.Fatalf("数据库初始化失败: %v", err)
 	}
 
+	// 加载平台配置，PLATFORMS_PATH指定的文件有误时不启动
+	if err := handlers.LoadPlatforms(); err != nil {
+		log.Fatalf("平台配置加载失败: %v", err)
+	}
+
+	// 启动任务队列，恢复上次中断的任务
+	queue := jobs.NewQueue(database.DB, cfg.JobWorkers, cfg.JobMaxAttempts)
+	if recovered, err := queue.Recover(); err != nil {
+		log.Printf("恢复中断任务失败: %v", err)
+	} else if recovered > 0 {
+		log.Printf("已恢复 %d 个中断的任务", recovered)
+	}
+	handlers.RegisterJobHandlers(queue)
+	handlers.RecoverTasks()
+	queue.Start(context.Background())
+
+	// 启动监控任务调度器
+	scheduler := monitor.NewScheduler(database.DB, time.Duration(cfg.MonitorCheckInterval)*time.Second)
+	handlers.RegisterMonitorScheduler(scheduler)
+	scheduler.Start(context.Background())
+
 	// 设置Gin模式
 	gin.SetMode(cfg.GinMode)
 
@@ -38,13 +63,8 @@ func main() {
 		c.Next()
 	})
 
-	// 健康检查
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status": "ok",
-			"version": "1.0.0",
-		})
-	})
+	// 健康检查（包括各外部服务的熔断状态）
+	r.GET("/health", handlers.Health)
 
 	// API路由组
 	api := r.Group("/api")
@@ -64,6 +84,8 @@ func main() {
 		{
 			crawl.POST("/single", crawlHandler.CrawlSingle)
 			crawl.POST("/batch", crawlHandler.CrawlBatch) // 批量爬取
+			crawl.GET("/jobs/:id", crawlHandler.GetCrawlJob)
+			crawl.POST("/jobs/:id/retry", crawlHandler.RetryCrawlJob) // 重试失败的URL
 		}
 
 		// 竞品管理
@@ -71,6 +93,21 @@ func main() {
 		{
 			competitors.GET("", handlers.GetCompetitors)
 			competitors.GET("/:id/sources", handlers.GetDataSources)
+			competitors.GET("/:id/changes", handlers.GetCompetitorChanges)   // 内容变化日志
+			competitors.GET("/duplicates", handlers.GetCompetitorDuplicates) // 疑似重复的竞品
+			competitors.POST("/merge", handlers.MergeCompetitors)            // 合并重复竞品
+			competitors.POST("/:id/aliases", handlers.AddCompetitorAlias)
+		}
+
+		// 数据源质量（按域名统计的爬取和分析效果）
+		api.GET("/domain_stats", handlers.GetDomainStats)
+
+		// 平台登录会话（加密保存的Cookie和请求头，用于爬取需要登录的平台）
+		platformSessions := api.Group("/sessions")
+		{
+			platformSessions.GET("", handlers.ListSessions) // 会话健康状态
+			platformSessions.PUT("/:platform", handlers.SaveSession)
+			platformSessions.DELETE("/:platform", handlers.DeleteSession)
 		}
 
 		// AI分析模块
@@ -93,6 +130,36 @@ func main() {
 		{
 			auto.POST("/analysis", automationHandler.AutoAnalysis) // 一键分析
 		}
+
+		// 任务管理
+		taskHandler := handlers.NewTaskHandler()
+		tasks := api.Group("/tasks")
+		{
+			tasks.POST("/:id/cancel", taskHandler.Cancel) // 取消进行中的任务
+		}
+
+		// 监控任务（定期重新爬取）
+		monitorHandler := handlers.NewMonitorHandler()
+		monitors := api.Group("/monitors")
+		{
+			monitors.GET("", monitorHandler.ListMonitors)
+			monitors.POST("", monitorHandler.CreateMonitor)
+			monitors.GET("/:id", monitorHandler.GetMonitor)
+			monitors.PUT("/:id", monitorHandler.UpdateMonitor)
+			monitors.DELETE("/:id", monitorHandler.DeleteMonitor)
+			monitors.POST("/:id/run", monitorHandler.RunMonitor) // 立即执行一次
+		}
+
+		// 告警记录
+		api.GET("/alerts", handlers.ListAlerts)
+		api.GET("/notifications", handlers.ListNotifications)
+
+		// 搜索缓存
+		searchCache := api.Group("/search_cache")
+		{
+			searchCache.GET("/stats", handlers.GetSearchCacheStats)
+			searchCache.DELETE("", handlers.PurgeSearchCache) // ?expired=true&engine=&query=
+		}
 	}
 
 	// 启动服务器
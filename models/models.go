@@ -28,6 +28,7 @@ type DiscoveryTask struct {
 	Market          string    `json:"market"`
 	TargetCount     int       `json:"target_count"`
 	SearchDepth     string    `json:"search_depth"` // quick/standard/deep
+	SearchPlan      JSONB     `gorm:"type:text" json:"search_plan"` // 搜索深度对应的发现策略
 	Status          string    `gorm:"default:'pending'" json:"status"`
 	Progress        int       `gorm:"default:0" json:"progress"`
 	CompetitorsFound int      `gorm:"default:0" json:"competitors_found"`
@@ -40,11 +41,13 @@ type DiscoveryTask struct {
 // SearchCache 搜索缓存
 type SearchCache struct {
 	ID           uint      `gorm:"primaryKey" json:"id"`
-	Query        string    `gorm:"uniqueIndex;not null" json:"query"`
-	SearchEngine string    `json:"search_engine"`
+	CacheKey     string    `gorm:"uniqueIndex" json:"cache_key"` // 搜索引擎+查询+参数的哈希
+	Query        string    `gorm:"index:idx_search_cache_query;not null" json:"query"`
+	SearchEngine string    `gorm:"index" json:"search_engine"`
+	Params       string    `json:"params"` // 影响结果的搜索参数，如 num=10
 	Results      JSONB     `gorm:"type:text" json:"results"`
 	CachedAt     time.Time `json:"cached_at"`
-	ExpiresAt    time.Time `json:"expires_at"`
+	ExpiresAt    time.Time `gorm:"index" json:"expires_at"`
 	HitCount     int       `gorm:"default:0" json:"hit_count"`
 }
 
@@ -60,6 +63,17 @@ type Competitor struct {
 	Status           string    `gorm:"default:'active'" json:"status"`
 	CreatedAt        time.Time `json:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at"`
+	Aliases          []CompetitorAlias `gorm:"foreignKey:CompetitorID" json:"aliases,omitempty"`
+}
+
+// CompetitorAlias 竞品别名（同一竞品的不同写法，如 Notion / Notion AI / notion.so）
+type CompetitorAlias struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	CompetitorID uint      `gorm:"index;not null" json:"competitor_id"`
+	Alias        string    `gorm:"not null" json:"alias"`
+	Normalized   string    `gorm:"uniqueIndex;not null" json:"normalized"` // 归一化后的名称，见entity.Normalize
+	Source       string    `json:"source"`                                 // name/fuzzy/manual/merge
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 // DataSource 数据源
@@ -73,9 +87,45 @@ type DataSource struct {
 	AutoDiscovered  bool      `gorm:"default:false" json:"auto_discovered"`
 	Status          string    `gorm:"default:'active'" json:"status"`
 	LastCrawlTime   *time.Time `json:"last_crawl_time"`
+	PublishedAt     *time.Time `json:"published_at,omitempty"` // 内容的发布时间（搜索结果或页面元数据）
 	Competitor      Competitor `gorm:"foreignKey:CompetitorID" json:"competitor,omitempty"`
 }
 
+// DomainStat 按域名（可注册域名）统计的爬取和分析效果，用于学习数据源质量
+type DomainStat struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	Domain         string    `gorm:"uniqueIndex;not null" json:"domain"`
+	CrawlAttempts  int       `gorm:"default:0" json:"crawl_attempts"`
+	CrawlSuccesses int       `gorm:"default:0" json:"crawl_successes"`
+	CaptchaHits    int       `gorm:"default:0" json:"captcha_hits"`
+	ContentLength  int64     `gorm:"default:0" json:"content_length"` // 成功爬取的内容总字符数
+	Extractions    int       `gorm:"default:0" json:"extractions"`    // 内容参与AI分析的次数
+	PricingHits    int       `gorm:"default:0" json:"pricing_hits"`   // 内容中包含分析出的定价信息的次数
+	FeatureHits    int       `gorm:"default:0" json:"feature_hits"`   // 内容中包含分析出的功能信息的次数
+	QualityScore   float64   `json:"quality_score"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// PlatformSession 平台的登录会话，Cookie和请求头加密保存，用于爬取需要登录的平台
+type PlatformSession struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	Platform        string     `gorm:"uniqueIndex;not null" json:"platform"` // 平台名称，与平台配置中的name一致
+	Credentials     string     `gorm:"type:text" json:"-"`                   // 加密后的Cookie和请求头（AES-GCM，base64），不对外返回
+	CookieCount     int        `json:"cookie_count"`
+	HeaderNames     string     `json:"header_names"` // 请求头名称（逗号分隔，不含值）
+	Note            string     `json:"note"`
+	Status          string     `gorm:"index;default:'active'" json:"status"` // active/expired
+	CookiesExpireAt *time.Time `json:"cookies_expire_at"`                    // Cookie中最早的过期时间
+	SuccessCount    int        `gorm:"default:0" json:"success_count"`
+	LoginWallCount  int        `gorm:"default:0" json:"login_wall_count"` // 连续遇到登录提示的次数，成功后清零，达到上限时标记失效
+	LastUsedAt      *time.Time `json:"last_used_at"`
+	LastSuccessAt   *time.Time `json:"last_success_at"`
+	ExpiredAt       *time.Time `json:"expired_at"` // 检测到会话失效（被跳转到登录页面或连续遇到登录提示）的时间
+	LastError       string     `gorm:"type:text" json:"last_error"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
 // RawContent 原始内容
 type RawContent struct {
 	ID          uint       `gorm:"primaryKey" json:"id"`
@@ -83,6 +133,7 @@ type RawContent struct {
 	ContentPath string     `json:"content_path"` // Markdown文件路径
 	ContentHash string     `json:"content_hash"` // 内容哈希
 	CrawlTime   time.Time  `json:"crawl_time"`
+	PublishedAt *time.Time `json:"published_at,omitempty"` // 页面的发布时间
 	Metadata    JSONB      `gorm:"type:text" json:"metadata"`
 	DataSource  DataSource `gorm:"foreignKey:SourceID" json:"data_source,omitempty"`
 }
@@ -111,14 +162,20 @@ type AnalysisReport struct {
 // ChangeLog 变化日志
 type ChangeLog struct {
 	ID           uint      `gorm:"primaryKey" json:"id"`
-	CompetitorID uint      `gorm:"not null" json:"competitor_id"`
-	ChangeType   string    `json:"change_type"`
+	CompetitorID uint      `gorm:"not null;index" json:"competitor_id"`
+	SourceID     *uint     `gorm:"index" json:"source_id"`      // 内容变化对应的数据源
+	RawContentID *uint     `json:"raw_content_id"`              // 检测到变化的爬取内容
+	ParsedDataID *uint     `json:"parsed_data_id"`              // 检测到变化的分析结果
+	ChangeType   string    `json:"change_type"` // content_change/price_change/free_to_paid/tier_added/tier_removed/feature_added/feature_removed/...
 	FieldName    string    `json:"field_name"`
 	OldValue     string    `gorm:"type:text" json:"old_value"`
 	NewValue     string    `gorm:"type:text" json:"new_value"`
 	ImpactLevel  string    `json:"impact_level"` // 高/中/低
+	ChangePercent *float64 `json:"change_percent,omitempty"` // 价格变化幅度（%），仅原价不为0的price_change
+	DiffPath     string    `json:"diff_path"` // 差异报告文件路径
 	DetectedAt   time.Time `json:"detected_at"`
-	Notified     bool      `gorm:"default:false" json:"notified"`
+	Evaluated    bool      `gorm:"default:false;index" json:"evaluated"` // 是否已按监控任务的告警规则评估
+	Notified     bool      `gorm:"default:false" json:"notified"`         // 告警通知是否已发送成功
 	Competitor   Competitor `gorm:"foreignKey:CompetitorID" json:"competitor,omitempty"`
 }
 
@@ -127,11 +184,100 @@ type MonitorTask struct {
 	ID           uint      `gorm:"primaryKey" json:"id"`
 	Name         string    `gorm:"not null" json:"name"`
 	CompetitorIDs JSONB    `gorm:"type:text" json:"competitor_ids"`
-	Frequency    string    `json:"frequency"` // daily/weekly/monthly
+	Frequency    string    `json:"frequency"` // daily/weekly/monthly 或 cron表达式
 	AlertRules   JSONB     `gorm:"type:text" json:"alert_rules"`
-	Status       string    `gorm:"default:'active'" json:"status"`
+	NotifyChannels JSONB   `gorm:"type:text" json:"notify_channels"` // 告警通知渠道 {"channels": [...]}
+	Status       string    `gorm:"default:'active'" json:"status"` // active/paused
 	LastRunTime  *time.Time `json:"last_run_time"`
-	NextRunTime  time.Time `json:"next_run_time"`
+	NextRunTime  time.Time `gorm:"index" json:"next_run_time"`
+	LastCrawlJobID *uint   `json:"last_crawl_job_id"` // 最近一次执行创建的爬取任务
+	LastError    string    `gorm:"type:text" json:"last_error"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
+
+// Job 持久化的后台任务（任务队列）
+type Job struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Type        string     `gorm:"index;not null" json:"type"` // discovery_search/crawl_batch/auto_analysis
+	Payload     JSONB      `gorm:"type:text" json:"payload"`
+	Status      string     `gorm:"index;default:'pending'" json:"status"` // pending/running/completed/failed/cancelled
+	TaskID      *uint      `gorm:"index" json:"task_id"`                  // 关联的发现任务
+	Attempts    int        `gorm:"default:0" json:"attempts"`
+	MaxAttempts int        `gorm:"default:3" json:"max_attempts"`
+	LastError   string     `gorm:"type:text" json:"last_error"`
+	RunAt       time.Time  `gorm:"index" json:"run_at"` // 最早可执行时间（用于重试退避）
+	LeaseOwner  string     `json:"lease_owner"`
+	LeasedUntil *time.Time `json:"leased_until"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+// CrawlJob 批量爬取任务
+type CrawlJob struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	Status         string         `gorm:"default:'pending'" json:"status"` // pending/running/completed/partial/failed/cancelled
+	Concurrent     int            `json:"concurrent"`
+	TaskID         *uint          `gorm:"index" json:"task_id"` // 自动化流程发起时关联的发现任务
+	MonitorTaskID  *uint          `gorm:"index" json:"monitor_task_id"` // 监控任务发起时关联的监控任务
+	TotalItems     int            `json:"total_items"`
+	SucceededItems int            `json:"succeeded_items"`
+	FailedItems    int            `json:"failed_items"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	CompletedAt    *time.Time     `json:"completed_at"`
+	Items          []CrawlJobItem `gorm:"foreignKey:CrawlJobID" json:"items,omitempty"`
+}
+
+// CrawlJobItem 批量爬取中的单个URL
+type CrawlJobItem struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	CrawlJobID   uint       `gorm:"index;not null" json:"crawl_job_id"`
+	URL          string     `gorm:"not null" json:"url"`
+	Competitor   string     `json:"competitor"`
+	SourceType   string     `json:"source_type"`
+	Status       string     `gorm:"default:'pending'" json:"status"` // pending/running/succeeded/failed
+	Attempts     int        `gorm:"default:0" json:"attempts"`
+	CrawlerLayer string     `json:"crawler_layer"` // 成功时使用的爬虫（firecrawl/jina/native）
+	Error        string     `gorm:"type:text" json:"error"`
+	ErrorKind    string     `json:"error_kind,omitempty"` // 失败类型（captcha/not_found/rate_limited等）
+	RawContentID *uint      `json:"raw_content_id"`
+	StartedAt    *time.Time `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at"`
+}
+
+// AlertEvent 告警记录（监控任务的规则被某条变化触发）
+type AlertEvent struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	MonitorTaskID uint      `gorm:"index;not null" json:"monitor_task_id"`
+	ChangeLogID   uint      `gorm:"index;not null" json:"change_log_id"`
+	CompetitorID  uint      `gorm:"index" json:"competitor_id"`
+	RuleID        string    `json:"rule_id"`
+	RuleName      string    `json:"rule_name"`
+	RuleType      string    `json:"rule_type"`
+	ImpactLevel   string    `json:"impact_level"`
+	Message       string    `gorm:"type:text" json:"message"`
+	CreatedAt     time.Time `json:"created_at"`
+	ChangeLog     ChangeLog `gorm:"foreignKey:ChangeLogID" json:"change_log,omitempty"`
+}
+
+// NotificationDelivery 通知发送记录
+type NotificationDelivery struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Event         string     `gorm:"index" json:"event"` // alert/task_finished
+	MonitorTaskID *uint      `gorm:"index" json:"monitor_task_id"`
+	TaskID        *uint      `gorm:"index" json:"task_id"`
+	ChannelType   string     `json:"channel_type"`
+	ChannelName   string     `json:"channel_name"`
+	Channel       JSONB      `gorm:"type:text" json:"-"` // 渠道配置（可能包含密钥，不对外返回）
+	Title         string     `json:"title"`
+	Body          string     `gorm:"type:text" json:"body"`
+	Data          JSONB      `gorm:"type:text" json:"data"`
+	ChangeLogIDs  JSONB      `gorm:"type:text" json:"change_log_ids"` // 告警涉及的变化日志 {"ids": [...]}
+	Status        string     `gorm:"index;default:'pending'" json:"status"` // pending/retrying/sent/failed
+	Attempts      int        `gorm:"default:0" json:"attempts"`
+	LastError     string     `gorm:"type:text" json:"last_error"`
+	CreatedAt     time.Time  `json:"created_at"`
+	SentAt        *time.Time `json:"sent_at"`
+}
@@ -0,0 +1,207 @@
+package monitor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 监控任务的执行计划
+type Schedule interface {
+	// Next 返回from之后的下一次执行时间
+	Next(from time.Time) time.Time
+}
+
+// intervalSchedule 固定周期（daily/weekly/monthly）
+type intervalSchedule struct {
+	days   int
+	months int
+}
+
+func (s intervalSchedule) Next(from time.Time) time.Time {
+	return from.AddDate(0, s.months, s.days)
+}
+
+// 预定义的执行频率
+var frequencies = map[string]Schedule{
+	"daily":   intervalSchedule{days: 1},
+	"weekly":  intervalSchedule{days: 7},
+	"monthly": intervalSchedule{months: 1},
+}
+
+// cron描述符对应的表达式
+var cronDescriptors = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+}
+
+// ParseSchedule 解析执行频率，支持daily/weekly/monthly和标准5段cron表达式
+// （分 时 日 月 周，如 "0 9 * * 1-5" 表示工作日9点）
+func ParseSchedule(frequency string) (Schedule, error) {
+	frequency = strings.TrimSpace(frequency)
+	if frequency == "" {
+		return nil, fmt.Errorf("执行频率不能为空")
+	}
+
+	if s, ok := frequencies[strings.ToLower(frequency)]; ok {
+		return s, nil
+	}
+
+	if expr, ok := cronDescriptors[strings.ToLower(frequency)]; ok {
+		frequency = expr
+	}
+
+	schedule, err := parseCron(frequency)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("cron表达式 %q 永远不会触发", frequency)
+	}
+	return schedule, nil
+}
+
+// cronSchedule 5段cron表达式，每段用位图表示允许的取值
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// 日和周都被限制时，按cron惯例满足其一即可
+	domRestricted, dowRestricted bool
+}
+
+// cron字段的取值范围
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{"分钟", 0, 59},
+	{"小时", 0, 23},
+	{"日", 1, 31},
+	{"月", 1, 12},
+	{"星期", 0, 7}, // 0和7都表示周日
+}
+
+func parseCron(expr string) (*cronSchedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("无效的执行频率 %q：应为daily/weekly/monthly或5段cron表达式", expr)
+	}
+
+	bits := make([]uint64, len(parts))
+	for i, part := range parts {
+		b, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("无效的cron表达式 %q: %w", expr, err)
+		}
+		bits[i] = b
+	}
+
+	// 周日统一为0
+	if bits[4]&(1<<7) != 0 {
+		bits[4] = bits[4]&^(1<<7) | 1
+	}
+
+	return &cronSchedule{
+		minute:        bits[0],
+		hour:          bits[1],
+		dom:           bits[2],
+		month:         bits[3],
+		dow:           bits[4],
+		domRestricted: !strings.HasPrefix(parts[2], "*"),
+		dowRestricted: !strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+// parseCronField 解析单个字段，支持 * 、数字、a-b 范围、/n 步长和逗号列表
+func parseCronField(field string, f cronField) (uint64, error) {
+	var bits uint64
+
+	for _, item := range strings.Split(field, ",") {
+		rangePart, step := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s字段步长无效: %s", f.name, item)
+			}
+			rangePart, step = item[:i], n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(bounds[0])
+			hi, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil || lo > hi {
+				return 0, fmt.Errorf("%s字段范围无效: %s", f.name, item)
+			}
+		default:
+			n, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("%s字段取值无效: %s", f.name, item)
+			}
+			lo, hi = n, n
+			if step > 1 {
+				hi = f.max // "5/15" 表示从5开始每15个单位
+			}
+		}
+
+		if lo < f.min || hi > f.max {
+			return 0, fmt.Errorf("%s字段超出范围 %d-%d: %s", f.name, f.min, f.max, item)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+// Next 逐级查找下一个匹配的时间（精确到分钟）
+func (s *cronSchedule) Next(from time.Time) time.Time {
+	t := from.Truncate(time.Minute).Add(time.Minute)
+	// 最多向后查找5年，防止 "0 0 30 2 *" 这类永远不会匹配的表达式死循环
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domRestricted && s.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
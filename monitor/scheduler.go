@@ -0,0 +1,136 @@
+package monitor
+
+import (
+	"competitive-analyzer/models"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// RunFunc 执行一次监控任务（重新爬取竞品的数据源）
+type RunFunc func(ctx context.Context, task *models.MonitorTask) error
+
+// Scheduler 监控任务调度器，定期检查到期的监控任务并执行
+type Scheduler struct {
+	db       *gorm.DB
+	interval time.Duration
+	run      RunFunc
+}
+
+// NewScheduler 创建调度器，interval为检查到期任务的间隔
+func NewScheduler(db *gorm.DB, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	return &Scheduler{
+		// 定时轮询不输出SQL日志
+		db:       db.Session(&gorm.Session{Logger: db.Logger.LogMode(logger.Warn)}),
+		interval: interval,
+	}
+}
+
+// SetRunFunc 设置监控任务的执行函数
+func (s *Scheduler) SetRunFunc(run RunFunc) {
+	s.run = run
+}
+
+// Start 启动调度循环
+func (s *Scheduler) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.runDue(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// NextRunTime 计算任务的下一次执行时间
+func NextRunTime(frequency string, from time.Time) (time.Time, error) {
+	schedule, err := ParseSchedule(frequency)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return schedule.Next(from), nil
+}
+
+// runDue 执行所有到期的监控任务
+func (s *Scheduler) runDue(ctx context.Context) {
+	now := time.Now()
+
+	var tasks []models.MonitorTask
+	if err := s.db.Where("status = ? AND next_run_time <= ?", "active", now).
+		Order("next_run_time").Find(&tasks).Error; err != nil {
+		log.Printf("[监控] 查询到期任务失败: %v", err)
+		return
+	}
+
+	for i := range tasks {
+		if ctx.Err() != nil {
+			return
+		}
+
+		task := &tasks[i]
+		next, err := NextRunTime(task.Frequency, now)
+		if err != nil {
+			// 频率配置错误的任务暂停，避免每次轮询都失败
+			log.Printf("[监控] 任务 #%d 执行频率无效，已暂停: %v", task.ID, err)
+			s.db.Model(task).Updates(map[string]interface{}{
+				"status":     "paused",
+				"last_error": err.Error(),
+			})
+			continue
+		}
+
+		// 先推进下一次执行时间再执行，条件更新保证同一周期只执行一次
+		result := s.db.Model(&models.MonitorTask{}).
+			Where("id = ? AND status = ? AND next_run_time <= ?", task.ID, "active", now).
+			Update("next_run_time", next)
+		if result.Error != nil || result.RowsAffected == 0 {
+			continue
+		}
+		task.NextRunTime = next
+
+		s.Execute(ctx, task)
+	}
+}
+
+// Execute 立即执行监控任务并记录结果，不影响下一次计划执行时间
+func (s *Scheduler) Execute(ctx context.Context, task *models.MonitorTask) error {
+	var err error
+	if s.run == nil {
+		err = fmt.Errorf("监控任务执行函数未设置")
+	} else {
+		err = s.run(ctx, task)
+	}
+
+	now := time.Now()
+	task.LastRunTime = &now
+	task.LastError = ""
+	if err != nil {
+		task.LastError = err.Error()
+		log.Printf("[监控] 任务 #%d %s 执行失败: %v", task.ID, task.Name, err)
+	} else {
+		log.Printf("[监控] 任务 #%d %s 已执行，下次执行时间 %s", task.ID, task.Name, task.NextRunTime.Format("2006-01-02 15:04"))
+	}
+
+	s.db.Model(&models.MonitorTask{}).Where("id = ?", task.ID).Updates(map[string]interface{}{
+		"last_run_time":     task.LastRunTime,
+		"last_error":        task.LastError,
+		"last_crawl_job_id": task.LastCrawlJobID,
+	})
+
+	return err
+}
@@ -0,0 +1,126 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EmailChannel SMTP邮件
+type EmailChannel struct {
+	SMTP SMTPConfig
+	To   []string
+}
+
+func (c *EmailChannel) Type() string { return TypeEmail }
+
+func (c *EmailChannel) Send(ctx context.Context, msg *Message) error {
+	port := c.SMTP.Port
+	if port == 0 {
+		port = 587
+	}
+	addr := net.JoinHostPort(c.SMTP.Host, strconv.Itoa(port))
+
+	from := c.SMTP.From
+	if from == "" {
+		from = c.SMTP.Username
+	}
+
+	data := buildEmail(from, c.To, msg)
+
+	// net/smtp不支持ctx，在单独的协程中发送，ctx结束时放弃等待
+	done := make(chan error, 1)
+	go func() {
+		done <- c.sendMail(addr, port, from, data)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-done:
+		return err
+	}
+}
+
+// sendMail 465端口使用隐式TLS，其他端口由服务器决定是否STARTTLS
+func (c *EmailChannel) sendMail(addr string, port int, from string, data []byte) error {
+	var auth smtp.Auth
+	if c.SMTP.Username != "" {
+		auth = smtp.PlainAuth("", c.SMTP.Username, c.SMTP.Password, c.SMTP.Host)
+	}
+
+	if port != 465 {
+		if err := smtp.SendMail(addr, auth, from, c.To, data); err != nil {
+			return fmt.Errorf("发送邮件失败: %w", err)
+		}
+		return nil
+	}
+
+	dialer := &net.Dialer{Timeout: 15 * time.Second}
+	conn, err := tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: c.SMTP.Host})
+	if err != nil {
+		return fmt.Errorf("连接SMTP服务器失败: %w", err)
+	}
+
+	client, err := smtp.NewClient(conn, c.SMTP.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("连接SMTP服务器失败: %w", err)
+	}
+	defer client.Close()
+
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP认证失败: %w", err)
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	for _, to := range c.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("收件人 %s 被拒绝: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	return client.Quit()
+}
+
+// buildEmail 生成纯文本邮件，标题和正文使用UTF-8编码
+func buildEmail(from string, to []string, msg *Message) []byte {
+	var b strings.Builder
+
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	b.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", msg.Title) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(msg.Body))
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+
+	return []byte(b.String())
+}
@@ -0,0 +1,249 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 通知渠道类型
+const (
+	TypeWebhook  = "webhook"  // 通用JSON webhook
+	TypeEmail    = "email"    // SMTP邮件
+	TypeFeishu   = "feishu"   // 飞书群机器人
+	TypeDingTalk = "dingtalk" // 钉钉群机器人
+	TypeSlack    = "slack"    // Slack incoming webhook
+)
+
+// 通知事件
+const (
+	EventAlert        = "alert"         // 监控告警
+	EventTaskFinished = "task_finished" // 发现/自动化任务结束
+)
+
+// Message 通知消息
+type Message struct {
+	Event string      `json:"event"`
+	Title string      `json:"title"`
+	Body  string      `json:"text"`
+	Data  interface{} `json:"data,omitempty"` // 结构化数据，通用webhook原样发送
+}
+
+// Channel 通知渠道
+type Channel interface {
+	Type() string
+	Send(ctx context.Context, msg *Message) error
+}
+
+// ChannelConfig 通知渠道配置（保存在监控任务或任务请求中）
+type ChannelConfig struct {
+	Type          string            `json:"type"`
+	Name          string            `json:"name,omitempty"`
+	URL           string            `json:"url,omitempty"`            // webhook地址
+	Secret        string            `json:"secret,omitempty"`         // 飞书/钉钉签名密钥
+	Headers       map[string]string `json:"headers,omitempty"`        // 通用webhook的额外请求头
+	To            []string          `json:"to,omitempty"`             // 邮件收件人
+	TitleTemplate string            `json:"title_template,omitempty"` // 自定义标题模板（text/template）
+	BodyTemplate  string            `json:"body_template,omitempty"`  // 自定义正文模板（text/template）
+}
+
+// SMTPConfig 邮件服务器配置
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// DisplayName 渠道名称，未设置时使用类型
+func (c *ChannelConfig) DisplayName() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Type
+}
+
+// RedactedValue 返回给客户端时替换密钥和请求头值的占位符
+const RedactedValue = "******"
+
+// Redacted 隐去签名密钥、请求头的值（如Authorization）和webhook地址中的令牌，用于API响应
+func (c ChannelConfig) Redacted() ChannelConfig {
+	c.URL = redactURL(c.URL)
+	if c.Secret != "" {
+		c.Secret = RedactedValue
+	}
+	if len(c.Headers) > 0 {
+		headers := make(map[string]string, len(c.Headers))
+		for name := range c.Headers {
+			headers[name] = RedactedValue
+		}
+		c.Headers = headers
+	}
+	return c
+}
+
+// webhook地址中作为凭证的路径前缀：Slack的/services/和飞书的/hook/之后的部分就是令牌
+var secretPathMarkers = []string{"/services/", "/hook/"}
+
+// webhook地址中作为凭证的查询参数（钉钉access_token、企业微信key等）
+var secretQueryParams = map[string]bool{
+	"access_token": true,
+	"token":        true,
+	"key":          true,
+	"secret":       true,
+	"sign":         true,
+}
+
+// redactURL 隐去webhook地址中的令牌：凭证路径前缀之后的部分和凭证查询参数的值
+func redactURL(raw string) string {
+	if raw == "" {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return RedactedValue
+	}
+
+	for _, marker := range secretPathMarkers {
+		if i := strings.Index(u.Path, marker); i >= 0 && i+len(marker) < len(u.Path) {
+			u.Path = u.Path[:i+len(marker)] + RedactedValue
+			u.RawPath = u.Path // 占位符保持原样，不转义成%2A
+			break
+		}
+	}
+
+	if u.RawQuery != "" {
+		params := strings.Split(u.RawQuery, "&")
+		for i, param := range params {
+			name, _, found := strings.Cut(param, "=")
+			if key, err := url.QueryUnescape(name); err == nil && found && secretQueryParams[strings.ToLower(key)] {
+				params[i] = name + "=" + RedactedValue
+			}
+		}
+		u.RawQuery = strings.Join(params, "&")
+	}
+	u.User = nil
+	return u.String()
+}
+
+// RestoreRedacted 客户端把读取到的渠道原样提交时，密钥、请求头和地址中的令牌仍是占位符：
+// 按位置从previous中取回同类型渠道的原值（地址须与原地址或其隐去后的形式一致），
+// 位置对不上时再按完整地址查找，避免更新其他字段时把凭证覆盖成占位符
+func RestoreRedacted(channels, previous []ChannelConfig) {
+	for i := range channels {
+		channel := &channels[i]
+		old := matchPrevious(channel, i, previous)
+		if old == nil {
+			continue
+		}
+		if channel.URL != old.URL && channel.URL == redactURL(old.URL) {
+			channel.URL = old.URL
+		}
+		if channel.Secret == RedactedValue {
+			channel.Secret = old.Secret
+		}
+		for name, value := range channel.Headers {
+			if value == RedactedValue {
+				channel.Headers[name] = old.Headers[name]
+			}
+		}
+	}
+}
+
+// matchPrevious 找到channel对应的原渠道：优先同一位置，其次类型和完整地址都相同的渠道
+func matchPrevious(channel *ChannelConfig, index int, previous []ChannelConfig) *ChannelConfig {
+	if index < len(previous) {
+		old := &previous[index]
+		if old.Type == channel.Type && (channel.URL == old.URL || channel.URL == redactURL(old.URL)) {
+			return old
+		}
+	}
+	for i := range previous {
+		if previous[i].Type == channel.Type && previous[i].URL == channel.URL {
+			return &previous[i]
+		}
+	}
+	return nil
+}
+
+// Validate 校验渠道配置和自定义模板
+func (c *ChannelConfig) Validate() error {
+	switch c.Type {
+	case TypeWebhook, TypeFeishu, TypeDingTalk, TypeSlack:
+		if !strings.HasPrefix(c.URL, "http://") && !strings.HasPrefix(c.URL, "https://") {
+			return fmt.Errorf("%s 渠道需要有效的 url", c.DisplayName())
+		}
+	case TypeEmail:
+		if len(c.To) == 0 {
+			return fmt.Errorf("%s 渠道需要收件人 to", c.DisplayName())
+		}
+	case "":
+		return fmt.Errorf("通知渠道缺少 type")
+	default:
+		return fmt.Errorf("未知的通知渠道类型 %s（可选 webhook/email/feishu/dingtalk/slack）", c.Type)
+	}
+
+	if _, err := parseTemplate("title", c.TitleTemplate); err != nil {
+		return fmt.Errorf("%s 渠道的标题模板无效: %w", c.DisplayName(), err)
+	}
+	if _, err := parseTemplate("body", c.BodyTemplate); err != nil {
+		return fmt.Errorf("%s 渠道的正文模板无效: %w", c.DisplayName(), err)
+	}
+	return nil
+}
+
+// ParseChannels 解析并校验通知渠道列表
+func ParseChannels(data interface{}) ([]ChannelConfig, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("通知渠道格式错误: %w", err)
+	}
+
+	var channels []ChannelConfig
+	if err := json.Unmarshal(encoded, &channels); err != nil {
+		return nil, fmt.Errorf("通知渠道格式错误: %w", err)
+	}
+
+	for i := range channels {
+		if err := channels[i].Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return channels, nil
+}
+
+// NewChannel 根据配置创建通知渠道
+func NewChannel(cfg ChannelConfig, smtpConfig SMTPConfig) (Channel, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: 15 * time.Second}
+
+	switch cfg.Type {
+	case TypeWebhook:
+		return &WebhookChannel{URL: cfg.URL, Headers: cfg.Headers, client: client}, nil
+	case TypeFeishu:
+		return &FeishuChannel{URL: cfg.URL, Secret: cfg.Secret, client: client}, nil
+	case TypeDingTalk:
+		return &DingTalkChannel{URL: cfg.URL, Secret: cfg.Secret, client: client}, nil
+	case TypeSlack:
+		return &SlackChannel{URL: cfg.URL, client: client}, nil
+	case TypeEmail:
+		if smtpConfig.Host == "" {
+			return nil, fmt.Errorf("未配置SMTP服务器（SMTP_HOST）")
+		}
+		return &EmailChannel{SMTP: smtpConfig, To: cfg.To}, nil
+	}
+
+	return nil, fmt.Errorf("未知的通知渠道类型 %s", cfg.Type)
+}
@@ -0,0 +1,133 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// AlertItem 告警中的单条变化
+type AlertItem struct {
+	Competitor  string    `json:"competitor"`
+	ChangeType  string    `json:"change_type"`
+	FieldName   string    `json:"field_name"`
+	OldValue    string    `json:"old_value"`
+	NewValue    string    `json:"new_value"`
+	ImpactLevel string    `json:"impact_level"`
+	RuleName    string    `json:"rule_name"`
+	Message     string    `json:"message"`
+	DetectedAt  time.Time `json:"detected_at"`
+}
+
+// AlertData 监控告警模板数据
+type AlertData struct {
+	MonitorID uint        `json:"monitor_id"`
+	Monitor   string      `json:"monitor"`
+	Alerts    []AlertItem `json:"alerts"`
+	Time      time.Time   `json:"time"`
+}
+
+// TaskData 任务结束模板数据
+type TaskData struct {
+	TaskID           uint      `json:"task_id"`
+	Topic            string    `json:"topic"`
+	Status           string    `json:"status"` // completed/failed/cancelled
+	Competitors      []string  `json:"competitors"`
+	CompetitorsFound int       `json:"competitors_found"`
+	SourcesFound     int       `json:"sources_found"`
+	ReportPath       string    `json:"report_path,omitempty"`
+	Error            string    `json:"error,omitempty"`
+	Time             time.Time `json:"time"`
+}
+
+// StatusText 任务状态的中文描述
+func (d TaskData) StatusText() string {
+	switch d.Status {
+	case "completed":
+		return "已完成"
+	case "failed":
+		return "失败"
+	case "cancelled":
+		return "已取消"
+	}
+	return d.Status
+}
+
+// 默认模板
+var defaultTemplates = map[string][2]string{
+	EventAlert: {
+		`竞品监控告警：{{.Monitor}}（{{len .Alerts}}条）`,
+		`{{range .Alerts}}- [{{.ImpactLevel}}] {{.Message}}{{if .RuleName}}（规则：{{.RuleName}}）{{end}}
+{{end}}
+时间：{{.Time.Format "2006-01-02 15:04"}}`,
+	},
+	EventTaskFinished: {
+		`任务{{.StatusText}}：{{.Topic}}（#{{.TaskID}}）`,
+		`状态：{{.StatusText}}
+{{- if .Competitors}}
+竞品：{{join .Competitors "、"}}{{end}}
+{{- if .SourcesFound}}
+数据源：{{.SourcesFound}}个{{end}}
+{{- if .ReportPath}}
+报告：{{.ReportPath}}{{end}}
+{{- if .Error}}
+错误：{{.Error}}{{end}}
+时间：{{.Time.Format "2006-01-02 15:04"}}`,
+	},
+}
+
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+	"truncate": func(n int, s string) string {
+		runes := []rune(s)
+		if len(runes) <= n {
+			return s
+		}
+		return string(runes[:n]) + "..."
+	},
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Parse(text)
+}
+
+// Render 用渠道的自定义模板（未设置时用默认模板）渲染通知消息
+func Render(event string, cfg ChannelConfig, data interface{}) (*Message, error) {
+	defaults, ok := defaultTemplates[event]
+	if !ok {
+		return nil, fmt.Errorf("未知的通知事件: %s", event)
+	}
+
+	titleText, bodyText := defaults[0], defaults[1]
+	if cfg.TitleTemplate != "" {
+		titleText = cfg.TitleTemplate
+	}
+	if cfg.BodyTemplate != "" {
+		bodyText = cfg.BodyTemplate
+	}
+
+	title, err := execute("title", titleText, data)
+	if err != nil {
+		return nil, err
+	}
+	body, err := execute("body", bodyText, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Message{Event: event, Title: strings.TrimSpace(title), Body: strings.TrimSpace(body), Data: data}, nil
+}
+
+func execute(name, text string, data interface{}) (string, error) {
+	tmpl, err := parseTemplate(name, text)
+	if err != nil {
+		return "", fmt.Errorf("模板解析失败: %w", err)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("模板渲染失败: %w", err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,178 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// postJSON 发送JSON请求，返回响应内容
+func postJSON(ctx context.Context, client *http.Client, endpoint string, payload interface{}, headers map[string]string) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, redactURLError(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", redactURLError(err))
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("webhook返回错误: %d - %s", resp.StatusCode, string(respBody))
+	}
+	return respBody, nil
+}
+
+// redactURLError 隐去请求错误中webhook地址的令牌：错误会写入发送记录并通过API返回
+func redactURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = redactURL(urlErr.URL)
+	}
+	return err
+}
+
+// WebhookChannel 通用JSON webhook，发送完整的消息结构
+type WebhookChannel struct {
+	URL     string
+	Headers map[string]string
+	client  *http.Client
+}
+
+func (c *WebhookChannel) Type() string { return TypeWebhook }
+
+func (c *WebhookChannel) Send(ctx context.Context, msg *Message) error {
+	_, err := postJSON(ctx, c.client, c.URL, msg, c.Headers)
+	return err
+}
+
+// FeishuChannel 飞书群机器人
+type FeishuChannel struct {
+	URL    string
+	Secret string
+	client *http.Client
+}
+
+func (c *FeishuChannel) Type() string { return TypeFeishu }
+
+func (c *FeishuChannel) Send(ctx context.Context, msg *Message) error {
+	payload := map[string]interface{}{
+		"msg_type": "text",
+		"content": map[string]string{
+			"text": msg.Title + "\n\n" + msg.Body,
+		},
+	}
+
+	// 开启签名校验时，签名为以"timestamp\nsecret"为密钥对空串做HmacSHA256
+	if c.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(timestamp+"\n"+c.Secret))
+		payload["timestamp"] = timestamp
+		payload["sign"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	respBody, err := postJSON(ctx, c.client, c.URL, payload, nil)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if json.Unmarshal(respBody, &result) == nil && result.Code != 0 {
+		return fmt.Errorf("飞书返回错误: %d - %s", result.Code, result.Msg)
+	}
+	return nil
+}
+
+// DingTalkChannel 钉钉群机器人（Markdown消息）
+type DingTalkChannel struct {
+	URL    string
+	Secret string
+	client *http.Client
+}
+
+func (c *DingTalkChannel) Type() string { return TypeDingTalk }
+
+func (c *DingTalkChannel) Send(ctx context.Context, msg *Message) error {
+	endpoint := c.URL
+
+	// 开启加签时，签名为以secret为密钥对"timestamp\nsecret"做HmacSHA256，附加在URL上
+	if c.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		mac := hmac.New(sha256.New, []byte(c.Secret))
+		mac.Write([]byte(timestamp + "\n" + c.Secret))
+		sign := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return fmt.Errorf("无效的钉钉webhook地址: %w", redactURLError(err))
+		}
+		q := u.Query()
+		q.Set("timestamp", timestamp)
+		q.Set("sign", sign)
+		u.RawQuery = q.Encode()
+		endpoint = u.String()
+	}
+
+	payload := map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"title": msg.Title,
+			"text":  "### " + msg.Title + "\n\n" + msg.Body,
+		},
+	}
+
+	respBody, err := postJSON(ctx, c.client, endpoint, payload, nil)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if json.Unmarshal(respBody, &result) == nil && result.ErrCode != 0 {
+		return fmt.Errorf("钉钉返回错误: %d - %s", result.ErrCode, result.ErrMsg)
+	}
+	return nil
+}
+
+// SlackChannel Slack incoming webhook
+type SlackChannel struct {
+	URL    string
+	client *http.Client
+}
+
+func (c *SlackChannel) Type() string { return TypeSlack }
+
+func (c *SlackChannel) Send(ctx context.Context, msg *Message) error {
+	payload := map[string]string{
+		"text": "*" + msg.Title + "*\n" + msg.Body,
+	}
+	_, err := postJSON(ctx, c.client, c.URL, payload, nil)
+	return err
+}
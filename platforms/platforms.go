@@ -0,0 +1,155 @@
+// Package platforms 平台配置：按域名识别内容平台，提供爬取时使用的User-Agent、Referer、爬虫顺序、
+// 正文选择器，以及链接分类使用的类型和评分。新增平台只需修改配置文件（PLATFORMS_PATH），无需改代码
+package platforms
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 内置的平台配置，PLATFORMS_PATH 指定的文件格式与之相同
+//
+//go:embed platforms.json
+var defaultPlatforms []byte
+
+// Platform 一个内容平台的爬取和分类配置
+type Platform struct {
+	Name             string    `json:"name"`
+	Hosts            []string  `json:"hosts"`                       // 域名，同时匹配其子域名，如 zhihu.com 匹配 www.zhihu.com
+	UserAgent        string    `json:"user_agent"`                  // 为空时使用默认平台的User-Agent
+	Referer          string    `json:"referer,omitempty"`           // 请求页面和下载图片时的Referer（防盗链）
+	Crawlers         []string  `json:"crawlers,omitempty"`          // 依次尝试的爬虫（firecrawl/jina/native/session），为空时按默认顺序尝试
+	NeedsLogin       bool      `json:"needs_login"`                 // 需要登录才能看到完整内容
+	ContentSelectors []string  `json:"content_selectors,omitempty"` // 正文区域的CSS选择器，依次尝试，都匹配不到时自动定位正文
+	Category         *Category `json:"category,omitempty"`          // 该平台链接的分类，为空时按URL和标题分类
+}
+
+// Category 平台链接的分类
+type Category struct {
+	Type     string  `json:"type"`     // 用户评价/电商/社交媒体等
+	Priority int     `json:"priority"` // 优先级 1-4
+	Score    float64 `json:"score"`    // 质量评分 0-10
+}
+
+// Registry 平台配置集合，nil时使用内置配置
+type Registry struct {
+	Default   *Platform   `json:"default"` // 不属于任何平台的普通网站
+	Platforms []*Platform `json:"platforms"`
+}
+
+// Load 加载平台配置，path为空时使用内置配置
+func Load(path string) (*Registry, error) {
+	data := defaultPlatforms
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取平台配置失败: %w", err)
+		}
+		data = content
+	}
+
+	var registry Registry
+	if err := json.Unmarshal(data, &registry); err != nil {
+		return nil, fmt.Errorf("解析平台配置失败: %w", err)
+	}
+	if registry.Default == nil || registry.Default.Name == "" || registry.Default.UserAgent == "" {
+		return nil, fmt.Errorf("平台配置缺少默认平台的名称或User-Agent")
+	}
+	normalize(registry.Default)
+
+	for i, platform := range registry.Platforms {
+		if platform == nil || platform.Name == "" {
+			return nil, fmt.Errorf("第 %d 个平台缺少名称", i+1)
+		}
+		if len(platform.Hosts) == 0 {
+			return nil, fmt.Errorf("平台 %s 缺少域名", platform.Name)
+		}
+		if platform.UserAgent == "" {
+			platform.UserAgent = registry.Default.UserAgent
+		}
+		normalize(platform)
+	}
+	return &registry, nil
+}
+
+// normalize 域名和爬虫名称统一为小写
+func normalize(platform *Platform) {
+	for i, host := range platform.Hosts {
+		platform.Hosts[i] = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(host)), "www.")
+	}
+	for i, name := range platform.Crawlers {
+		platform.Crawlers[i] = strings.ToLower(strings.TrimSpace(name))
+	}
+}
+
+var (
+	builtinOnce     sync.Once
+	builtinRegistry *Registry
+)
+
+// Builtin 内置配置（内置文件有误时直接panic）
+func Builtin() *Registry {
+	builtinOnce.Do(func() {
+		registry, err := Load("")
+		if err != nil {
+			panic(err)
+		}
+		builtinRegistry = registry
+	})
+	return builtinRegistry
+}
+
+// Identify 识别URL所属的平台，不属于任何平台时返回默认平台
+func (r *Registry) Identify(rawURL string) (*Platform, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("无效的URL: %w", err)
+	}
+	if r == nil {
+		r = Builtin()
+	}
+	if platform := r.Lookup(parsedURL.Hostname()); platform != nil {
+		return platform, nil
+	}
+	return r.Default, nil
+}
+
+// ByName 按名称查找平台（不区分大小写），不存在时返回nil
+func (r *Registry) ByName(name string) *Platform {
+	if r == nil {
+		r = Builtin()
+	}
+	for _, platform := range r.Platforms {
+		if strings.EqualFold(platform.Name, name) {
+			return platform
+		}
+	}
+	return nil
+}
+
+// Lookup 按主机名查找平台，多个平台匹配时取域名最长（最具体）的，没有匹配时返回nil
+func (r *Registry) Lookup(host string) *Platform {
+	if r == nil {
+		r = Builtin()
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" {
+		return nil
+	}
+
+	var best *Platform
+	bestLength := 0
+	for _, platform := range r.Platforms {
+		for _, pattern := range platform.Hosts {
+			if (host == pattern || strings.HasSuffix(host, "."+pattern)) && len(pattern) > bestLength {
+				best, bestLength = platform, len(pattern)
+			}
+		}
+	}
+	return best
+}
@@ -0,0 +1,181 @@
+// Package pubdate 从搜索摘要、网页元数据和正文中解析发布时间，
+// 支持ISO/RFC格式、中文日期（2024年3月5日）、英文日期（Mar 5, 2024）和相对时间（3天前、2 days ago）
+package pubdate
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 元数据中常见的完整日期格式
+var layouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05.000Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	time.RFC1123,
+	time.RFC1123Z,
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"20060102",
+}
+
+var monthNames = map[string]time.Month{
+	"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+	"may": time.May, "jun": time.June, "jul": time.July, "aug": time.August,
+	"sep": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+}
+
+const monthPattern = `(jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?`
+
+var (
+	chineseDate      = regexp.MustCompile(`(\d{4})\s*年\s*(\d{1,2})\s*月\s*(\d{1,2})\s*[日号]`)
+	chineseMonth     = regexp.MustCompile(`(\d{4})\s*年\s*(\d{1,2})\s*月`)
+	chineseShortDate = regexp.MustCompile(`(?:^|[^\d年])(\d{1,2})\s*月\s*(\d{1,2})\s*[日号]`)
+	numericDate      = regexp.MustCompile(`(?:^|\D)(\d{4})[-/.](\d{1,2})[-/.](\d{1,2})(?:\D|$)`)
+	englishMonthDay  = regexp.MustCompile(`(?i)\b` + monthPattern + `\s+(\d{1,2})(?:st|nd|rd|th)?,?\s+(\d{4})\b`)
+	englishDayMonth  = regexp.MustCompile(`(?i)\b(\d{1,2})(?:st|nd|rd|th)?\s+` + monthPattern + `,?\s+(\d{4})\b`)
+	chineseRelative  = regexp.MustCompile(`(\d+)\s*(分钟|小时|天|日|周|星期|个月|月|年)前`)
+	englishRelative  = regexp.MustCompile(`(?i)\b(\d+|an?)\s+(minute|min|hour|hr|day|week|month|year)s?\s+ago\b`)
+	relativeWords    = regexp.MustCompile(`(?i)(刚刚|昨天|前天|\byesterday\b|\bjust now\b)`)
+)
+
+// Parse 解析元数据中的日期值（整个字符串是一个日期），不符合完整格式时在文本中查找
+func Parse(value string, now time.Time) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			t = validate(t, now)
+			return t, !t.IsZero()
+		}
+	}
+	return Find(value, now)
+}
+
+// Find 在文本中查找第一个日期（按出现位置），包括相对时间
+func Find(text string, now time.Time) (time.Time, bool) {
+	best, bestIndex := time.Time{}, -1
+	// 解析失败的候选为零值
+	consider := func(index int, t time.Time) {
+		if t.IsZero() || index < 0 {
+			return
+		}
+		if bestIndex < 0 || index < bestIndex {
+			best, bestIndex = t, index
+		}
+	}
+
+	if m := chineseDate.FindStringSubmatchIndex(text); m != nil {
+		consider(m[0], date(text, m, 2, 4, 6, now))
+	} else if m := chineseMonth.FindStringSubmatchIndex(text); m != nil {
+		year, _ := strconv.Atoi(text[m[2]:m[3]])
+		month, _ := strconv.Atoi(text[m[4]:m[5]])
+		consider(m[0], makeDate(year, month, 1, now))
+	}
+	if m := numericDate.FindStringSubmatchIndex(text); m != nil {
+		consider(m[2], date(text, m, 2, 4, 6, now))
+	}
+	if m := englishMonthDay.FindStringSubmatchIndex(text); m != nil {
+		day, _ := strconv.Atoi(text[m[4]:m[5]])
+		year, _ := strconv.Atoi(text[m[6]:m[7]])
+		consider(m[0], makeDate(year, int(monthNames[strings.ToLower(text[m[2]:m[3]])]), day, now))
+	}
+	if m := englishDayMonth.FindStringSubmatchIndex(text); m != nil {
+		day, _ := strconv.Atoi(text[m[2]:m[3]])
+		year, _ := strconv.Atoi(text[m[6]:m[7]])
+		consider(m[0], makeDate(year, int(monthNames[strings.ToLower(text[m[4]:m[5]])]), day, now))
+	}
+	if m := chineseShortDate.FindStringSubmatchIndex(text); m != nil && bestIndex < 0 {
+		// 没有年份时按最近的一个这样的日期
+		month, _ := strconv.Atoi(text[m[2]:m[3]])
+		day, _ := strconv.Atoi(text[m[4]:m[5]])
+		t := makeDate(now.Year(), month, day, now)
+		if t.IsZero() {
+			t = makeDate(now.Year()-1, month, day, now)
+		}
+		consider(m[2], t)
+	}
+	if m := chineseRelative.FindStringSubmatchIndex(text); m != nil {
+		n, _ := strconv.Atoi(text[m[2]:m[3]])
+		consider(m[0], relative(n, text[m[4]:m[5]], now))
+	}
+	if m := englishRelative.FindStringSubmatchIndex(text); m != nil {
+		n, err := strconv.Atoi(text[m[2]:m[3]])
+		if err != nil {
+			n = 1 // a/an
+		}
+		consider(m[0], relative(n, strings.ToLower(text[m[4]:m[5]]), now))
+	}
+	if m := relativeWords.FindStringSubmatchIndex(text); m != nil {
+		days := 0
+		switch strings.ToLower(text[m[2]:m[3]]) {
+		case "昨天", "yesterday":
+			days = 1
+		case "前天":
+			days = 2
+		}
+		consider(m[0], now.AddDate(0, 0, -days))
+	}
+
+	return best, bestIndex >= 0
+}
+
+// FromSnippet 从搜索结果摘要开头查找发布时间（搜索引擎通常把日期放在摘要开头，如"2024年3月5日 — ..."）
+func FromSnippet(snippet string, now time.Time) (time.Time, bool) {
+	if runes := []rune(snippet); len(runes) > 40 {
+		snippet = string(runes[:40])
+	}
+	return Find(snippet, now)
+}
+
+func date(text string, m []int, yearGroup, monthGroup, dayGroup int, now time.Time) time.Time {
+	year, _ := strconv.Atoi(text[m[yearGroup]:m[yearGroup+1]])
+	month, _ := strconv.Atoi(text[m[monthGroup]:m[monthGroup+1]])
+	day, _ := strconv.Atoi(text[m[dayGroup]:m[dayGroup+1]])
+	return makeDate(year, month, day, now)
+}
+
+func makeDate(year, month, day int, now time.Time) time.Time {
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}
+	}
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, now.Location())
+	if t.Day() != day {
+		return time.Time{} // 如2月30日
+	}
+	return validate(t, now)
+}
+
+func relative(n int, unit string, now time.Time) time.Time {
+	switch unit {
+	case "分钟", "minute", "min":
+		return now.Add(-time.Duration(n) * time.Minute)
+	case "小时", "hour", "hr":
+		return now.Add(-time.Duration(n) * time.Hour)
+	case "天", "日", "day":
+		return now.AddDate(0, 0, -n)
+	case "周", "星期", "week":
+		return now.AddDate(0, 0, -7*n)
+	case "个月", "月", "month":
+		return now.AddDate(0, -n, 0)
+	case "年", "year":
+		return now.AddDate(-n, 0, 0)
+	}
+	return time.Time{}
+}
+
+// validate 排除明显不是发布时间的日期（早于1995年或晚于当前时间一天以上），返回零值
+func validate(t time.Time, now time.Time) time.Time {
+	if t.Year() < 1995 || t.After(now.Add(24*time.Hour)) {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,264 @@
+// Package sessions 平台登录会话：运维上传的Cookie和请求头按平台加密保存在PlatformSession表中，
+// 供爬虫爬取需要登录的平台；使用会话后被跳转到登录页面或连续多次遇到登录提示时标记为失效，重新上传后恢复
+package sessions
+
+import (
+	"competitive-analyzer/crawler"
+	"competitive-analyzer/models"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrNoKey 未配置加密密钥，不能保存和使用登录会话
+var ErrNoKey = errors.New("未配置SESSION_ENCRYPTION_KEY，不能保存登录会话")
+
+// 会话状态
+const (
+	StatusActive  = "active"  // 可用
+	StatusExpired = "expired" // 使用后被跳转到登录页面、连续遇到登录提示或Cookie已过期，需要重新上传
+)
+
+// maxLoginWalls 连续遇到登录提示达到该次数时标记会话失效；单个页面需要更高权限时不会使会话失效
+const maxLoginWalls = 3
+
+// Cookie 上传的一个Cookie，未指定域名时对平台的所有域名生效
+type Cookie struct {
+	Name     string     `json:"name"`
+	Value    string     `json:"value"`
+	Domain   string     `json:"domain,omitempty"`
+	Path     string     `json:"path,omitempty"`
+	Expires  *time.Time `json:"expires,omitempty"`
+	Secure   bool       `json:"secure,omitempty"`
+	HTTPOnly bool       `json:"http_only,omitempty"`
+}
+
+// Credentials 一个平台的会话内容（加密保存）
+type Credentials struct {
+	Cookies []Cookie          `json:"cookies"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// ParseCookieHeader 解析从浏览器复制的Cookie请求头（如 "a=1; b=2"）
+func ParseCookieHeader(header string) ([]Cookie, error) {
+	header = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(header), "Cookie:"))
+	if header == "" {
+		return nil, nil
+	}
+	parsed, err := http.ParseCookie(header)
+	if err != nil {
+		return nil, fmt.Errorf("解析Cookie失败: %w", err)
+	}
+	cookies := make([]Cookie, len(parsed))
+	for i, cookie := range parsed {
+		cookies[i] = Cookie{Name: cookie.Name, Value: cookie.Value}
+	}
+	return cookies, nil
+}
+
+// Store 加密保存的平台登录会话，实现crawler.SessionStore
+type Store struct {
+	db   *gorm.DB
+	aead cipher.AEAD
+}
+
+// NewStore 创建会话存储，secret为加密密钥（经SHA-256派生为AES-256密钥），为空时返回ErrNoKey
+func NewStore(db *gorm.DB, secret string) (*Store, error) {
+	if secret == "" {
+		return nil, ErrNoKey
+	}
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db, aead: aead}, nil
+}
+
+// Save 保存（覆盖）平台的会话，状态重置为可用
+func (s *Store) Save(platform string, credentials Credentials, note string) (*models.PlatformSession, error) {
+	if len(credentials.Cookies) == 0 && len(credentials.Headers) == 0 {
+		return nil, errors.New("会话中没有Cookie或请求头")
+	}
+	for _, cookie := range credentials.Cookies {
+		if cookie.Name == "" {
+			return nil, errors.New("Cookie缺少名称")
+		}
+	}
+
+	encrypted, err := s.encrypt(credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	var session models.PlatformSession
+	if err := s.db.Where("platform = ?", platform).Limit(1).Find(&session).Error; err != nil {
+		return nil, err
+	}
+	session.Platform = platform
+	session.Credentials = encrypted
+	session.CookieCount = len(credentials.Cookies)
+	session.HeaderNames = headerNames(credentials.Headers)
+	session.Note = note
+	session.Status = StatusActive
+	session.LoginWallCount = 0
+	session.CookiesExpireAt = earliestExpiry(credentials.Cookies)
+	session.ExpiredAt = nil
+	session.LastError = ""
+	if err := s.db.Save(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Delete 删除平台的会话，不存在时返回false
+func (s *Store) Delete(platform string) (bool, error) {
+	result := s.db.Where("platform = ?", platform).Delete(&models.PlatformSession{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// List 所有会话（不含Cookie和请求头的内容），按平台名称排序
+func (s *Store) List() ([]models.PlatformSession, error) {
+	var sessions []models.PlatformSession
+	err := s.db.Order("platform").Find(&sessions).Error
+	return sessions, err
+}
+
+// Session 平台当前可用的会话，没有或已失效时返回nil；Cookie已过期的会话标记为失效
+func (s *Store) Session(ctx context.Context, platform string) (*crawler.Session, error) {
+	var session models.PlatformSession
+	result := s.db.WithContext(ctx).Where("platform = ? AND status = ?", platform, StatusActive).Limit(1).Find(&session)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	// 有Cookie已过期时站点不会再认这个会话，标记失效，等待重新上传
+	if session.CookiesExpireAt != nil && session.CookiesExpireAt.Before(time.Now()) {
+		s.ReportExpired(platform, "Cookie已于"+session.CookiesExpireAt.Format(time.RFC3339)+"过期")
+		return nil, nil
+	}
+
+	credentials, err := s.decrypt(session.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	s.db.Model(&session).Update("last_used_at", time.Now())
+
+	cookies := make([]*http.Cookie, len(credentials.Cookies))
+	for i, cookie := range credentials.Cookies {
+		cookies[i] = &http.Cookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Domain:   cookie.Domain,
+			Path:     cookie.Path,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HTTPOnly,
+		}
+		if cookie.Expires != nil {
+			cookies[i].Expires = *cookie.Expires
+		}
+	}
+	return &crawler.Session{Cookies: cookies, Headers: credentials.Headers}, nil
+}
+
+// ReportSuccess 记录一次使用会话爬取成功，连续登录提示的次数清零
+func (s *Store) ReportSuccess(platform string) {
+	s.db.Model(&models.PlatformSession{}).Where("platform = ? AND status = ?", platform, StatusActive).Updates(map[string]interface{}{
+		"success_count":    gorm.Expr("success_count + 1"),
+		"login_wall_count": 0,
+		"last_success_at":  time.Now(),
+	})
+}
+
+// ReportLoginWall 使用会话后页面中仍有登录提示：记录错误，会话保持可用；连续达到maxLoginWalls次时标记失效
+func (s *Store) ReportLoginWall(platform, reason string) {
+	s.db.Model(&models.PlatformSession{}).Where("platform = ? AND status = ?", platform, StatusActive).Updates(map[string]interface{}{
+		"login_wall_count": gorm.Expr("login_wall_count + 1"),
+		"last_error":       reason,
+	})
+	s.db.Model(&models.PlatformSession{}).Where("platform = ? AND status = ? AND login_wall_count >= ?", platform, StatusActive, maxLoginWalls).Updates(map[string]interface{}{
+		"status":     StatusExpired,
+		"expired_at": time.Now(),
+	})
+}
+
+// ReportExpired 使用会话仍被跳转到登录页面，标记会话失效，不再使用直到重新上传
+func (s *Store) ReportExpired(platform, reason string) {
+	s.db.Model(&models.PlatformSession{}).Where("platform = ? AND status = ?", platform, StatusActive).Updates(map[string]interface{}{
+		"status":     StatusExpired,
+		"expired_at": time.Now(),
+		"last_error": reason,
+	})
+}
+
+// encrypt 序列化并加密会话内容，结果为base64(nonce + 密文)
+func (s *Store) encrypt(credentials Credentials) (string, error) {
+	plaintext, err := json.Marshal(credentials)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(s.aead.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+// decrypt 解密会话内容，密钥变更后无法解密，需要重新上传
+func (s *Store) decrypt(encrypted string) (*Credentials, error) {
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil || len(data) < s.aead.NonceSize() {
+		return nil, errors.New("会话数据已损坏")
+	}
+	nonce, ciphertext := data[:s.aead.NonceSize()], data[s.aead.NonceSize():]
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("解密会话失败，密钥可能已变更，请重新上传")
+	}
+
+	var credentials Credentials
+	if err := json.Unmarshal(plaintext, &credentials); err != nil {
+		return nil, err
+	}
+	return &credentials, nil
+}
+
+// headerNames 请求头名称，用于展示（不含值）
+func headerNames(headers map[string]string) string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// earliestExpiry Cookie中最早的过期时间，都没有设置时返回nil
+func earliestExpiry(cookies []Cookie) *time.Time {
+	var earliest *time.Time
+	for _, cookie := range cookies {
+		if cookie.Expires != nil && (earliest == nil || cookie.Expires.Before(*earliest)) {
+			expires := *cookie.Expires
+			earliest = &expires
+		}
+	}
+	return earliest
+}